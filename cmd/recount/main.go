@@ -0,0 +1,48 @@
+// Command recount rebuilds the denormalized counters (post reactions,
+// comments, views and last activity, comment replies and reactions) from
+// their source tables, then the post scores built on them. Triggers keep
+// them in sync during normal operation; run this after editing the database
+// by hand or restoring a partial backup:
+//
+//	go run ./cmd/recount
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	mydb "real-time-forum/internal/db"
+	"real-time-forum/internal/models"
+)
+
+func main() {
+	// Same database resolution as cmd/server.
+	dsn := "forum.db"
+	if v := os.Getenv("DATABASE_PATH"); v != "" {
+		dsn = v
+	}
+
+	db, err := mydb.Open(dsn)
+	if err != nil {
+		log.Fatalf("error opening DB: %v", err)
+	}
+	defer db.Close()
+
+	// Make sure the counter columns and triggers exist before recounting.
+	if err := mydb.RunMigrations(db); err != nil {
+		log.Fatalf("error running migrations: %v", err)
+	}
+
+	fixed, err := mydb.RepairCounters(context.Background(), db)
+	if err != nil {
+		log.Fatalf("error repairing counters: %v", err)
+	}
+	log.Printf("[RECOUNT] repaired counters on %d row(s)", fixed)
+
+	// The hot, top and discussed feed sorts read post_scores.
+	if err := (&models.PostModel{DB: db}).RefreshScores(context.Background()); err != nil {
+		log.Fatalf("error refreshing scores: %v", err)
+	}
+	log.Println("[RECOUNT] refreshed post scores")
+}
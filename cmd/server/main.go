@@ -1,15 +1,35 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	mydb "real-time-forum/internal/db"
 	httpserver "real-time-forum/internal/http"
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/storage"
 	"real-time-forum/internal/ws"
 )
 
+// scoreRefreshInterval is how often cached feed scores (hot/top/discussed) are recomputed.
+const scoreRefreshInterval = time.Minute
+
+// publishInterval is how often scheduled posts are checked for publication.
+const publishInterval = 30 * time.Second
+
+// chatEventRetention is how long chat events are kept for reconnect replay;
+// pruneInterval is how often older ones are deleted.
+const (
+	chatEventRetention = 7 * 24 * time.Hour
+	pruneInterval      = time.Hour
+)
+
 func main() {
 	// Determine database path (environment overrides default).
 	dsn := "forum.db"
@@ -29,12 +49,51 @@ func main() {
 		log.Fatalf("error running migrations: %v", err)
 	}
 
+	// Promote moderators listed by nickname (comma-separated).
+	if v := os.Getenv("MODERATORS"); v != "" {
+		users := &models.UserModel{DB: db}
+		for _, nick := range strings.Split(v, ",") {
+			nick = strings.TrimSpace(nick)
+			if nick == "" {
+				continue
+			}
+			if err := users.SetRoleByNickname(context.Background(), nick, models.RoleModerator); err != nil {
+				log.Printf("[MODERATORS] cannot promote %q: %v", nick, err)
+			}
+		}
+	}
+
+	// Keep feed scores fresh in the background.
+	go refreshScores(db, scoreRefreshInterval)
+
+	// Forget chat events too old to be worth replaying.
+	go pruneChatEvents(db, pruneInterval)
+
 	// Create and start the WebSocket hub for real-time messaging.
 	hub := ws.NewHub()
+	hub.Limits = wsLimits()
 	go hub.Run()
 
 	// Create the HTTP server with all dependencies.
-	server := httpserver.NewServer(db, hub)
+	cfg := httpserver.DefaultConfig()
+	if v := os.Getenv("ALLOWED_REACTIONS"); v != "" {
+		cfg.Reactions = models.ParseReactions(v)
+	}
+	if v := os.Getenv("UPLOAD_DIR"); v != "" {
+		cfg.Storage = storage.NewLocal(v)
+	}
+	if v := os.Getenv("EDIT_WINDOW"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("invalid EDIT_WINDOW %q: want a duration such as 24h, or 0 for no limit", v)
+		}
+		cfg.EditWindow = d
+	}
+	server := httpserver.NewServerWithConfig(db, hub, cfg)
+
+	// Publish scheduled posts once their publish_at has passed, and tell
+	// live feed subscribers about them.
+	go publishScheduled(db, publishInterval, server.AnnouncePublished)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -47,3 +106,84 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// wsLimits reads the WebSocket keepalive and size limits, falling back to
+// ws.DefaultLimits for anything not set.
+func wsLimits() ws.Limits {
+	limits := ws.DefaultLimits()
+	for name, d := range map[string]*time.Duration{
+		"WS_PING_INTERVAL": &limits.PingInterval,
+		"WS_PONG_WAIT":     &limits.PongWait,
+		"WS_WRITE_WAIT":    &limits.WriteWait,
+	} {
+		if v := os.Getenv(name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				log.Fatalf("invalid %s %q: want a duration such as 30s", name, v)
+			}
+			*d = parsed
+		}
+	}
+	if v := os.Getenv("WS_MAX_MESSAGE_SIZE"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Fatalf("invalid WS_MAX_MESSAGE_SIZE %q: want a size in bytes", v)
+		}
+		limits.MaxMessageSize = n
+	}
+	if err := limits.Validate(); err != nil {
+		log.Fatalf("invalid WebSocket limits: %v", err)
+	}
+	return limits
+}
+
+// refreshScores recomputes post scores now and then on every tick.
+func refreshScores(db *sql.DB, every time.Duration) {
+	posts := &models.PostModel{DB: db}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if err := posts.RefreshScores(context.Background()); err != nil {
+			log.Println("[SCORES] refresh error:", err)
+		}
+		<-ticker.C
+	}
+}
+
+// publishScheduled publishes due scheduled posts now and then on every tick,
+// passing their IDs to announce.
+func publishScheduled(db *sql.DB, every time.Duration, announce func(context.Context, []int64)) {
+	posts := &models.PostModel{DB: db}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		ids, err := posts.PublishDue(context.Background(), time.Now())
+		if err != nil {
+			log.Println("[SCHEDULER] publish error:", err)
+		} else if len(ids) > 0 {
+			log.Printf("[SCHEDULER] published %d post(s): %v", len(ids), ids)
+			announce(context.Background(), ids)
+		}
+		<-ticker.C
+	}
+}
+
+// pruneChatEvents drops chat events older than chatEventRetention now and
+// then on every tick.
+func pruneChatEvents(db *sql.DB, every time.Duration) {
+	events := &models.ChatEventModel{DB: db}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		if err := events.Prune(context.Background(), time.Now().Add(-chatEventRetention)); err != nil {
+			log.Println("[CHAT EVENTS] prune error:", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// counterTriggers keep the denormalized counters on posts and comments in
+// step with their source tables, so feed queries read plain columns instead
+// of running a COUNT(*) per row. INSERT OR IGNORE that ignores a row fires
+// no trigger.
+//
+// Deleting a comment moves last_activity_at back to the newest remaining
+// comment, or to the post's creation when none is left.
+//
+// reactions_count counts every stored reaction, including types since
+// dropped from the allowed set; it only feeds ranking. What the API shows
+// is recomputed from the allowed types (ReactionModel.Attach).
+var counterTriggers = []struct {
+	name string
+	stmt string
+}{
+	{"post_reactions_count_ai", `CREATE TRIGGER IF NOT EXISTS post_reactions_count_ai AFTER INSERT ON post_reactions BEGIN
+		UPDATE posts SET reactions_count = reactions_count + 1 WHERE id = new.post_id;
+	END;`},
+	{"post_reactions_count_ad", `CREATE TRIGGER IF NOT EXISTS post_reactions_count_ad AFTER DELETE ON post_reactions BEGIN
+		UPDATE posts SET reactions_count = reactions_count - 1 WHERE id = old.post_id;
+	END;`},
+	{"comments_count_ai", `CREATE TRIGGER IF NOT EXISTS comments_count_ai AFTER INSERT ON comments BEGIN
+		UPDATE posts SET comments_count = comments_count + 1, last_activity_at = new.created_at WHERE id = new.post_id;
+	END;`},
+	{"comments_count_activity_ad", `CREATE TRIGGER IF NOT EXISTS comments_count_activity_ad AFTER DELETE ON comments BEGIN
+		UPDATE posts SET
+			comments_count = comments_count - 1,
+			last_activity_at = MAX(created_at, COALESCE((SELECT MAX(c.created_at) FROM comments c WHERE c.post_id = old.post_id), created_at))
+		WHERE id = old.post_id;
+	END;`},
+	{"comments_replies_count_ai", `CREATE TRIGGER IF NOT EXISTS comments_replies_count_ai AFTER INSERT ON comments WHEN new.parent_comment_id IS NOT NULL BEGIN
+		UPDATE comments SET replies_count = replies_count + 1 WHERE id = new.parent_comment_id;
+	END;`},
+	{"comments_replies_count_ad", `CREATE TRIGGER IF NOT EXISTS comments_replies_count_ad AFTER DELETE ON comments WHEN old.parent_comment_id IS NOT NULL BEGIN
+		UPDATE comments SET replies_count = replies_count - 1 WHERE id = old.parent_comment_id;
+	END;`},
+	{"comment_reactions_count_ai", `CREATE TRIGGER IF NOT EXISTS comment_reactions_count_ai AFTER INSERT ON comment_reactions BEGIN
+		UPDATE comments SET reactions_count = reactions_count + 1 WHERE id = new.comment_id;
+	END;`},
+	{"comment_reactions_count_ad", `CREATE TRIGGER IF NOT EXISTS comment_reactions_count_ad AFTER DELETE ON comment_reactions BEGIN
+		UPDATE comments SET reactions_count = reactions_count - 1 WHERE id = old.comment_id;
+	END;`},
+	{"comment_votes_score_ai", `CREATE TRIGGER IF NOT EXISTS comment_votes_score_ai AFTER INSERT ON comment_votes BEGIN
+		UPDATE comments SET score = score + new.value WHERE id = new.comment_id;
+	END;`},
+	{"comment_votes_score_au", `CREATE TRIGGER IF NOT EXISTS comment_votes_score_au AFTER UPDATE OF value ON comment_votes BEGIN
+		UPDATE comments SET score = score - old.value + new.value WHERE id = new.comment_id;
+	END;`},
+	{"comment_votes_score_ad", `CREATE TRIGGER IF NOT EXISTS comment_votes_score_ad AFTER DELETE ON comment_votes BEGIN
+		UPDATE comments SET score = score - old.value WHERE id = old.comment_id;
+	END;`},
+	{"post_views_count_ai", `CREATE TRIGGER IF NOT EXISTS post_views_count_ai AFTER INSERT ON post_views BEGIN
+		UPDATE posts SET views_count = views_count + 1 WHERE id = new.post_id;
+	END;`},
+}
+
+// retiredTriggers were replaced by a counterTriggers entry under a new name.
+var retiredTriggers = []string{
+	"comments_count_ad", // left last_activity_at on the deleted comment
+}
+
+// runCounterMigrations adds the counter columns and installs their triggers.
+// Counters written before a trigger existed may have drifted, so installing
+// any trigger for the first time is followed by a full recount.
+func runCounterMigrations(db *sql.DB) error {
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN reactions_count INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN replies_count INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN reactions_count INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN score INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	// Q&A threads list answers by score.
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_post_root_score ON comments(post_id, score, id) WHERE parent_comment_id IS NULL;`); err != nil {
+		return err
+	}
+
+	for _, name := range retiredTriggers {
+		if _, err := db.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+			return err
+		}
+	}
+
+	installed := false
+	for _, t := range counterTriggers {
+		exists, err := tableExists(db, t.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(t.stmt); err != nil {
+			return err
+		}
+		installed = true
+	}
+	if !installed {
+		return nil
+	}
+
+	_, err := RepairCounters(context.Background(), db)
+	return err
+}
+
+// RepairCounters recomputes every post's reactions_count, comments_count,
+// views_count and last_activity_at from post_reactions, comments and
+// post_views, and every comment's replies_count, reactions_count and score
+// from its direct replies, comment_reactions and comment_votes. It returns
+// how many rows had drifted. The triggers make this unnecessary in normal
+// operation; it is for databases edited by hand or restored from backups
+// (see cmd/recount). Scores built from the counters are refreshed by
+// PostModel.RefreshScores, which cmd/recount runs next.
+func RepairCounters(ctx context.Context, db *sql.DB) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	posts, err := tx.ExecContext(ctx, `
+		UPDATE posts SET
+			reactions_count = t.reactions,
+			comments_count = t.comments,
+			views_count = t.views,
+			last_activity_at = t.activity
+		FROM (
+			SELECT
+				p.id,
+				(SELECT COUNT(*) FROM post_reactions r WHERE r.post_id = p.id) AS reactions,
+				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id) AS comments,
+				(SELECT COUNT(*) FROM post_views v WHERE v.post_id = p.id) AS views,
+				MAX(p.created_at, COALESCE((SELECT MAX(c.created_at) FROM comments c WHERE c.post_id = p.id), p.created_at)) AS activity
+			FROM posts p
+		) AS t
+		WHERE posts.id = t.id
+		  AND (posts.reactions_count != t.reactions
+		    OR posts.comments_count != t.comments
+		    OR posts.views_count != t.views
+		    OR posts.last_activity_at IS NOT t.activity);
+	`)
+	if err != nil {
+		return 0, err
+	}
+	comments, err := tx.ExecContext(ctx, `
+		UPDATE comments SET
+			replies_count = t.replies,
+			reactions_count = t.reactions,
+			score = t.score
+		FROM (
+			SELECT
+				c.id,
+				(SELECT COUNT(*) FROM comments r WHERE r.parent_comment_id = c.id) AS replies,
+				(SELECT COUNT(*) FROM comment_reactions cr WHERE cr.comment_id = c.id) AS reactions,
+				(SELECT COALESCE(SUM(v.value), 0) FROM comment_votes v WHERE v.comment_id = c.id) AS score
+			FROM comments c
+		) AS t
+		WHERE comments.id = t.id
+		  AND (comments.replies_count != t.replies
+		    OR comments.reactions_count != t.reactions
+		    OR comments.score != t.score);
+	`)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	nPosts, _ := posts.RowsAffected()
+	nComments, _ := comments.RowsAffected()
+	return nPosts + nComments, nil
+}
@@ -2,7 +2,10 @@ package db
 
 import (
 	"database/sql"
+	"log"
 	"strings"
+
+	"real-time-forum/internal/markdown"
 )
 
 func execIgnoreDuplicateColumn(db *sql.DB, stmt string) error {
@@ -17,6 +20,25 @@ func execIgnoreDuplicateColumn(db *sql.DB, stmt string) error {
 	return err
 }
 
+// isMissingModule reports whether err comes from a virtual table module that
+// was not compiled into the SQLite driver (e.g. FTS5 without the sqlite_fts5 tag).
+func isMissingModule(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "no such module")
+}
+
+// columnExists reports whether table has a column called name.
+func columnExists(db *sql.DB, table, name string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, name).Scan(&n)
+	return n > 0, err
+}
+
+func tableExists(db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, name).Scan(&n)
+	return n > 0, err
+}
+
 // RunMigrations applies the database schema required by the application.
 // Each statement is idempotent and safe to execute multiple times.
 func RunMigrations(db *sql.DB) error {
@@ -157,11 +179,388 @@ func RunMigrations(db *sql.DB) error {
 		return err
 	}
 
+	// Feed ordering and keyset pagination on (created_at, id).
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_created_id ON posts(created_at DESC, id DESC);`); err != nil {
+		return err
+	}
+
+	// Posts: publication status ("draft", "scheduled" or "published") and,
+	// for scheduled posts, when the scheduler should publish them.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published';`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN publish_at DATETIME;`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_status_publish_at ON posts(status, publish_at);`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_user_status ON posts(user_id, status);`); err != nil {
+		return err
+	}
+
+	// Posts: moderation flags. pin_scope is NULL (not pinned), 'global' or
+	// 'category'; pinned_at orders pinned posts. Locked posts take no comments.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN pin_scope TEXT;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN pinned_at DATETIME;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_pinned ON posts(pin_scope, pinned_at DESC) WHERE pin_scope IS NOT NULL;`); err != nil {
+		return err
+	}
+
+	// Posts: comment counter and last activity (creation or newest comment),
+	// kept up to date by triggers (see counters.go). The backfill runs once,
+	// for posts that predate the columns.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN comments_count INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN last_activity_at DATETIME;`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`
+		UPDATE posts SET
+			comments_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.id),
+			last_activity_at = MAX(created_at, COALESCE((SELECT MAX(c.created_at) FROM comments c WHERE c.post_id = posts.id), created_at))
+		WHERE last_activity_at IS NULL;
+	`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_posts_activity_id ON posts(last_activity_at DESC, id DESC);`); err != nil {
+		return err
+	}
+
+	// Comments: threading. Root comments have no parent and depth 0; replies
+	// point at their parent and at the root of their thread, so a thread's
+	// replies can be paged with one index.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN parent_comment_id INTEGER REFERENCES comments(id);`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN root_comment_id INTEGER REFERENCES comments(id);`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_root ON comments(root_comment_id, id);`); err != nil {
+		return err
+	}
+	// Root comments are paged by ID (see CommentModel.ListByPost).
+	if _, err := db.Exec(`DROP INDEX IF EXISTS idx_comments_post_roots;`); err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_comments_post_root_id ON comments(post_id, id) WHERE parent_comment_id IS NULL;`); err != nil {
+		return err
+	}
+
+	// Comments: deleted comments that still have replies stay as tombstones,
+	// with their text cleared.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN deleted_at DATETIME;`); err != nil {
+		return err
+	}
+
+	// Comment reactions: same shape and rules as post_reactions.
+	commentReactionStmts := []string{
+		`CREATE TABLE IF NOT EXISTS comment_reactions (
+			comment_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			reaction TEXT NOT NULL DEFAULT 'like',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (comment_id, user_id, reaction),
+			FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_comment_reactions_user ON comment_reactions(user_id);`,
+	}
+	for _, stmt := range commentReactionStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Comment revisions: like post_revisions, but only for edited comments.
+	// Revision 1 is the original text, saved by the first edit.
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS comment_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		comment_id INTEGER NOT NULL,
+		revision INTEGER NOT NULL,
+		editor_id INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (comment_id, revision),
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+		FOREIGN KEY (editor_id) REFERENCES users(id)
+	);`); err != nil {
+		return err
+	}
+
+	// Q&A: up/down votes on comments (comments.score is their sum, see
+	// counters.go) and the answer the post author accepted.
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS comment_votes (
+		comment_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		value INTEGER NOT NULL CHECK (value IN (-1, 1)),
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (comment_id, user_id),
+		FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN accepted_comment_id INTEGER;`); err != nil {
+		return err
+	}
+
+	// Chat event log: each user's message, delivered and seen events,
+	// numbered per user so a reconnecting tab can replay what it missed.
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS chat_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, seq),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`); err != nil {
+		return err
+	}
+
+	// Posts and comments: counter columns and the triggers that maintain them.
+	if err := runCounterMigrations(db); err != nil {
+		return err
+	}
+
+	// Cached feed scores, recomputed periodically by PostModel.RefreshScores.
+	scoreStmts := []string{
+		`CREATE TABLE IF NOT EXISTS post_scores (
+			post_id INTEGER PRIMARY KEY,
+			reactions INTEGER NOT NULL DEFAULT 0,
+			comments INTEGER NOT NULL DEFAULT 0,
+			hot REAL NOT NULL DEFAULT 0,
+			computed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_post_scores_hot ON post_scores(hot DESC, post_id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_post_scores_reactions ON post_scores(reactions DESC, post_id DESC);`,
+		`CREATE INDEX IF NOT EXISTS idx_post_scores_comments ON post_scores(comments DESC, post_id DESC);`,
+		// Posts created before scoring existed get a row; the first refresh fills it in.
+		`INSERT OR IGNORE INTO post_scores (post_id) SELECT id FROM posts;`,
+	}
+	for _, stmt := range scoreStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// stale marks scores whose counters changed since they were computed,
+	// so a refresh can skip old posts nothing has happened to. Rows that
+	// predate the column start out stale and are all rescored once.
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE post_scores ADD COLUMN stale INTEGER NOT NULL DEFAULT 1;`); err != nil {
+		return err
+	}
+	staleStmts := []string{
+		`CREATE INDEX IF NOT EXISTS idx_post_scores_stale ON post_scores(post_id) WHERE stale = 1;`,
+		`CREATE TRIGGER IF NOT EXISTS post_scores_stale_au AFTER UPDATE OF reactions_count, comments_count, views_count ON posts BEGIN
+			UPDATE post_scores SET stale = 1 WHERE post_id = new.id AND stale = 0;
+		END;`,
+	}
+	for _, stmt := range staleStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Post revisions: one snapshot per edit (revision 1 = original post).
+	revisionStmts := []string{
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL,
+			revision INTEGER NOT NULL,
+			editor_id INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			content TEXT NOT NULL,
+			category TEXT NOT NULL,
+			reverted_from INTEGER,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (post_id, revision),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (editor_id) REFERENCES users(id)
+		);`,
+		// Existing posts start their history at their current text.
+		`INSERT INTO post_revisions (post_id, revision, editor_id, title, content, category, created_at)
+		 SELECT p.id, 1, p.user_id, p.title, p.content, p.category, COALESCE(p.edited_at, p.created_at)
+		 FROM posts p
+		 WHERE p.status = 'published'
+		   AND NOT EXISTS (SELECT 1 FROM post_revisions r WHERE r.post_id = p.id);`,
+	}
+	for _, stmt := range revisionStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Attachments: images uploaded to a post. The bytes live in storage
+	// (see package storage) under storage_key; this table holds metadata.
+	attachmentStmts := []string{
+		`CREATE TABLE IF NOT EXISTS attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			storage_key TEXT NOT NULL UNIQUE,
+			filename TEXT NOT NULL DEFAULT '',
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			sha256 TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_attachments_post ON attachments(post_id, id);`,
+	}
+	for _, stmt := range attachmentStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Polls: at most one per post. A user votes once (one option, or a set
+	// of options for multiple choice); poll_votes holds one row per option.
+	pollStmts := []string{
+		`CREATE TABLE IF NOT EXISTS polls (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL UNIQUE,
+			question TEXT NOT NULL,
+			multiple INTEGER NOT NULL DEFAULT 0,
+			anonymous INTEGER NOT NULL DEFAULT 1,
+			closes_at DATETIME,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		);`,
+		`CREATE TABLE IF NOT EXISTS poll_options (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			poll_id INTEGER NOT NULL,
+			position INTEGER NOT NULL,
+			label TEXT NOT NULL,
+			UNIQUE (poll_id, position),
+			FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE
+		);`,
+		`CREATE TABLE IF NOT EXISTS poll_votes (
+			poll_id INTEGER NOT NULL,
+			option_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (poll_id, user_id, option_id),
+			FOREIGN KEY (poll_id) REFERENCES polls(id) ON DELETE CASCADE,
+			FOREIGN KEY (option_id) REFERENCES poll_options(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_poll_votes_option ON poll_votes(option_id);`,
+	}
+	for _, stmt := range pollStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Tags: free-form labels next to the (capped) categories. Names are
+	// stored normalized (see models.NormalizeTag).
+	tagStmts := []string{
+		`CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS post_tags (
+			post_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (post_id, tag_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags(tag_id, post_id);`,
+	}
+	for _, stmt := range tagStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Bookmarks: posts a user saved for later, newest first.
+	bookmarkStmts := []string{
+		`CREATE TABLE IF NOT EXISTS bookmarks (
+			user_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_bookmarks_user_created ON bookmarks(user_id, created_at DESC, post_id DESC);`,
+	}
+	for _, stmt := range bookmarkStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Follows: authors and categories a user wants in their personal feed.
+	followStmts := []string{
+		`CREATE TABLE IF NOT EXISTS user_follows (
+			follower_id INTEGER NOT NULL,
+			followee_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (follower_id, followee_id),
+			CHECK (follower_id != followee_id),
+			FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_user_follows_followee ON user_follows(followee_id);`,
+		`CREATE TABLE IF NOT EXISTS category_follows (
+			user_id INTEGER NOT NULL,
+			category_id INTEGER NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, category_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+			FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+		);`,
+	}
+	for _, stmt := range followStmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Rendered Markdown cache (see package markdown).
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE posts ADD COLUMN content_html TEXT;`); err != nil {
+		return err
+	}
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE comments ADD COLUMN content_html TEXT;`); err != nil {
+		return err
+	}
+	for _, table := range []string{"posts", "comments"} {
+		if err := backfillContentHTML(db, table); err != nil {
+			return err
+		}
+	}
+
 	// Users: last seen timestamp (for sidebar/notifications)
 	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE users ADD COLUMN last_seen_at DATETIME;`); err != nil {
 		return err
 	}
 
+	// Users: role ("user" or "moderator")
+	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';`); err != nil {
+		return err
+	}
+
 	// Sessions created before expiration was introduced remain valid for 30 days.
 	if err := execIgnoreDuplicateColumn(db, `ALTER TABLE sessions ADD COLUMN expires_at DATETIME;`); err != nil {
 		return err
@@ -196,5 +595,153 @@ func RunMigrations(db *sql.DB) error {
 		return err
 	}
 
+	// Categories in Q&A mode sort answers by votes and let the author accept
+	// one. The support categories start in it; moderators can switch any
+	// category later, so this only happens when the column is new.
+	hadQA, err := columnExists(db, "categories", "qa_mode")
+	if err != nil {
+		return err
+	}
+	if !hadQA {
+		if _, err := db.Exec(`ALTER TABLE categories ADD COLUMN qa_mode INTEGER NOT NULL DEFAULT 0;`); err != nil {
+			return err
+		}
+		if _, err := db.Exec(`UPDATE categories SET qa_mode = 1 WHERE name IN ('Tech-support', 'FAQ');`); err != nil {
+			return err
+		}
+	}
+
+	if err := runSearchMigrations(db); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// backfillContentHTML renders content_html for rows written before it existed.
+func backfillContentHTML(db *sql.DB, table string) error {
+	rows, err := db.Query(`SELECT id, content FROM ` + table + ` WHERE content_html IS NULL`)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id      int64
+		content string
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.content); err != nil {
+			rows.Close()
+			return err
+		}
+		todo = append(todo, p)
+	}
+	// Open allows a single connection; release it for the UPDATE
+	// transaction below.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(todo) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, p := range todo {
+		if _, err := tx.Exec(`UPDATE `+table+` SET content_html = ? WHERE id = ?`, markdown.Render(p.content), p.id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// runSearchMigrations creates the FTS5 indexes over posts and comments and the
+// triggers that keep them in sync. Both indexes are external-content tables,
+// so only the tokens are stored; the text itself stays in posts/comments.
+// When the driver was built without FTS5, search is skipped and the rest of
+// the application keeps working.
+func runSearchMigrations(db *sql.DB) error {
+	indexes := []struct {
+		table string
+		stmts []string
+	}{
+		{
+			table: "posts_fts",
+			stmts: []string{
+				`CREATE VIRTUAL TABLE posts_fts USING fts5(
+					title, content,
+					content='posts', content_rowid='id',
+					tokenize='unicode61 remove_diacritics 2'
+				);`,
+				`CREATE TRIGGER IF NOT EXISTS posts_fts_ai AFTER INSERT ON posts BEGIN
+					INSERT INTO posts_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+				END;`,
+				`CREATE TRIGGER IF NOT EXISTS posts_fts_ad AFTER DELETE ON posts BEGIN
+					INSERT INTO posts_fts(posts_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+				END;`,
+				`CREATE TRIGGER IF NOT EXISTS posts_fts_au AFTER UPDATE OF title, content ON posts BEGIN
+					INSERT INTO posts_fts(posts_fts, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+					INSERT INTO posts_fts(rowid, title, content) VALUES (new.id, new.title, new.content);
+				END;`,
+			},
+		},
+		{
+			table: "comments_fts",
+			stmts: []string{
+				`CREATE VIRTUAL TABLE comments_fts USING fts5(
+					content,
+					content='comments', content_rowid='id',
+					tokenize='unicode61 remove_diacritics 2'
+				);`,
+				`CREATE TRIGGER IF NOT EXISTS comments_fts_ai AFTER INSERT ON comments BEGIN
+					INSERT INTO comments_fts(rowid, content) VALUES (new.id, new.content);
+				END;`,
+				`CREATE TRIGGER IF NOT EXISTS comments_fts_ad AFTER DELETE ON comments BEGIN
+					INSERT INTO comments_fts(comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+				END;`,
+				`CREATE TRIGGER IF NOT EXISTS comments_fts_au AFTER UPDATE OF content ON comments BEGIN
+					INSERT INTO comments_fts(comments_fts, rowid, content) VALUES ('delete', old.id, old.content);
+					INSERT INTO comments_fts(rowid, content) VALUES (new.id, new.content);
+				END;`,
+			},
+		},
+	}
+
+	for _, idx := range indexes {
+		exists, err := tableExists(db, idx.table)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		if _, err := db.Exec(idx.stmts[0]); err != nil {
+			if isMissingModule(err) {
+				log.Println("[DB] FTS5 not available, search disabled (build with -tags sqlite_fts5)")
+				return nil
+			}
+			return err
+		}
+		for _, stmt := range idx.stmts[1:] {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+
+		// Backfill rows that existed before the index was created.
+		if _, err := db.Exec(`INSERT INTO ` + idx.table + `(` + idx.table + `) VALUES ('rebuild');`); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,231 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func uploadRequest(t *testing.T, path, filename string, data []byte, cookie *http.Cookie) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestAttachmentUploadAndServe(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, otherCookie := newTestSession(t, server, "other")
+
+	post := &models.Post{UserID: owner.ID, Title: "Pics", Content: "see below", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(post.ID, 10) + "/attachments"
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	detail := server.withSessionMiddleware(http.HandlerFunc(server.handlePostDetail))
+	serve := server.withSessionMiddleware(http.HandlerFunc(server.handleAttachment))
+
+	// Only the post owner may upload.
+	rec := httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "x.png", img.Bytes(), otherCookie))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("non-owner upload: got %d", rec.Code)
+	}
+
+	// Content is sniffed, not trusted from the filename.
+	rec = httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "evil.png", []byte("<html><script>x</script></html>"), ownerCookie))
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("html upload: got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "../../photo.png", img.Bytes(), ownerCookie))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Attachment models.Attachment `json:"attachment"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	att := created.Attachment
+	if att.Filename != "photo.png" || att.Width != 3 || att.Height != 2 || att.ContentType != "image/png" {
+		t.Fatalf("unexpected attachment: %+v", att)
+	}
+
+	// The post JSON carries attachment metadata.
+	rec = doJSON(t, server, http.MethodGet, "/api/posts/"+strconv.FormatInt(post.ID, 10), "", nil)
+	var got struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Post.Attachments) != 1 || got.Post.Attachments[0].URL != att.URL {
+		t.Fatalf("post attachments = %+v", got.Post.Attachments)
+	}
+
+	// Serve, then revalidate with the ETag.
+	rec = httptest.NewRecorder()
+	serve.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, att.URL, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("serve: got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if _, err := png.Decode(rec.Body); err != nil {
+		t.Fatalf("served bytes are not a png: %v", err)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Fatalf("Cache-Control = %q", cc)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, att.URL, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	serve.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("revalidate: got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestAttachmentUploadLimits(t *testing.T) {
+	server := newTestServer(t)
+	server.cfg.MaxUploadBytes = 200
+	server.cfg.MaxAttachmentsPerPost = 1
+	ctx := context.Background()
+
+	owner, cookie := newTestSession(t, server, "owner")
+	post := &models.Post{UserID: owner.ID, Title: "Pics", Content: "x", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(post.ID, 10) + "/attachments"
+	detail := server.withSessionMiddleware(http.HandlerFunc(server.handlePostDetail))
+
+	rec := httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "big.png", bytes.Repeat([]byte{0x89}, 300), cookie))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: got %d", rec.Code)
+	}
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1)))
+
+	rec = httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "a.png", img.Bytes(), cookie))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first upload: got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "b.png", img.Bytes(), cookie))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("over per-post limit: got %d", rec.Code)
+	}
+
+	// Uploads racing for the last slot: only one gets it.
+	server.cfg.MaxAttachmentsPerPost = 2
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			detail.ServeHTTP(rec, uploadRequest(t, path, "c.png", img.Bytes(), cookie))
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	created := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			created++
+		}
+	}
+	if n, _ := server.attachments.CountByPost(ctx, post.ID); created != 1 || n != 2 {
+		t.Fatalf("racing uploads: %d created, %d stored", created, n)
+	}
+}
+
+func TestDraftAttachmentsStayPrivate(t *testing.T) {
+	server := newTestServer(t)
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, otherCookie := newTestSession(t, server, "other")
+
+	draft := &models.Post{UserID: owner.ID, Title: "Soon", Content: "x", Category: "General", Status: models.PostStatusDraft}
+	if err := server.posts.Create(context.Background(), draft); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(draft.ID, 10) + "/attachments"
+	detail := server.withSessionMiddleware(http.HandlerFunc(server.handlePostDetail))
+	serve := server.withSessionMiddleware(http.HandlerFunc(server.handleAttachment))
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewGray(image.Rect(0, 0, 1, 1)))
+	rec := httptest.NewRecorder()
+	detail.ServeHTTP(rec, uploadRequest(t, path, "a.png", img.Bytes(), ownerCookie))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: got %d", rec.Code)
+	}
+	var created struct {
+		Attachment models.Attachment `json:"attachment"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string, cookie *http.Cookie, h http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := get(created.Attachment.URL, ownerCookie, serve); rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "private, no-store" {
+		t.Fatalf("author: got %d, Cache-Control %q", rec.Code, rec.Header().Get("Cache-Control"))
+	}
+	if rec := get(created.Attachment.URL, otherCookie, serve); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user image: got %d", rec.Code)
+	}
+	if rec := get(path, otherCookie, detail); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user list: got %d", rec.Code)
+	}
+}
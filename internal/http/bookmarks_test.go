@@ -0,0 +1,64 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestBookmarkEndpoints(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, _ := newTestSession(t, server, "owner")
+	_, readerCookie := newTestSession(t, server, "reader")
+
+	post := &models.Post{UserID: owner.ID, Title: "Keep me", Content: "body", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(post.ID, 10) + "/bookmark"
+
+	if rec := doJSON(t, server, http.MethodPost, path, "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous bookmark: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, "/api/posts/999/bookmark", "", readerCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing post: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, path, "", readerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("bookmark: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	list := func() []models.Post {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/me/bookmarks?limit=5", nil)
+		req.AddCookie(readerCookie)
+		rec := httptest.NewRecorder()
+		server.withSessionMiddleware(http.HandlerFunc(server.handleMyBookmarks)).ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list bookmarks: got %d", rec.Code)
+		}
+		var body struct {
+			Posts []models.Post `json:"posts"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Posts
+	}
+
+	if posts := list(); len(posts) != 1 || posts[0].ID != post.ID || !posts[0].IBookmarked {
+		t.Fatalf("bookmarks = %+v", posts)
+	}
+	if rec := doJSON(t, server, http.MethodDelete, path, "", readerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("unbookmark: got %d", rec.Code)
+	}
+	if posts := list(); len(posts) != 0 {
+		t.Fatalf("after delete = %+v", posts)
+	}
+}
@@ -0,0 +1,295 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestCommentReplyEndpoints(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	user, cookie := newTestSession(t, server, "replier")
+
+	post := &models.Post{UserID: user.ID, Title: "Threads", Content: "body", Category: "General"}
+	other := &models.Post{UserID: user.ID, Title: "Elsewhere", Content: "body", Category: "General"}
+	for _, p := range []*models.Post{post, other} {
+		if err := server.posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	rec := doJSON(t, server, http.MethodPost, base+"/comments", `{"content":"root"}`, cookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("root comment: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Comment models.Comment `json:"comment"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	rootID := strconv.FormatInt(created.Comment.ID, 10)
+
+	rec = doJSON(t, server, http.MethodPost, base+"/comments", `{"content":"reply","parent_id":`+rootID+`}`, cookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("reply: got %d: %s", rec.Code, rec.Body.String())
+	}
+	otherPath := "/api/posts/" + strconv.FormatInt(other.ID, 10) + "/comments"
+	if rec := doJSON(t, server, http.MethodPost, otherPath, `{"content":"stray","parent_id":`+rootID+`}`, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("reply across posts: got %d", rec.Code)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec = get("/api/comments/" + rootID + "/replies?limit=10")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("replies: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Replies []models.Comment `json:"replies"`
+		HasMore bool             `json:"has_more"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Replies) != 1 || page.HasMore || page.Replies[0].Depth != 1 {
+		t.Fatalf("replies page = %+v", page)
+	}
+
+	replyID := strconv.FormatInt(page.Replies[0].ID, 10)
+	if rec := get("/api/comments/" + replyID + "/replies"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replies of a reply: got %d", rec.Code)
+	}
+	if rec := get("/api/comments/999999/replies"); rec.Code != http.StatusNotFound {
+		t.Fatalf("replies of a missing comment: got %d", rec.Code)
+	}
+}
+
+func TestCommentDeletePermissions(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	author, authorCookie := newTestSession(t, server, "writer")
+	_, strangerCookie := newTestSession(t, server, "stranger")
+	_, modCookie := newTestSession(t, server, "warden")
+	if err := server.users.SetRoleByNickname(ctx, "warden", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.Post{UserID: author.ID, Title: "Deletions", Content: "body", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	newComment := func() string {
+		t.Helper()
+		c := &models.Comment{PostID: post.ID, UserID: author.ID, Content: "gone soon"}
+		if err := server.comments.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		return "/api/comments/" + strconv.FormatInt(c.ID, 10)
+	}
+	del := func(path string, cookie *http.Cookie) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	own, modded := newComment(), newComment()
+
+	if code := del(own, nil); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous delete: got %d", code)
+	}
+	if code := del(own, strangerCookie); code != http.StatusForbidden {
+		t.Fatalf("stranger delete: got %d", code)
+	}
+	if code := del(own, authorCookie); code != http.StatusOK {
+		t.Fatalf("author delete: got %d", code)
+	}
+	if code := del(own, authorCookie); code != http.StatusNotFound {
+		t.Fatalf("second delete: got %d", code)
+	}
+	if code := del(modded, modCookie); code != http.StatusOK {
+		t.Fatalf("moderator delete: got %d", code)
+	}
+}
+
+func TestPostCommentsArePagedNotInlined(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	user, cookie := newTestSession(t, server, "paged")
+	post := &models.Post{UserID: user.ID, Title: "Paged", Content: "body", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		c := &models.Comment{PostID: post.ID, UserID: user.ID, Content: "hello"}
+		if err := server.comments.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ID)
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	rec := doJSON(t, server, http.MethodGet, base, "", cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get post: got %d", rec.Code)
+	}
+	var detail map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := detail["comments"]; ok {
+		t.Fatal("post response still inlines comments")
+	}
+	var got struct {
+		CommentsCount int64 `json:"comments_count"`
+	}
+	if err := json.Unmarshal(detail["post"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.CommentsCount != 3 {
+		t.Fatalf("comments_count = %d, want 3", got.CommentsCount)
+	}
+
+	type page struct {
+		Comments   []models.Comment `json:"comments"`
+		HasMore    bool             `json:"has_more"`
+		NextAfter  int64            `json:"next_after"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	list := func(query string) page {
+		t.Helper()
+		rec := doJSON(t, server, http.MethodGet, base+"/comments?"+query, "", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list %q: got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p := list("order=newest&limit=2")
+	if len(p.Comments) != 2 || !p.HasMore || p.Comments[0].ID != ids[2] || p.NextAfter != ids[1] {
+		t.Fatalf("newest first page = %+v", p)
+	}
+	p = list("order=newest&limit=2&after=" + strconv.FormatInt(p.NextAfter, 10))
+	if len(p.Comments) != 1 || p.HasMore || p.Comments[0].ID != ids[0] {
+		t.Fatalf("newest second page = %+v", p)
+	}
+
+	// Top pages by cursor; every comment scores 0, so newest first.
+	p = list("order=top&limit=2")
+	if len(p.Comments) != 2 || !p.HasMore || p.NextCursor == "" || p.Comments[0].ID != ids[2] {
+		t.Fatalf("top first page = %+v", p)
+	}
+	p = list("order=top&limit=2&cursor=" + p.NextCursor)
+	if len(p.Comments) != 1 || p.HasMore || p.Comments[0].ID != ids[0] {
+		t.Fatalf("top second page = %+v", p)
+	}
+	for _, query := range []string{"order=top&after=1", "order=top&cursor=bogus"} {
+		if rec := doJSON(t, server, http.MethodGet, base+"/comments?"+query, "", nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: got %d, want 400", query, rec.Code)
+		}
+	}
+
+	if rec := doJSON(t, server, http.MethodGet, base+"/comments?order=sideways", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad order: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodGet, base+"/comments?after=-1", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad after: got %d", rec.Code)
+	}
+}
+
+func TestCommentReactionEndpoints(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	user, cookie := newTestSession(t, server, "reactor")
+	post := &models.Post{UserID: user.ID, Title: "Reactions", Content: "body", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	c := &models.Comment{PostID: post.ID, UserID: user.ID, Content: "react to me"}
+	if err := server.comments.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/comments/" + strconv.FormatInt(c.ID, 10) + "/reactions"
+
+	do := func(method, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, `{"reaction":"love"}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous react: got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, `{"reaction":"meh"}`, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown reaction: got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, `{"reaction":"love"}`, cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("react: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var toggled struct {
+		Reacted        bool  `json:"reacted"`
+		ReactionsCount int64 `json:"reactions_count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &toggled); err != nil {
+		t.Fatal(err)
+	}
+	if !toggled.Reacted || toggled.ReactionsCount != 1 {
+		t.Fatalf("toggle = %+v", toggled)
+	}
+
+	rec = do(http.MethodGet, "", cookie)
+	var state struct {
+		Reactions   map[string]int64 `json:"reactions"`
+		MyReactions []string         `json:"my_reactions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Reactions["love"] != 1 || len(state.MyReactions) != 1 || state.MyReactions[0] != "love" {
+		t.Fatalf("reactions = %+v", state)
+	}
+
+	if _, err := server.comments.Delete(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(http.MethodGet, "", cookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("reactions of a deleted comment: got %d", rec.Code)
+	}
+}
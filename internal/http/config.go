@@ -0,0 +1,38 @@
+// internal/http/config.go
+package httpserver
+
+import (
+	"time"
+
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/storage"
+)
+
+// Config holds tunable server settings. The zero value is not useful;
+// start from DefaultConfig.
+type Config struct {
+	// Reactions is the set of reaction types users may leave on posts.
+	Reactions []string
+
+	// EditWindow is how long authors may edit their posts and comments;
+	// after that only moderators can. Zero means no limit.
+	EditWindow time.Duration
+
+	// Storage holds uploaded post images.
+	Storage storage.Storage
+	// MaxUploadBytes caps the size of a single uploaded image.
+	MaxUploadBytes int64
+	// MaxAttachmentsPerPost caps how many images one post may carry.
+	MaxAttachmentsPerPost int
+}
+
+// DefaultConfig returns the settings used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		Reactions:             models.DefaultReactions,
+		EditWindow:            models.DefaultEditWindow,
+		Storage:               storage.NewLocal("uploads"),
+		MaxUploadBytes:        5 << 20,
+		MaxAttachmentsPerPost: 4,
+	}
+}
@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestDraftsVisibleOnlyToAuthor(t *testing.T) {
+	server := newTestServer(t)
+	_, authorCookie := newTestSession(t, server, "author")
+	_, otherCookie := newTestSession(t, server, "other")
+
+	do := func(h http.HandlerFunc, method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		server.withSessionMiddleware(h).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(server.handlePosts, http.MethodPost, "/api/posts",
+		`{"title":"wip","content":"not yet","status":"draft"}`, authorCookie)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create draft: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.Post.Status != models.PostStatusDraft {
+		t.Fatalf("status = %q", created.Post.Status)
+	}
+	postPath := "/api/posts/" + strconv.FormatInt(created.Post.ID, 10)
+
+	if rec := doJSON(t, server, http.MethodGet, postPath, "", otherCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user GET draft: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, postPath+"/comments", `{"content":"hi"}`, otherCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user comment on draft: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodGet, postPath, "", authorCookie); rec.Code != http.StatusOK {
+		t.Fatalf("author GET draft: got %d", rec.Code)
+	}
+
+	rec = do(server.handlePosts, http.MethodGet, "/api/posts", "", otherCookie)
+	if strings.Contains(rec.Body.String(), `"wip"`) {
+		t.Fatal("draft listed in the feed")
+	}
+
+	rec = do(server.handlePosts, http.MethodPost, "/api/posts",
+		`{"title":"t","content":"c","status":"scheduled"}`, authorCookie)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("scheduled without publish_at: got %d", rec.Code)
+	}
+
+	draftPath := "/api/drafts/" + strconv.FormatInt(created.Post.ID, 10)
+	if rec := do(server.handleDraftByID, http.MethodPatch, draftPath, `{"title":"x"}`, otherCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("other user PATCH draft: got %d", rec.Code)
+	}
+	if rec := do(server.handleDraftByID, http.MethodPatch, draftPath, `{"status":"published"}`, authorCookie); rec.Code != http.StatusOK {
+		t.Fatalf("publish draft: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, server, http.MethodGet, postPath, "", otherCookie); rec.Code != http.StatusOK {
+		t.Fatalf("other user GET published post: got %d", rec.Code)
+	}
+}
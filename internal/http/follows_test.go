@@ -0,0 +1,88 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestFollowEndpointsAndFollowingFeed(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	_, readerCookie := newTestSession(t, server, "reader")
+	author, _ := newTestSession(t, server, "author")
+
+	post := &models.Post{UserID: author.ID, Title: "Followed", Content: "body", Category: "Travel"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	feed := func() []models.Post {
+		t.Helper()
+		rec := do(http.MethodGet, "/api/feed/following", readerCookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("following feed: got %d", rec.Code)
+		}
+		var body struct {
+			Posts []models.Post `json:"posts"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Posts
+	}
+
+	if rec := do(http.MethodGet, "/api/feed/following", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous following feed: got %d", rec.Code)
+	}
+	if posts := feed(); len(posts) != 0 {
+		t.Fatalf("feed before following = %+v", posts)
+	}
+
+	if rec := do(http.MethodPost, "/api/categories/nope/follow", readerCookie); rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown category: got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/categories/travel/follow", readerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("follow category: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if posts := feed(); len(posts) != 1 || posts[0].ID != post.ID {
+		t.Fatalf("feed after category follow = %+v", posts)
+	}
+	if rec := do(http.MethodDelete, "/api/categories/Travel/follow", readerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("unfollow category: got %d", rec.Code)
+	}
+
+	authorPath := "/api/users/" + strconv.FormatInt(author.ID, 10) + "/follow"
+	if rec := do(http.MethodPost, authorPath, readerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("follow user: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if posts := feed(); len(posts) != 1 || posts[0].ID != post.ID {
+		t.Fatalf("feed after user follow = %+v", posts)
+	}
+
+	rec := do(http.MethodGet, "/api/me/follows", readerCookie)
+	var follows models.Follows
+	if err := json.Unmarshal(rec.Body.Bytes(), &follows); err != nil {
+		t.Fatal(err)
+	}
+	if len(follows.Users) != 1 || follows.Users[0].ID != author.ID || len(follows.Categories) != 0 {
+		t.Fatalf("follows = %+v", follows)
+	}
+}
@@ -0,0 +1,296 @@
+// internal/http/handlers_attachments.go
+package httpserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"real-time-forum/internal/media"
+	"real-time-forum/internal/models"
+)
+
+// multipartOverhead is allowed on top of MaxUploadBytes for boundaries
+// and part headers.
+const multipartOverhead = 64 << 10
+
+// handlePostAttachments routes:
+//
+//	GET  /api/posts/{id}/attachments -> list attachment metadata
+//	POST /api/posts/{id}/attachments -> multipart upload, field "file" (post owner only)
+func (s *Server) handlePostAttachments(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/posts/")
+	path = strings.TrimSuffix(path, "/attachments")
+
+	postID, err := strconv.ParseInt(path, 10, 64)
+	if err != nil || postID <= 0 {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		viewerID, _ := getUserIDFromContext(r)
+		visible, err := s.posts.VisibleTo(r.Context(), postID, viewerID)
+		if err != nil {
+			log.Println("[ATTACHMENTS] Visibility error:", err)
+			http.Error(w, "cannot load attachments", http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+
+		list, err := s.attachments.ListByPost(r.Context(), postID)
+		if err != nil {
+			log.Println("[ATTACHMENTS] List error:", err)
+			http.Error(w, "cannot load attachments", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"post_id":     postID,
+			"attachments": list,
+		})
+
+	case http.MethodPost:
+		s.uploadAttachment(w, r, postID)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) uploadAttachment(w http.ResponseWriter, r *http.Request, postID int64) {
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	post, err := s.posts.Get(r.Context(), postID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[ATTACHMENTS] Get post error:", err)
+		http.Error(w, "cannot upload attachment", http.StatusInternalServerError)
+		return
+	}
+	if post.UserID != userID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	// Fail fast before reading the upload; Create checks again.
+	n, err := s.attachments.CountByPost(r.Context(), postID)
+	if err != nil {
+		log.Println("[ATTACHMENTS] Count error:", err)
+		http.Error(w, "cannot upload attachment", http.StatusInternalServerError)
+		return
+	}
+	if n >= s.cfg.MaxAttachmentsPerPost {
+		http.Error(w, fmt.Sprintf("attachment limit reached (%d)", s.cfg.MaxAttachmentsPerPost), http.StatusBadRequest)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxUploadBytes+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, s.cfg.MaxUploadBytes+1))
+	if err != nil {
+		http.Error(w, "cannot read upload", http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > s.cfg.MaxUploadBytes {
+		http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// The client's Content-Type is ignored: Sanitize sniffs the bytes and
+	// re-encodes them, which also strips EXIF and other metadata.
+	img, err := media.Sanitize(data)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.ErrUnsupportedType):
+			http.Error(w, "unsupported image type (jpeg, png or gif)", http.StatusUnsupportedMediaType)
+		case errors.Is(err, media.ErrTooLarge):
+			http.Error(w, "image dimensions too large", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, media.ErrTooManyFrames):
+			http.Error(w, "animation has too many frames", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, media.ErrCorrupt):
+			http.Error(w, "invalid image", http.StatusBadRequest)
+		default:
+			log.Println("[ATTACHMENTS] Sanitize error:", err)
+			http.Error(w, "cannot process image", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	key, err := newStorageKey(postID, media.Extension(img.ContentType))
+	if err != nil {
+		log.Println("[ATTACHMENTS] Key error:", err)
+		http.Error(w, "cannot upload attachment", http.StatusInternalServerError)
+		return
+	}
+	if err := s.cfg.Storage.Put(r.Context(), key, bytes.NewReader(img.Data)); err != nil {
+		log.Println("[ATTACHMENTS] Store error:", err)
+		http.Error(w, "cannot upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(img.Data)
+	att := &models.Attachment{
+		PostID:      postID,
+		UserID:      userID,
+		StorageKey:  key,
+		Filename:    cleanFilename(header.Filename),
+		ContentType: img.ContentType,
+		Size:        int64(len(img.Data)),
+		Width:       img.Width,
+		Height:      img.Height,
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+	if err := s.attachments.Create(r.Context(), att, s.cfg.MaxAttachmentsPerPost); err != nil {
+		_ = s.cfg.Storage.Delete(r.Context(), key)
+		if errors.Is(err, models.ErrAttachmentLimit) {
+			http.Error(w, fmt.Sprintf("attachment limit reached (%d)", s.cfg.MaxAttachmentsPerPost), http.StatusBadRequest)
+			return
+		}
+		log.Println("[ATTACHMENTS] Create error:", err)
+		http.Error(w, "cannot upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"attachment": att})
+}
+
+// handleAttachment serves the image bytes: GET /api/attachments/{id}.
+// Stored objects never change, so the content hash is a strong ETag.
+func (s *Server) handleAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/attachments/")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid attachment id", http.StatusBadRequest)
+		return
+	}
+
+	att, err := s.attachments.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, models.ErrAttachmentNotFound) {
+			http.Error(w, "attachment not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[ATTACHMENTS] Get error:", err)
+		http.Error(w, "cannot load attachment", http.StatusInternalServerError)
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r)
+	visible, err := s.posts.VisibleTo(r.Context(), att.PostID, viewerID)
+	if err != nil {
+		log.Println("[ATTACHMENTS] Visibility error:", err)
+		http.Error(w, "cannot load attachment", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "attachment not found", http.StatusNotFound)
+		return
+	}
+
+	// Only published posts' images may sit in shared caches; drafts and
+	// scheduled posts are their author's alone.
+	cacheControl := "public, max-age=31536000, immutable"
+	if _, err := s.posts.PublishedCategory(r.Context(), att.PostID); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Println("[ATTACHMENTS] Status error:", err)
+		}
+		cacheControl = "private, no-store"
+	}
+
+	etag := `"` + att.SHA256 + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rc, err := s.cfg.Storage.Open(r.Context(), att.StorageKey)
+	if err != nil {
+		log.Println("[ATTACHMENTS] Open error:", err)
+		http.Error(w, "cannot load attachment", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", att.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(att.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Println("[ATTACHMENTS] Write error:", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// newStorageKey returns a random, unguessable key for a post's image.
+func newStorageKey(postID int64, ext string) (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("posts/%d/%s%s", postID, hex.EncodeToString(b[:]), ext), nil
+}
+
+// cleanFilename keeps only the base name the client sent, for display.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
@@ -0,0 +1,111 @@
+// internal/http/handlers_bookmarks.go
+package httpserver
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"real-time-forum/internal/models"
+)
+
+// handlePostBookmark routes:
+//
+//	POST   /api/posts/{id}/bookmark -> save the post
+//	DELETE /api/posts/{id}/bookmark -> unsave it
+//
+// Both are idempotent and answer {"bookmarked": bool}.
+func (s *Server) handlePostBookmark(w http.ResponseWriter, r *http.Request) {
+	postID, ok := postIDFromPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = s.bookmarks.Add(r.Context(), userID, postID)
+	case http.MethodDelete:
+		err = s.bookmarks.Remove(r.Context(), userID, postID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		log.Println("[BOOKMARKS] Update error:", err)
+		http.Error(w, "cannot update bookmark", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"bookmarked": r.Method == http.MethodPost})
+}
+
+// handleMyBookmarks lists the current user's saved posts, newest first.
+//
+//	GET /api/me/bookmarks?limit=10&cursor=<next_cursor>
+func (s *Server) handleMyBookmarks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	limit := int64(10)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	var after *models.BookmarkCursor
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := models.DecodeBookmarkCursor(v)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		after = &cursor
+	}
+
+	posts, next, err := s.bookmarks.List(r.Context(), userID, limit, after)
+	if err == nil {
+		err = s.reactions.Attach(r.Context(), posts, userID)
+	}
+	if err == nil {
+		err = s.attachments.Attach(r.Context(), posts)
+	}
+	if err == nil {
+		err = s.tags.Attach(r.Context(), posts)
+	}
+	if err != nil {
+		log.Println("[BOOKMARKS] List error:", err)
+		http.Error(w, "cannot load bookmarks", http.StatusInternalServerError)
+		return
+	}
+
+	nextCursor := ""
+	if next != nil {
+		nextCursor = next.Encode()
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"posts":       posts,
+		"has_more":    next != nil,
+		"next_cursor": nextCursor,
+	})
+}
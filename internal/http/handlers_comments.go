@@ -0,0 +1,277 @@
+// internal/http/handlers_comments.go
+package httpserver
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/ws"
+)
+
+// handleCommentReplies pages through the replies under a root comment.
+//
+//	GET /api/comments/{id}/replies?after=<reply id>&limit=20
+//	  -> {"replies": [...], "has_more": bool, "next_after": id}
+//
+// Pass next_after back as after to get the next page.
+func (s *Server) handleCommentReplies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/comments/"), "/replies")
+	rootID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || rootID <= 0 {
+		http.Error(w, "invalid comment id", http.StatusBadRequest)
+		return
+	}
+
+	after, limit, ok := parsePage(w, r, 20, 100)
+	if !ok {
+		return
+	}
+
+	root, err := s.comments.GetByID(r.Context(), rootID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "comment not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[COMMENTS] Get error:", err)
+		http.Error(w, "cannot load replies", http.StatusInternalServerError)
+		return
+	}
+	if root.ParentID != nil {
+		http.Error(w, "replies are listed per root comment", http.StatusBadRequest)
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r)
+	visible, err := s.posts.VisibleTo(r.Context(), root.PostID, viewerID)
+	if err != nil {
+		log.Println("[COMMENTS] Visibility error:", err)
+		http.Error(w, "cannot load replies", http.StatusInternalServerError)
+		return
+	}
+	if !visible {
+		http.Error(w, "comment not found", http.StatusNotFound)
+		return
+	}
+
+	replies, hasMore, err := s.comments.ListReplies(r.Context(), rootID, after, limit, viewerID)
+	if err != nil {
+		log.Println("[COMMENTS] Replies error:", err)
+		http.Error(w, "cannot load replies", http.StatusInternalServerError)
+		return
+	}
+
+	resp := map[string]any{"replies": replies, "has_more": hasMore}
+	if hasMore {
+		resp["next_after"] = replies[len(replies)-1].ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// parsePage reads ?after=<comment id> and ?limit= for comment listings.
+// A bad after answers 400 and returns ok=false; a bad limit falls back to
+// def, and limit is capped at max.
+func parsePage(w http.ResponseWriter, r *http.Request, def, max int) (after int64, limit int, ok bool) {
+	q := r.URL.Query()
+	if v := q.Get("after"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid after", http.StatusBadRequest)
+			return 0, 0, false
+		}
+		after = n
+	}
+
+	limit = def
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > max {
+		limit = max
+	}
+	return after, limit, true
+}
+
+// handleCommentDelete deletes a comment for its author or a moderator.
+//
+//	DELETE /api/comments/{id} -> {"deleted": true, "tombstoned": bool, "removed": [...]}
+//
+// A comment with replies stays as a "[deleted]" tombstone. Viewers of the
+// post get a comment_deleted event either way.
+func (s *Server) handleCommentDelete(w http.ResponseWriter, r *http.Request, commentID, userID int64) {
+	comment, err := s.comments.GetByID(r.Context(), commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "comment not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[COMMENTS] Get error:", err)
+		http.Error(w, "cannot delete comment", http.StatusInternalServerError)
+		return
+	}
+	if comment.Deleted {
+		http.Error(w, "comment not found", http.StatusNotFound)
+		return
+	}
+
+	if comment.UserID != userID {
+		isMod, err := s.users.IsModerator(r.Context(), userID)
+		if err != nil {
+			log.Println("[COMMENTS] Role error:", err)
+			http.Error(w, "cannot delete comment", http.StatusInternalServerError)
+			return
+		}
+		if !isMod {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	del, err := s.comments.Delete(r.Context(), commentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "comment not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[COMMENTS] Delete error:", err)
+		http.Error(w, "cannot delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	removed := del.Removed
+	if removed == nil {
+		removed = []int64{}
+	}
+	s.publish(r.Context(), comment.PostID, ws.CommentDeletedEvent{
+		Type:       "comment_deleted",
+		PostID:     comment.PostID,
+		CommentID:  commentID,
+		Tombstoned: del.Tombstoned,
+		Removed:    removed,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deleted":    true,
+		"tombstoned": del.Tombstoned,
+		"removed":    removed,
+	})
+}
+
+// handleCommentReactions routes:
+//
+//	GET  /api/comments/{id}/reactions -> counts per type + viewer's reactions
+//	POST /api/comments/{id}/reactions -> toggle {"reaction": "love"}
+//
+// Same reaction types and toggle semantics as post reactions.
+func (s *Server) handleCommentReactions(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/comments/"), "/reactions")
+	commentID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || commentID <= 0 {
+		http.Error(w, "invalid comment id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r)
+	if r.Method == http.MethodPost && viewerID <= 0 {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	comment, ok := s.visibleComment(w, r, commentID, viewerID)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		counts, mine, err := s.reactions.CommentReactions(r.Context(), commentID, viewerID)
+		if err != nil {
+			log.Println("[REACTIONS] Comment get error:", err)
+			http.Error(w, "cannot load reactions", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"comment_id":   commentID,
+			"allowed":      s.reactions.AllowedReactions(),
+			"reactions":    counts,
+			"my_reactions": mine,
+		})
+		return
+	}
+
+	reaction := "like"
+	var req struct {
+		Reaction string `json:"reaction"`
+	}
+	_ = decodeJSON(w, r, &req)
+	if strings.TrimSpace(req.Reaction) != "" {
+		reaction = req.Reaction
+	}
+
+	reacted, counts, err := s.reactions.ToggleComment(r.Context(), commentID, viewerID, reaction)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownReaction) {
+			http.Error(w, "unknown reaction", http.StatusBadRequest)
+			return
+		}
+		log.Println("[REACTIONS] Comment toggle error:", err)
+		http.Error(w, "cannot react", http.StatusInternalServerError)
+		return
+	}
+	reaction, _ = s.reactions.Normalize(reaction)
+
+	s.publish(r.Context(), comment.PostID, ws.ReactionChangedEvent{
+		Type:      "reaction_changed",
+		PostID:    comment.PostID,
+		CommentID: commentID,
+		Reactions: counts,
+	})
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"comment_id":      commentID,
+		"reaction":        reaction,
+		"reacted":         reacted,
+		"reactions_count": counts[reaction],
+		"reactions":       counts,
+	})
+}
+
+// visibleComment loads a comment viewerID may see. Tombstones and comments
+// on posts the viewer cannot see answer 404 like missing ones; ok is false
+// once a response has been written.
+func (s *Server) visibleComment(w http.ResponseWriter, r *http.Request, commentID, viewerID int64) (*models.Comment, bool) {
+	comment, err := s.comments.GetByID(r.Context(), commentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Println("[COMMENTS] Get error:", err)
+		http.Error(w, "cannot load comment", http.StatusInternalServerError)
+		return nil, false
+	}
+	visible := false
+	if err == nil && !comment.Deleted {
+		if visible, err = s.posts.VisibleTo(r.Context(), comment.PostID, viewerID); err != nil {
+			log.Println("[COMMENTS] Visibility error:", err)
+			http.Error(w, "cannot load comment", http.StatusInternalServerError)
+			return nil, false
+		}
+	}
+	if !visible {
+		http.Error(w, "comment not found", http.StatusNotFound)
+		return nil, false
+	}
+	return comment, true
+}
@@ -0,0 +1,164 @@
+// internal/http/handlers_drafts.go
+package httpserver
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/models"
+)
+
+// handleDrafts lists the current user's drafts and scheduled posts:
+// GET /api/drafts. Drafts are created with POST /api/posts and
+// "status": "draft" (or "scheduled" plus "publish_at").
+func (s *Server) handleDrafts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	drafts, err := s.posts.ListDrafts(r.Context(), userID)
+	if err == nil {
+		err = s.tags.Attach(r.Context(), drafts)
+	}
+	if err != nil {
+		log.Println("[DRAFTS] List error:", err)
+		http.Error(w, "cannot load drafts", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"drafts": drafts})
+}
+
+// handleDraftByID routes:
+//
+//	GET   /api/drafts/{id} -> one of the user's unpublished posts
+//	PATCH /api/drafts/{id} -> edit fields; "status": "published" publishes now,
+//	                          "scheduled" (with "publish_at") hands it to the scheduler
+func (s *Server) handleDraftByID(w http.ResponseWriter, r *http.Request) {
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/drafts/"), "/")
+	postID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || postID <= 0 {
+		http.Error(w, "invalid draft id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		post, err := s.loadPost(r.Context(), postID, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Println("[DRAFTS] Get error:", err)
+			http.Error(w, "cannot load draft", http.StatusInternalServerError)
+			return
+		}
+		if err != nil || post.UserID != userID || post.Status == models.PostStatusPublished {
+			http.Error(w, "draft not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"draft": post})
+
+	case http.MethodPatch:
+		var req struct {
+			Title     *string    `json:"title"`
+			Content   *string    `json:"content"`
+			Category  *string    `json:"category"`
+			Status    *string    `json:"status"`
+			PublishAt *time.Time `json:"publish_at"`
+			Tags      *[]string  `json:"tags"`
+		}
+		if err := decodeJSON(w, r, &req); err != nil {
+			http.Error(w, "invalid json body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+			http.Error(w, "title cannot be empty", http.StatusBadRequest)
+			return
+		}
+		if req.Content != nil && strings.TrimSpace(*req.Content) == "" {
+			http.Error(w, "content cannot be empty", http.StatusBadRequest)
+			return
+		}
+
+		upd := models.DraftUpdate{
+			Title:     req.Title,
+			Content:   req.Content,
+			Category:  req.Category,
+			PublishAt: req.PublishAt,
+		}
+		if req.Tags != nil {
+			tags, ok := parseTags(w, *req.Tags)
+			if !ok {
+				return
+			}
+			upd.Tags = &tags
+		}
+		if req.Status != nil {
+			status, err := models.ParsePostStatus(*req.Status)
+			if err != nil {
+				http.Error(w, "invalid status", http.StatusBadRequest)
+				return
+			}
+			upd.Status = &status
+		}
+		if req.PublishAt != nil && !req.PublishAt.After(time.Now()) {
+			http.Error(w, "publish_at must be in the future", http.StatusBadRequest)
+			return
+		}
+		if req.Category != nil {
+			name, ok := s.ensureCategory(w, r, *req.Category)
+			if !ok {
+				return
+			}
+			upd.Category = &name
+		}
+
+		if err := s.posts.UpdateDraft(r.Context(), postID, userID, upd); err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				http.Error(w, "draft not found", http.StatusNotFound)
+			case errors.Is(err, models.ErrPublishAtRequired):
+				http.Error(w, "publish_at is required for scheduled posts", http.StatusBadRequest)
+			case errors.Is(err, models.ErrInvalidStatus):
+				http.Error(w, "invalid status", http.StatusBadRequest)
+			case errors.Is(err, models.ErrContentTooLong):
+				http.Error(w, "content is too long", http.StatusBadRequest)
+			default:
+				log.Println("[DRAFTS] Update error:", err)
+				http.Error(w, "cannot update draft", http.StatusInternalServerError)
+			}
+			return
+		}
+		updated, err := s.loadPost(r.Context(), postID, userID)
+		if err != nil {
+			log.Println("[DRAFTS] Reload error:", err)
+			http.Error(w, "cannot load updated draft", http.StatusInternalServerError)
+			return
+		}
+		// Publishing a draft makes it news; saving one stays private.
+		if upd.Status != nil && *upd.Status == models.PostStatusPublished {
+			s.publishPost(r.Context(), postID, true)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"post": updated})
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
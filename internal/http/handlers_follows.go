@@ -0,0 +1,140 @@
+// internal/http/handlers_follows.go
+package httpserver
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"real-time-forum/internal/models"
+)
+
+// handleUserFollow routes:
+//
+//	POST   /api/users/{id}/follow -> follow an author
+//	DELETE /api/users/{id}/follow -> unfollow
+//
+// Both are idempotent and answer {"following": bool}.
+func (s *Server) handleUserFollow(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	if !strings.HasSuffix(rest, "/follow") {
+		http.NotFound(w, r)
+		return
+	}
+	followeeID, err := strconv.ParseInt(strings.TrimSuffix(rest, "/follow"), 10, 64)
+	if err != nil || followeeID <= 0 {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		err = s.follows.FollowUser(r.Context(), userID, followeeID)
+	case http.MethodDelete:
+		err = s.follows.UnfollowUser(r.Context(), userID, followeeID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			http.Error(w, "user not found", http.StatusNotFound)
+		case errors.Is(err, models.ErrSelfFollow):
+			http.Error(w, "cannot follow yourself", http.StatusBadRequest)
+		default:
+			log.Println("[FOLLOWS] User follow error:", err)
+			http.Error(w, "cannot update follow", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"following": r.Method == http.MethodPost})
+}
+
+// handleCategoryFollow routes:
+//
+//	POST   /api/categories/{name}/follow -> follow a category
+//	DELETE /api/categories/{name}/follow -> unfollow
+//
+// Both are idempotent and answer {"following": bool}.
+// POST /api/categories/{name}/qa is handled by handleCategoryQA.
+func (s *Server) handleCategoryFollow(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/categories/")
+	if strings.HasSuffix(rest, "/qa") {
+		s.handleCategoryQA(w, r)
+		return
+	}
+	if !strings.HasSuffix(rest, "/follow") {
+		http.NotFound(w, r)
+		return
+	}
+	name := strings.TrimSuffix(rest, "/follow")
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	cat, err := s.categories.GetByName(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, models.ErrCategoryNotFound) {
+			http.Error(w, "category not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[FOLLOWS] Category lookup error:", err)
+		http.Error(w, "cannot load category", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		err = s.follows.FollowCategory(r.Context(), userID, cat.ID)
+	case http.MethodDelete:
+		err = s.follows.UnfollowCategory(r.Context(), userID, cat.ID)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err != nil {
+		log.Println("[FOLLOWS] Category follow error:", err)
+		http.Error(w, "cannot update follow", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"following": r.Method == http.MethodPost, "category": cat.Name})
+}
+
+// handleMyFollows lists who and what the current user follows.
+//
+//	GET /api/me/follows -> {"users": [...], "categories": [...]}
+func (s *Server) handleMyFollows(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	follows, err := s.follows.List(r.Context(), userID)
+	if err != nil {
+		log.Println("[FOLLOWS] List error:", err)
+		http.Error(w, "cannot load follows", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, follows)
+}
@@ -3,7 +3,6 @@ package httpserver
 
 import (
 	"database/sql"
-	"encoding/json"
 	"log"
 	"net/http"
 	"strconv"
@@ -84,7 +83,7 @@ func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPost:
 		var req sendMessageRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSON(w, r, &req); err != nil {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
@@ -0,0 +1,110 @@
+// internal/http/handlers_moderation.go
+package httpserver
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"real-time-forum/internal/models"
+)
+
+// requireModerator returns the current user's ID if they are a moderator.
+// Otherwise it writes 401/403 and returns false.
+func (s *Server) requireModerator(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return 0, false
+	}
+
+	isMod, err := s.users.IsModerator(r.Context(), userID)
+	if err != nil {
+		log.Println("[MODERATION] Role error:", err)
+		http.Error(w, "cannot check permissions", http.StatusInternalServerError)
+		return 0, false
+	}
+	if !isMod {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return 0, false
+	}
+	return userID, true
+}
+
+// handlePostModeration routes (moderators only):
+//
+//	POST /api/posts/{id}/pin  {"pinned": true, "scope": "global"|"category"}
+//	POST /api/posts/{id}/lock {"locked": true}
+func (s *Server) handlePostModeration(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, ok := postIDFromPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	modID, ok := s.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Pinned *bool  `json:"pinned"`
+		Scope  string `json:"scope"`
+		Locked *bool  `json:"locked"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/pin"):
+		if req.Pinned == nil {
+			http.Error(w, "pinned is required", http.StatusBadRequest)
+			return
+		}
+		scope := ""
+		if *req.Pinned {
+			if scope, err = models.ParsePinScope(req.Scope); err != nil {
+				http.Error(w, "invalid scope", http.StatusBadRequest)
+				return
+			}
+		}
+		err = s.posts.SetPinned(r.Context(), postID, scope)
+
+	case strings.HasSuffix(r.URL.Path, "/lock"):
+		if req.Locked == nil {
+			http.Error(w, "locked is required", http.StatusBadRequest)
+			return
+		}
+		err = s.posts.SetLocked(r.Context(), postID, *req.Locked)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[MODERATION] Update error:", err)
+		http.Error(w, "cannot update post", http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := s.loadPost(r.Context(), postID, modID)
+	if err != nil {
+		log.Println("[MODERATION] Reload error:", err)
+		http.Error(w, "cannot load updated post", http.StatusInternalServerError)
+		return
+	}
+
+	s.publishPost(r.Context(), postID, false)
+
+	writeJSON(w, http.StatusOK, map[string]any{"post": updated})
+}
@@ -0,0 +1,76 @@
+// internal/http/handlers_polls.go
+package httpserver
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/ws"
+)
+
+// handlePollVote handles POST /api/posts/{id}/poll/vote {"option_ids": [..]}.
+// The new counts are published as "poll_update" to clients subscribed to
+// the post, its category or the whole feed.
+func (s *Server) handlePollVote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, ok := postIDFromPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		OptionIDs []int64 `json:"option_ids"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.polls.Vote(r.Context(), postID, userID, req.OptionIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrPollNotFound):
+			http.Error(w, "poll not found", http.StatusNotFound)
+		case errors.Is(err, models.ErrPollClosed):
+			http.Error(w, "poll is closed", http.StatusForbidden)
+		case errors.Is(err, models.ErrAlreadyVoted):
+			http.Error(w, "already voted", http.StatusConflict)
+		case errors.Is(err, models.ErrInvalidChoices):
+			http.Error(w, "invalid options", http.StatusBadRequest)
+		default:
+			log.Println("[POLL] Vote error:", err)
+			http.Error(w, "cannot record vote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.publish(r.Context(), results.PostID, ws.PollEvent{
+		Type:       "poll_update",
+		PostID:     results.PostID,
+		PollID:     results.PollID,
+		TotalVotes: results.TotalVotes,
+		Votes:      results.Votes,
+	})
+
+	poll, err := s.polls.ForPost(r.Context(), postID, userID)
+	if err != nil {
+		log.Println("[POLL] Reload error:", err)
+		http.Error(w, "cannot load poll", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"poll": poll})
+}
@@ -0,0 +1,161 @@
+// internal/http/handlers_qa.go
+package httpserver
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"real-time-forum/internal/models"
+)
+
+// handleAcceptedAnswer lets the author of a question pick its answer:
+//
+//	POST /api/posts/{id}/accepted {"comment_id": 12} -> {"post": {...}}
+//
+// A comment_id of 0 (or none) clears the accepted answer. Only root
+// comments can be accepted, and only in Q&A categories.
+func (s *Server) handleAcceptedAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	postID, ok := postIDFromPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok || userID <= 0 {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		CommentID int64 `json:"comment_id"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.posts.SetAcceptedAnswer(r.Context(), postID, userID, req.CommentID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case errors.Is(err, models.ErrNotQA), errors.Is(err, models.ErrInvalidAnswer):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println("[QA] Accept error:", err)
+			http.Error(w, "cannot accept answer", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	updated, err := s.loadPost(r.Context(), postID, userID)
+	if err != nil {
+		log.Println("[QA] Reload error:", err)
+		http.Error(w, "cannot load updated post", http.StatusInternalServerError)
+		return
+	}
+
+	s.publishPost(r.Context(), postID, false)
+
+	writeJSON(w, http.StatusOK, map[string]any{"post": updated})
+}
+
+// handleCommentVote votes an answer up or down:
+//
+//	POST /api/comments/{id}/vote {"value": 1|-1|0} -> {"comment_id", "score", "my_vote"}
+//
+// 0 withdraws the vote. Only comments on Q&A posts take votes.
+func (s *Server) handleCommentVote(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/comments/"), "/vote")
+	commentID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || commentID <= 0 {
+		http.Error(w, "invalid comment id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := getUserIDFromContext(r)
+	if !ok || userID <= 0 {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+	if _, ok := s.visibleComment(w, r, commentID, userID); !ok {
+		return
+	}
+
+	var req struct {
+		Value *int `json:"value"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil || req.Value == nil {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+
+	score, err := s.comments.Vote(r.Context(), commentID, userID, *req.Value)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "comment not found", http.StatusNotFound)
+		case errors.Is(err, models.ErrNotQA), errors.Is(err, models.ErrInvalidVote):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Println("[QA] Vote error:", err)
+			http.Error(w, "cannot vote", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"comment_id": commentID,
+		"score":      score,
+		"my_vote":    *req.Value,
+	})
+}
+
+// handleCategoryQA switches a category's Q&A mode (moderators only):
+//
+//	POST /api/categories/{name}/qa {"enabled": true} -> {"category": {...}}
+func (s *Server) handleCategoryQA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/categories/"), "/qa")
+
+	if _, ok := s.requireModerator(w, r); !ok {
+		return
+	}
+
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := decodeJSON(w, r, &req); err != nil || req.Enabled == nil {
+		http.Error(w, "enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	cat, err := s.categories.SetQA(r.Context(), name, *req.Enabled)
+	if err != nil {
+		if errors.Is(err, models.ErrCategoryNotFound) {
+			http.Error(w, "category not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[QA] Category error:", err)
+		http.Error(w, "cannot update category", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"category": cat})
+}
@@ -0,0 +1,181 @@
+// internal/http/handlers_revisions.go
+package httpserver
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/textdiff"
+)
+
+// handlePostRevisions routes:
+//
+//	GET  /api/posts/{id}/revisions                  -> full edit history
+//	GET  /api/posts/{id}/revisions/diff?from=1&to=3 -> line diff between two revisions
+//	POST /api/posts/{id}/revisions/{rev}/revert     -> moderators only
+func (s *Server) handlePostRevisions(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/posts/")
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(parts) < 2 || parts[1] != "revisions" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	postID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || postID <= 0 {
+		http.Error(w, "invalid post id", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 2:
+		s.handleListRevisions(w, r, postID)
+	case len(parts) == 3 && parts[2] == "diff":
+		s.handleRevisionDiff(w, r, postID)
+	case len(parts) == 4 && parts[3] == "revert":
+		rev, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil || rev <= 0 {
+			http.Error(w, "invalid revision", http.StatusBadRequest)
+			return
+		}
+		s.handleRevertRevision(w, r, postID, rev)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleListRevisions(w http.ResponseWriter, r *http.Request, postID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	revs, err := s.posts.ListRevisions(r.Context(), postID)
+	if err != nil {
+		log.Println("[REVISIONS] List error:", err)
+		http.Error(w, "cannot load revisions", http.StatusInternalServerError)
+		return
+	}
+	if len(revs) == 0 {
+		http.Error(w, "post not found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"post_id":   postID,
+		"revisions": revs,
+	})
+}
+
+func (s *Server) handleRevisionDiff(w http.ResponseWriter, r *http.Request, postID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, err1 := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	to, err2 := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err1 != nil || err2 != nil || from <= 0 || to <= 0 {
+		http.Error(w, "from and to revisions are required", http.StatusBadRequest)
+		return
+	}
+
+	a, err := s.posts.GetRevision(r.Context(), postID, from)
+	var b *models.PostRevision
+	if err == nil {
+		b, err = s.posts.GetRevision(r.Context(), postID, to)
+	}
+	if err != nil {
+		if errors.Is(err, models.ErrRevisionNotFound) {
+			http.Error(w, "revision not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[REVISIONS] Diff error:", err)
+		http.Error(w, "cannot load revisions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"post_id": postID,
+		"from":    a.Revision,
+		"to":      b.Revision,
+		"title":   textdiff.Lines(a.Title, b.Title),
+		"content": textdiff.Lines(a.Content, b.Content),
+		"category": map[string]string{
+			"from": a.Category,
+			"to":   b.Category,
+		},
+	})
+}
+
+func (s *Server) handleRevertRevision(w http.ResponseWriter, r *http.Request, postID, rev int64) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := s.requireModerator(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.posts.RevertToRevision(r.Context(), postID, rev, userID); err != nil {
+		if errors.Is(err, models.ErrRevisionNotFound) {
+			http.Error(w, "revision not found", http.StatusNotFound)
+			return
+		}
+		log.Println("[REVISIONS] Revert error:", err)
+		http.Error(w, "cannot revert post", http.StatusInternalServerError)
+		return
+	}
+
+	updated, err := s.loadPost(r.Context(), postID, userID)
+	if err != nil {
+		log.Println("[REVISIONS] Reload error:", err)
+		http.Error(w, "cannot load updated post", http.StatusInternalServerError)
+		return
+	}
+
+	s.publishPost(r.Context(), postID, false)
+
+	writeJSON(w, http.StatusOK, map[string]any{"post": updated})
+}
+
+// handleCommentRevisions lists a comment's earlier versions.
+//
+//	GET /api/comments/{id}/revisions -> {"comment_id": id, "revisions": [...]}
+//
+// Revision 1 is the original text; a comment never edited has none.
+func (s *Server) handleCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/comments/"), "/revisions")
+	commentID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || commentID <= 0 {
+		http.Error(w, "invalid comment id", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	viewerID, _ := getUserIDFromContext(r)
+	if _, ok := s.visibleComment(w, r, commentID, viewerID); !ok {
+		return
+	}
+
+	revs, err := s.comments.ListRevisions(r.Context(), commentID)
+	if err != nil {
+		log.Println("[REVISIONS] Comment list error:", err)
+		http.Error(w, "cannot load revisions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"comment_id": commentID,
+		"revisions":  revs,
+	})
+}
@@ -0,0 +1,99 @@
+// internal/http/handlers_search.go
+package httpserver
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/models"
+)
+
+// handleSearch runs a full-text search over posts and comments.
+//
+//	GET /api/search?q=go+channels&category=Go&author=gus&from=2024-01-01&to=2024-12-31&limit=20&offset=0
+//
+// from/to accept a date (YYYY-MM-DD) or an RFC3339 timestamp; a bare "to"
+// date includes the whole day.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+
+	params := models.SearchParams{
+		Query:    strings.TrimSpace(q.Get("q")),
+		Category: strings.TrimSpace(q.Get("category")),
+		Author:   strings.TrimSpace(q.Get("author")),
+		Limit:    20,
+	}
+	if params.Query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			params.Limit = n
+		}
+	}
+	if params.Limit > 50 {
+		params.Limit = 50
+	}
+	if v := q.Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			params.Offset = n
+		}
+	}
+
+	if v := q.Get("from"); v != "" {
+		t, _, err := parseDateParam(v)
+		if err != nil {
+			http.Error(w, "invalid from date", http.StatusBadRequest)
+			return
+		}
+		params.From = &t
+	}
+	if v := q.Get("to"); v != "" {
+		t, dateOnly, err := parseDateParam(v)
+		if err != nil {
+			http.Error(w, "invalid to date", http.StatusBadRequest)
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		params.To = &t
+	}
+
+	hits, hasMore, err := s.search.Search(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, models.ErrSearchUnavailable) {
+			http.Error(w, "search unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		log.Println("[SEARCH] Error:", err)
+		http.Error(w, "cannot search", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"results":     hits,
+		"has_more":    hasMore,
+		"next_offset": params.Offset + len(hits),
+	})
+}
+
+// parseDateParam accepts YYYY-MM-DD or RFC3339. dateOnly is true for the former.
+func parseDateParam(v string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, v)
+	return t, false, err
+}
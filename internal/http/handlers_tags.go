@@ -0,0 +1,69 @@
+// internal/http/handlers_tags.go
+package httpserver
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"real-time-forum/internal/models"
+)
+
+// handleTags serves tag autocomplete and counts.
+//
+//	GET /api/tags?q=go&limit=10 -> {"tags": [{"name": "golang", "count": 12}, ...]}
+//
+// Without q it returns the most used tags.
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Match what the user typed the way it will be stored; a prefix that
+	// cannot become a tag (e.g. "#" or "?!") matches nothing useful.
+	prefix := ""
+	if q := r.URL.Query().Get("q"); q != "" {
+		tag, err := models.NormalizeTag(q)
+		if err != nil {
+			writeJSON(w, http.StatusOK, map[string]any{"tags": []models.TagCount{}})
+			return
+		}
+		prefix = tag
+	}
+
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	tags, err := s.tags.Suggest(r.Context(), prefix, limit)
+	if err != nil {
+		log.Println("[TAGS] Suggest error:", err)
+		http.Error(w, "cannot load tags", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tags": tags})
+}
+
+// parseTags normalizes tags from a request body. On failure it writes a
+// 400 and returns false.
+func parseTags(w http.ResponseWriter, raw []string) ([]string, bool) {
+	tags, err := models.NormalizeTags(raw)
+	if err != nil {
+		if errors.Is(err, models.ErrTooManyTags) {
+			http.Error(w, "too many tags (5 max)", http.StatusBadRequest)
+			return nil, false
+		}
+		http.Error(w, "invalid tag", http.StatusBadRequest)
+		return nil, false
+	}
+	return tags, true
+}
@@ -0,0 +1,59 @@
+// internal/http/live.go
+package httpserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/ws"
+)
+
+// Live feed: handlers publish what changed on a post to the WebSocket
+// clients subscribed to the feed, the post's category or the post itself
+// (see ws/feed.go). Only published posts are announced.
+
+// publish sends a live event about postID to its subscribers.
+func (s *Server) publish(ctx context.Context, postID int64, payload any) {
+	category, err := s.posts.PublishedCategory(ctx, postID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Println("[LIVE] Topic error:", err)
+		}
+		return
+	}
+	s.hub.Publish(ws.Topic{PostID: postID, Category: category}, payload)
+}
+
+// publishPost sends a post as an anonymous viewer sees it, as
+// post_created for a new post or post_updated for a changed one.
+func (s *Server) publishPost(ctx context.Context, postID int64, created bool) {
+	post, err := s.loadPost(ctx, postID, 0)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Println("[LIVE] Load error:", err)
+		}
+		return
+	}
+	if post.Status != models.PostStatusPublished {
+		return
+	}
+
+	topic := ws.Topic{PostID: postID, Category: post.Category}
+	if created {
+		s.hub.Publish(topic, ws.PostCreatedEvent{Type: "post_created", PostID: postID, Post: post})
+		return
+	}
+	s.hub.Publish(topic, ws.PostUpdatedEvent{Type: "post_updated", PostID: postID, Post: post})
+}
+
+// AnnouncePublished sends post_created for posts published outside a
+// request: the scheduler in cmd/server calls it with what
+// PostModel.PublishDue returned.
+func (s *Server) AnnouncePublished(ctx context.Context, postIDs []int64) {
+	for _, id := range postIDs {
+		s.publishPost(ctx, id, true)
+	}
+}
@@ -0,0 +1,142 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"real-time-forum/internal/models"
+)
+
+// dialLive opens path (/ws/chat...) for a session on a running test server.
+func dialLive(t *testing.T, ts *httptest.Server, path string, cookie *http.Cookie) *websocket.Conn {
+	t.Helper()
+
+	header := http.Header{}
+	header.Add("Cookie", cookie.String())
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+path, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextEvent reads frames until one of type typ arrives.
+func nextEvent(t *testing.T, conn *websocket.Conn, typ string) map[string]any {
+	t.Helper()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var ev map[string]any
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatalf("waiting for %q: %v", typ, err)
+		}
+		if ev["type"] == typ {
+			return ev
+		}
+	}
+}
+
+func TestLiveFeedSubscriptions(t *testing.T) {
+	server := newTestServer(t)
+	go server.hub.Run()
+	ts := httptest.NewServer(server.Router())
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	author, authorCookie := newTestSession(t, server, "author")
+	reader, readerCookie := newTestSession(t, server, "reader")
+
+	post := &models.Post{UserID: author.ID, Title: "Hello", Content: "World", Category: "Travel"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	postPath := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	do := func(method, path, body string, cookie *http.Cookie) {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.AddCookie(cookie)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode >= 300 {
+			t.Fatalf("%s %s: got %d", method, path, res.StatusCode)
+		}
+	}
+
+	conn := dialLive(t, ts, "/ws/chat", readerCookie)
+	frames := []string{
+		`{"type":"subscribe","scope":"post","post_id":` + strconv.FormatInt(post.ID, 10) + `}`,
+		`{"type":"subscribe","scope":"category","category":"travel"}`,
+		// Frames are handled in order, so our own typing echo means the
+		// subscriptions are in place.
+		`{"type":"typing","to_user_id":` + strconv.FormatInt(reader.ID, 10) + `}`,
+	}
+	for _, frame := range frames {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextEvent(t, conn, "typing")
+
+	do(http.MethodPost, postPath+"/comments", `{"content":"First!"}`, authorCookie)
+	ev := nextEvent(t, conn, "comment_created")
+	if ev["post_id"] != float64(post.ID) || ev["comment"].(map[string]any)["content"] != "First!" {
+		t.Fatalf("comment_created = %v", ev)
+	}
+
+	do(http.MethodPost, postPath+"/reactions", `{"reaction":"like"}`, authorCookie)
+	ev = nextEvent(t, conn, "reaction_changed")
+	if ev["reactions"].(map[string]any)["like"] != float64(1) {
+		t.Fatalf("reaction_changed = %v", ev)
+	}
+
+	do(http.MethodPatch, postPath, `{"title":"Hello again"}`, authorCookie)
+	ev = nextEvent(t, conn, "post_updated")
+	if ev["post"].(map[string]any)["title"] != "Hello again" {
+		t.Fatalf("post_updated = %v", ev)
+	}
+
+	do(http.MethodPost, postPath+"/views", ``, readerCookie)
+	ev = nextEvent(t, conn, "post_updated")
+	if ev["views_count"] != float64(1) {
+		t.Fatalf("post_updated views = %v", ev)
+	}
+
+	// The category subscription hears about new posts there; drafts and
+	// other categories stay quiet.
+	do(http.MethodPost, "/api/posts", `{"title":"Secret","content":"Draft","category":"Travel","status":"draft"}`, authorCookie)
+	do(http.MethodPost, "/api/posts", `{"title":"Elsewhere","content":"Body","category":"General"}`, authorCookie)
+	do(http.MethodPost, "/api/posts", `{"title":"Trip","content":"Report","category":"Travel"}`, authorCookie)
+	ev = nextEvent(t, conn, "post_created")
+	created := ev["post"].(map[string]any)
+	if created["title"] != "Trip" || created["author"] != "author" {
+		t.Fatalf("post_created = %v", ev)
+	}
+
+	// Scheduled posts are announced when the scheduler publishes them.
+	soon := time.Now().Add(time.Minute)
+	scheduled := &models.Post{UserID: author.ID, Title: "Later", Content: "Body", Category: "Travel", Status: models.PostStatusScheduled, PublishAt: &soon}
+	if err := server.posts.Create(ctx, scheduled); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := server.posts.PublishDue(ctx, time.Now().Add(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.AnnouncePublished(ctx, ids)
+	ev = nextEvent(t, conn, "post_created")
+	if ev["post"].(map[string]any)["title"] != "Later" {
+		t.Fatalf("scheduled post_created = %v", ev)
+	}
+}
@@ -0,0 +1,56 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestLockedThreadRejectsComments(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, modCookie := newTestSession(t, server, "mod")
+	if err := server.users.SetRoleByNickname(ctx, "mod", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.Post{UserID: owner.ID, Title: "Rules", Content: "be nice", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	// Only moderators may lock or pin.
+	if rec := doJSON(t, server, http.MethodPost, base+"/lock", `{"locked":true}`, ownerCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("owner lock: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/pin", `{"pinned":true,"scope":"everywhere"}`, modCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad scope: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/pin", `{"pinned":true}`, modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("mod pin: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/lock", `{"locked":true}`, modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("mod lock: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := doJSON(t, server, http.MethodPost, base+"/comments", `{"content":"hello"}`, ownerCookie)
+	if rec.Code != http.StatusLocked {
+		t.Fatalf("comment on locked thread: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/comments", `{"content":"closing this"}`, modCookie); rec.Code != http.StatusCreated {
+		t.Fatalf("moderator comment on locked thread: got %d", rec.Code)
+	}
+
+	if rec := doJSON(t, server, http.MethodPost, base+"/lock", `{"locked":false}`, modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("mod unlock: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/comments", `{"content":"hello"}`, ownerCookie); rec.Code != http.StatusCreated {
+		t.Fatalf("comment after unlock: got %d", rec.Code)
+	}
+}
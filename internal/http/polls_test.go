@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestPollVoteEndpoint(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, _ := newTestSession(t, server, "owner")
+	_, voterCookie := newTestSession(t, server, "voter")
+
+	post := &models.Post{UserID: owner.ID, Title: "Lunch", Content: "where?", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	poll, err := models.NewPoll("Where to eat?", []string{"pizza", "sushi"}, false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.polls.Create(ctx, post.ID, poll); err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+	vote := fmt.Sprintf(`{"option_ids":[%d]}`, poll.Options[1].ID)
+
+	if rec := doJSON(t, server, http.MethodPost, base+"/poll/vote", vote, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous vote: got %d", rec.Code)
+	}
+	rec := doJSON(t, server, http.MethodPost, base+"/poll/vote", vote, voterCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("vote: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/poll/vote", vote, voterCookie); rec.Code != http.StatusConflict {
+		t.Fatalf("second vote: got %d", rec.Code)
+	}
+
+	// Results ride along on the post detail.
+	rec = doJSON(t, server, http.MethodGet, base, "", voterCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get post: got %d", rec.Code)
+	}
+	var body struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	p := body.Post.Poll
+	if p == nil || p.TotalVotes != 1 || p.Options[1].Votes != 1 || len(p.MyVotes) != 1 {
+		t.Fatalf("poll in detail = %+v", p)
+	}
+}
@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestQAEndpoints(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	asker, askerCookie := newTestSession(t, server, "asker")
+	helper, helperCookie := newTestSession(t, server, "helper")
+	_, modCookie := newTestSession(t, server, "mod")
+	if err := server.users.SetRoleByNickname(ctx, "mod", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Travel is not a Q&A category until a moderator says so.
+	post := &models.Post{UserID: asker.ID, Title: "Visa?", Content: "Do I need one?", Category: "Travel"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	answer := &models.Comment{PostID: post.ID, UserID: helper.ID, Content: "Yes"}
+	if err := server.comments.Create(ctx, answer); err != nil {
+		t.Fatal(err)
+	}
+	votePath := "/api/comments/" + strconv.FormatInt(answer.ID, 10) + "/vote"
+	acceptPath := "/api/posts/" + strconv.FormatInt(post.ID, 10) + "/accepted"
+	acceptBody := `{"comment_id":` + strconv.FormatInt(answer.ID, 10) + `}`
+
+	if rec := do(http.MethodPost, votePath, `{"value":1}`, askerCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("vote outside Q&A: got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/categories/travel/qa", `{"enabled":true}`, helperCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-moderator QA toggle: got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/api/categories/travel/qa", `{"enabled":true}`, modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("moderator QA toggle: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := do(http.MethodPost, votePath, `{"value":1}`, askerCookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"score":1`) {
+		t.Fatalf("vote: got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodPost, votePath, `{"value":1}`, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous vote: got %d", rec.Code)
+	}
+
+	if rec := doJSON(t, server, http.MethodPost, acceptPath, acceptBody, helperCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("accept by non-author: got %d", rec.Code)
+	}
+	rec = doJSON(t, server, http.MethodPost, acceptPath, acceptBody, askerCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("accept: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Post.QA || resp.Post.AcceptedCommentID == nil || *resp.Post.AcceptedCommentID != answer.ID {
+		t.Fatalf("accepted post = %+v", resp.Post)
+	}
+
+	if rec := do(http.MethodGet, "/api/posts?solved=maybe", "", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad solved filter: got %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/api/posts?solved=solved", "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"title":"Visa?"`) {
+		t.Fatalf("solved feed: got %d: %s", rec.Code, rec.Body.String())
+	}
+}
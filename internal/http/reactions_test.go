@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestPostReactionsKeepLegacyFields(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	author, cookie := newTestSession(t, server, "author")
+	post := &models.Post{UserID: author.ID, Title: "Hello", Content: "World", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(post.ID, 10) + "/reactions"
+
+	get := func(query string) map[string]any {
+		t.Helper()
+		rec := doJSON(t, server, http.MethodGet, path+query, "", cookie)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: got %d", query, rec.Code)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	rec := doJSON(t, server, http.MethodPost, path, `{"reaction":"love"}`, cookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST: got %d", rec.Code)
+	}
+	var toggled map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &toggled); err != nil {
+		t.Fatal(err)
+	}
+	if toggled["reactions_count"] != float64(1) || toggled["i_reacted"] != true {
+		t.Fatalf("POST = %v, want reactions_count 1 and i_reacted", toggled)
+	}
+
+	// Without ?reaction= the old fields describe "like".
+	body := get("")
+	if body["reaction"] != "like" || body["reactions_count"] != float64(0) || body["i_reacted"] != false {
+		t.Fatalf("GET = %v, want no likes", body)
+	}
+	if body["reactions"].(map[string]any)["love"] != float64(1) {
+		t.Fatalf("GET reactions = %v", body["reactions"])
+	}
+
+	body = get("?reaction=Love")
+	if body["reaction"] != "love" || body["reactions_count"] != float64(1) || body["i_reacted"] != true {
+		t.Fatalf("GET ?reaction=love = %v", body)
+	}
+
+	if rec := doJSON(t, server, http.MethodGet, path+"?reaction=angry", "", cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("GET ?reaction=angry: got %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,213 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	appdb "real-time-forum/internal/db"
+	"real-time-forum/internal/models"
+	"real-time-forum/internal/storage"
+	"real-time-forum/internal/ws"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	db, err := appdb.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := appdb.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Storage = storage.NewLocal(t.TempDir())
+	return NewServerWithConfig(db, ws.NewHub(), cfg)
+}
+
+// newTestSession creates a user and a session for it, returning both.
+func newTestSession(t *testing.T, server *Server, nickname string) (*models.User, *http.Cookie) {
+	t.Helper()
+
+	user := &models.User{
+		Nickname:  nickname,
+		Age:       30,
+		Gender:    "other",
+		FirstName: nickname,
+		LastName:  "Tester",
+		Email:     nickname + "@example.com",
+	}
+	if err := server.users.Create(context.Background(), user, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.createSession(context.Background(), "session-"+nickname, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	return user, &http.Cookie{Name: "session_id", Value: "session-" + nickname}
+}
+
+func doJSON(t *testing.T, server *Server, method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	server.withSessionMiddleware(http.HandlerFunc(server.handlePostDetail)).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPostRevisionsDiffAndModeratorRevert(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, modCookie := newTestSession(t, server, "mod")
+	if err := server.users.SetRoleByNickname(ctx, "mod", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.Post{UserID: owner.ID, Title: "Hello", Content: "line one\nline two", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	rec := doJSON(t, server, http.MethodPatch, base, `{"content":"line one\nline 2"}`, ownerCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH status = %d; body=%q", rec.Code, rec.Body.String())
+	}
+
+	rec = doJSON(t, server, http.MethodGet, base+"/revisions", "", nil)
+	var list struct {
+		Revisions []models.PostRevision `json:"revisions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Revisions) != 2 || list.Revisions[1].EditorID != owner.ID {
+		t.Fatalf("revisions = %+v, want 2 with owner as editor", list.Revisions)
+	}
+
+	rec = doJSON(t, server, http.MethodGet, base+"/revisions/diff?from=1&to=2", "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `{"op":"insert","text":"line 2"}`) {
+		t.Fatalf("diff status = %d; body=%q", rec.Code, rec.Body.String())
+	}
+
+	if rec := doJSON(t, server, http.MethodPost, base+"/revisions/1/revert", "", ownerCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("owner revert status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := doJSON(t, server, http.MethodPost, base+"/revisions/1/revert", "", modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("moderator revert status = %d; body=%q", rec.Code, rec.Body.String())
+	}
+
+	got, err := server.posts.Get(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "line one\nline two" {
+		t.Fatalf("content after revert = %q", got.Content)
+	}
+}
+
+func TestEditWindowLeavesModeratorsEditing(t *testing.T) {
+	server := newTestServer(t)
+	router := server.Router()
+	ctx := context.Background()
+
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, modCookie := newTestSession(t, server, "mod")
+	if err := server.users.SetRoleByNickname(ctx, "mod", models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &models.Post{UserID: owner.ID, Title: "Old", Content: "old news", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	c := &models.Comment{PostID: post.ID, UserID: owner.ID, Content: "old reply"}
+	if err := server.comments.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+	// Both were written two days ago, past the default 24h window.
+	if _, err := server.db.Exec(`UPDATE posts SET created_at = datetime('now', '-2 days') WHERE id = ?`, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.db.Exec(`UPDATE comments SET created_at = datetime('now', '-2 days') WHERE id = ?`, c.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	postPath := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+	if rec := doJSON(t, server, http.MethodPatch, postPath, `{"content":"too late"}`, ownerCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("late owner post edit: got %d", rec.Code)
+	}
+	// Tags are held to the same rule.
+	if rec := doJSON(t, server, http.MethodPatch, postPath, `{"tags":["late"]}`, ownerCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("late owner tags edit: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPatch, postPath, `{"tags":["moderated"]}`, modCookie); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tags":["moderated"]`) {
+		t.Fatalf("moderator tags edit: got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := doJSON(t, server, http.MethodPatch, postPath, `{"content":"moderated"}`, modCookie)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"edited_at"`) {
+		t.Fatalf("moderator post edit: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	commentPath := "/api/comments/" + strconv.FormatInt(c.ID, 10)
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do(http.MethodPatch, commentPath, `{"content":"too late"}`, ownerCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("late owner comment edit: got %d", rec.Code)
+	}
+	if rec := do(http.MethodPatch, commentPath, `{"content":"moderated"}`, modCookie); rec.Code != http.StatusOK {
+		t.Fatalf("moderator comment edit: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, commentPath+"/revisions", "", ownerCookie)
+	var list struct {
+		Revisions []models.CommentRevision `json:"revisions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Revisions) != 2 || list.Revisions[0].Content != "old reply" || list.Revisions[1].Editor != "mod" {
+		t.Fatalf("comment revisions = %+v", list.Revisions)
+	}
+}
+
+func TestPatchPostRejectsOversizedContent(t *testing.T) {
+	server := newTestServer(t)
+	owner, ownerCookie := newTestSession(t, server, "owner")
+
+	post := &models.Post{UserID: owner.ID, Title: "Hello", Content: "body", Category: "General"}
+	if err := server.posts.Create(context.Background(), post); err != nil {
+		t.Fatal(err)
+	}
+	path := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	long := strings.Repeat("é", models.MaxContentLen+1)
+	if rec := doJSON(t, server, http.MethodPatch, path, `{"content":"`+long+`"}`, ownerCookie); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too long") {
+		t.Fatalf("long content: got %d %q", rec.Code, rec.Body.String())
+	}
+	huge := strings.Repeat(" ", maxJSONBody) + `{"content":"x"}`
+	if rec := doJSON(t, server, http.MethodPatch, path, huge, ownerCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("huge body: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPatch, path, `{"content":"`+long[:len(long)-4]+`"}`, ownerCookie); rec.Code != http.StatusOK {
+		t.Fatalf("content at the limit: got %d %q", rec.Code, rec.Body.String())
+	}
+}
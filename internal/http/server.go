@@ -7,11 +7,13 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -22,36 +24,82 @@ import (
 
 // Server holds shared application dependencies.
 type Server struct {
-	db         *sql.DB
-	hub        *ws.Hub
-	users      *models.UserModel
-	posts      *models.PostModel
-	categories *models.CategoryModel
-	comments   *models.CommentModel
-	messages   *models.MessageModel
+	cfg         Config
+	db          *sql.DB
+	hub         *ws.Hub
+	users       *models.UserModel
+	posts       *models.PostModel
+	categories  *models.CategoryModel
+	comments    *models.CommentModel
+	messages    *models.MessageModel
+	chatEvents  *models.ChatEventModel
+	search      *models.SearchModel
+	reactions   *models.ReactionModel
+	attachments *models.AttachmentModel
+	polls       *models.PollModel
+	tags        *models.TagModel
+	bookmarks   *models.BookmarkModel
+	follows     *models.FollowModel
 }
 
 // createPostRequest represents the JSON payload used to create a new post.
 type createPostRequest struct {
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	Category string `json:"category"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+
+	// Optional: "draft", "scheduled" (requires PublishAt) or "published" (default).
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	// Optional poll created together with the post.
+	Poll *createPollRequest `json:"poll"`
+}
+
+type createPollRequest struct {
+	Question  string     `json:"question"`
+	Options   []string   `json:"options"`
+	Multiple  bool       `json:"multiple"`
+	Anonymous *bool      `json:"anonymous"` // default true
+	ClosesAt  *time.Time `json:"closes_at"`
 }
 
-// NewServer creates a new Server instance with all required components.
+// NewServer creates a new Server instance with all required components,
+// using DefaultConfig.
 func NewServer(db *sql.DB, hub *ws.Hub) *Server {
+	return NewServerWithConfig(db, hub, DefaultConfig())
+}
+
+// NewServerWithConfig is NewServer with explicit settings.
+func NewServerWithConfig(db *sql.DB, hub *ws.Hub, cfg Config) *Server {
 	s := &Server{
-		db:         db,
-		hub:        hub,
-		users:      &models.UserModel{DB: db},
-		posts:      &models.PostModel{DB: db},
-		categories: &models.CategoryModel{DB: db},
-		comments:   &models.CommentModel{DB: db},
-		messages:   &models.MessageModel{DB: db},
+		cfg:         cfg,
+		db:          db,
+		hub:         hub,
+		users:       &models.UserModel{DB: db},
+		posts:       &models.PostModel{DB: db, EditWindow: cfg.EditWindow},
+		categories:  &models.CategoryModel{DB: db},
+		messages:    &models.MessageModel{DB: db},
+		chatEvents:  &models.ChatEventModel{DB: db},
+		search:      &models.SearchModel{DB: db},
+		reactions:   &models.ReactionModel{DB: db, Allowed: cfg.Reactions},
+		attachments: &models.AttachmentModel{DB: db},
+		polls:       &models.PollModel{DB: db},
+		tags:        &models.TagModel{DB: db},
+		bookmarks:   &models.BookmarkModel{DB: db},
+		follows:     &models.FollowModel{DB: db},
 	}
+	s.comments = &models.CommentModel{DB: db, Reactions: s.reactions, EditWindow: cfg.EditWindow}
 
 	// Wire WS persistence (save to DB before broadcast).
 	hub.OnMessage = func(ctx context.Context, in ws.MessageEvent) (ws.MessageEvent, error) {
+		if _, err := s.users.GetByID(ctx, in.ToUserID); err != nil {
+			if errors.Is(err, models.ErrUserNotFound) {
+				return in, fmt.Errorf("no such user: %w", ws.ErrBlocked)
+			}
+			return in, err
+		}
 		msg, err := s.messages.Create(ctx, in.FromUserID, in.ToUserID, in.Content)
 		if err != nil {
 			return in, err
@@ -65,6 +113,10 @@ func NewServer(db *sql.DB, hub *ws.Hub) *Server {
 	// 1) DELIVERED: recipient acks, we persist and notify sender
 	hub.OnDelivered = func(ctx context.Context, receiverID, messageID int64) (int64, string, error) {
 		fromUserID, deliveredAt, err := s.messages.MarkDelivered(ctx, receiverID, messageID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Not a message sent to this user.
+			return 0, "", fmt.Errorf("not your message: %w", ws.ErrBlocked)
+		}
 		if err != nil {
 			return 0, "", err
 		}
@@ -80,6 +132,23 @@ func NewServer(db *sql.DB, hub *ws.Hub) *Server {
 		return otherUserID, viewerID, seenUpToID, seenAt.UTC().Format(time.RFC3339), nil
 	}
 
+	// 3) EVENT LOG: every message/delivered/seen a user gets is numbered and
+	// kept, so a reconnecting tab can replay what it missed.
+	hub.OnEvent = func(ctx context.Context, userID int64, eventType, payload string) (int64, error) {
+		return s.chatEvents.Append(ctx, userID, eventType, payload)
+	}
+	hub.OnReplay = func(ctx context.Context, userID, since int64, limit int) ([]ws.StoredEvent, int64, bool, error) {
+		events, last, complete, err := s.chatEvents.Since(ctx, userID, since, limit)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		stored := make([]ws.StoredEvent, 0, len(events))
+		for _, ev := range events {
+			stored = append(stored, ws.StoredEvent{Seq: ev.Seq, Type: ev.Type, Payload: ev.Payload})
+		}
+		return stored, last, complete, nil
+	}
+
 	// offline-Online
 	hub.OnOffline = func(ctx context.Context, userID int64) (string, error) {
 		now := time.Now().UTC()
@@ -116,45 +185,128 @@ func writeJSON(w http.ResponseWriter, status int, data any) {
 	_ = json.NewEncoder(w).Encode(data)
 }
 
+// maxJSONBody caps request bodies read by decodeJSON. Post and comment
+// content is capped separately (models.MaxContentLen); this only has to
+// leave room for that, escaped, plus the other fields.
+const maxJSONBody = 1 << 20
+
+// decodeJSON decodes the request body into v, reading at most maxJSONBody
+// bytes.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBody)
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
 // ------------------------------------------------------------
 // POSTS (list/create)
 // ------------------------------------------------------------
 
+// handlePosts serves /api/posts (GET feed, POST create) and the personal
+// feed GET /api/feed/following, which takes the same query parameters but
+// only lists posts from followed authors and categories.
 func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
+	following := r.URL.Path == "/api/feed/following"
+	if following && r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		viewerID, _ := getUserIDFromContext(r)
 
-		limit := int64(10)
-		offset := int64(0)
+		q := models.FeedQuery{Limit: 10}
+		if following {
+			if viewerID <= 0 {
+				http.Error(w, "unauthorised", http.StatusUnauthorized)
+				return
+			}
+			q.FollowedBy = viewerID
+		}
 
 		if v := r.URL.Query().Get("limit"); v != "" {
 			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
-				limit = n
+				q.Limit = n
 			}
 		}
-		if v := r.URL.Query().Get("offset"); v != "" {
-			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
-				offset = n
+		if q.Limit > 50 {
+			q.Limit = 50
+		}
+
+		// ?sort=new|hot|top|discussed|active, ?window=day|week|month|all (top only)
+		var err error
+		if q.Sort, err = models.ParseSort(r.URL.Query().Get("sort")); err != nil {
+			http.Error(w, "invalid sort", http.StatusBadRequest)
+			return
+		}
+		if q.Window, err = models.ParseWindow(r.URL.Query().Get("window")); err != nil {
+			http.Error(w, "invalid window", http.StatusBadRequest)
+			return
+		}
+		q.Category = strings.TrimSpace(r.URL.Query().Get("category"))
+		// ?solved=solved|unsolved keeps only questions (Q&A categories).
+		if q.Solved, err = models.ParseSolved(r.URL.Query().Get("solved")); err != nil {
+			http.Error(w, "invalid solved filter", http.StatusBadRequest)
+			return
+		}
+		if v := r.URL.Query().Get("tag"); v != "" {
+			if q.Tag, err = models.NormalizeTag(v); err != nil {
+				http.Error(w, "invalid tag", http.StatusBadRequest)
+				return
 			}
 		}
-		if limit > 50 {
-			limit = 50
+
+		// Cursor pagination (preferred): ?cursor=<next_cursor from previous page>.
+		if v := r.URL.Query().Get("cursor"); v != "" {
+			cursor, err := models.DecodeFeedCursor(v)
+			if err != nil || cursor.Sort != q.Sort {
+				http.Error(w, "invalid cursor", http.StatusBadRequest)
+				return
+			}
+			q.After = &cursor
+		} else if v := r.URL.Query().Get("offset"); v != "" {
+			// Deprecated: offset pages shift when new posts arrive.
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+				q.Offset = n
+			}
+			w.Header().Set("Deprecation", "true")
 		}
 
-		posts, hasMore, err := s.posts.ListWithReactionsPage(r.Context(), limit, offset, viewerID)
+		posts, next, err := s.posts.ListWithReactionsPage(r.Context(), q, viewerID)
+		if err == nil {
+			err = s.reactions.Attach(r.Context(), posts, viewerID)
+		}
+		if err == nil {
+			err = s.attachments.Attach(r.Context(), posts)
+		}
+		if err == nil {
+			err = s.tags.Attach(r.Context(), posts)
+		}
 		if err != nil {
 			log.Println("[POSTS] Error loading posts:", err)
 			http.Error(w, "cannot load posts", http.StatusInternalServerError)
 			return
 		}
 
-		nextOffset := offset + int64(len(posts))
+		nextCursor := ""
+		if next != nil {
+			nextCursor = next.Encode()
+		}
+
+		// Pinned posts ride along on the first page; they do not count
+		// towards the deprecated offset.
+		listed := int64(0)
+		for _, p := range posts {
+			if !q.PinnedInScope(p) {
+				listed++
+			}
+		}
 
 		writeJSON(w, http.StatusOK, map[string]any{
 			"posts":       posts,
-			"has_more":    hasMore,
-			"next_offset": nextOffset,
+			"has_more":    next != nil,
+			"next_cursor": nextCursor,
+			"next_offset": q.Offset + listed, // deprecated, kept for old clients
 		})
 
 	case http.MethodPost:
@@ -165,48 +317,79 @@ func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var req createPostRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSON(w, r, &req); err != nil {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
 
 		req.Title = strings.TrimSpace(req.Title)
 		req.Content = strings.TrimSpace(req.Content)
-		req.Category = strings.TrimSpace(req.Category)
 
 		if req.Title == "" || req.Content == "" {
 			http.Error(w, "title and content are required", http.StatusBadRequest)
 			return
 		}
-		if req.Category == "" {
-			req.Category = "General"
-		}
 
-		const maxCategories = 30
-		cat, err := s.categories.Ensure(r.Context(), req.Category, maxCategories)
+		status, err := models.ParsePostStatus(req.Status)
 		if err != nil {
-			if errors.Is(err, models.ErrCategoryLimit) {
-				http.Error(w, "category limit reached (30)", http.StatusBadRequest)
+			http.Error(w, "invalid status", http.StatusBadRequest)
+			return
+		}
+		if status == models.PostStatusScheduled {
+			if req.PublishAt == nil {
+				http.Error(w, "publish_at is required for scheduled posts", http.StatusBadRequest)
 				return
 			}
-			log.Println("[POSTS] Error ensuring category:", err)
-			http.Error(w, "cannot use category", http.StatusInternalServerError)
+			if !req.PublishAt.After(time.Now()) {
+				http.Error(w, "publish_at must be in the future", http.StatusBadRequest)
+				return
+			}
+		}
+
+		tags, ok := parseTags(w, req.Tags)
+		if !ok {
+			return
+		}
+
+		var poll *models.Poll
+		if req.Poll != nil {
+			anonymous := req.Poll.Anonymous == nil || *req.Poll.Anonymous
+			poll, err = models.NewPoll(req.Poll.Question, req.Poll.Options, req.Poll.Multiple, anonymous, req.Poll.ClosesAt)
+			if err != nil {
+				http.Error(w, "invalid poll: needs a question, 2-10 distinct options and a future close time", http.StatusBadRequest)
+				return
+			}
+		}
+
+		category, ok := s.ensureCategory(w, r, req.Category)
+		if !ok {
 			return
 		}
 
 		post := &models.Post{
-			UserID:   userID,
-			Title:    req.Title,
-			Content:  req.Content,
-			Category: cat.Name,
+			UserID:    userID,
+			Title:     req.Title,
+			Content:   req.Content,
+			Category:  category,
+			Status:    status,
+			PublishAt: req.PublishAt,
+			Tags:      tags,
+			Poll:      poll,
 		}
 
+		// The post, its tags and its poll are stored together or not at all.
 		if err := s.posts.Create(r.Context(), post); err != nil {
+			if errors.Is(err, models.ErrContentTooLong) {
+				http.Error(w, "content is too long", http.StatusBadRequest)
+				return
+			}
 			log.Println("[POSTS] Error creating post:", err)
 			http.Error(w, "cannot create post", http.StatusInternalServerError)
 			return
 		}
 
+		s.publishPost(r.Context(), post.ID, true)
+
 		writeJSON(w, http.StatusCreated, map[string]any{"post": post})
 
 	default:
@@ -214,26 +397,99 @@ func (s *Server) handlePosts(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ensureCategory resolves a category name for a new or edited post
+// ("" means General), creating it if needed. On failure it writes the
+// error response and returns false.
+func (s *Server) ensureCategory(w http.ResponseWriter, r *http.Request, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "General"
+	}
+
+	const maxCategories = 30
+	cat, err := s.categories.Ensure(r.Context(), name, maxCategories)
+	if err != nil {
+		if errors.Is(err, models.ErrCategoryLimit) {
+			http.Error(w, "category limit reached (30)", http.StatusBadRequest)
+			return "", false
+		}
+		log.Println("[POSTS] Error ensuring category:", err)
+		http.Error(w, "cannot use category", http.StatusInternalServerError)
+		return "", false
+	}
+	return cat.Name, true
+}
+
 // ------------------------------------------------------------
 // POST DETAIL router (/api/posts/{id} + subroutes)
 // ------------------------------------------------------------
 
 func (s *Server) handlePostDetail(w http.ResponseWriter, r *http.Request) {
+	// Drafts and scheduled posts (and everything hanging off them) exist
+	// only for their author.
+	if postID, ok := postIDFromPath(r.URL.Path); ok {
+		viewerID, _ := getUserIDFromContext(r)
+		visible, err := s.posts.VisibleTo(r.Context(), postID, viewerID)
+		if err != nil {
+			log.Println("[POST] Visibility error:", err)
+			http.Error(w, "cannot load post", http.StatusInternalServerError)
+			return
+		}
+		if !visible {
+			http.Error(w, "post not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if strings.Contains(r.URL.Path, "/revisions") {
+		s.handlePostRevisions(w, r)
+		return
+	}
 	if strings.HasSuffix(r.URL.Path, "/comments") {
 		s.handlePostComments(w, r)
 		return
 	}
-	if strings.HasSuffix(r.URL.Path, "/reactions") {
+	if strings.HasSuffix(r.URL.Path, "/reactions") || strings.HasSuffix(r.URL.Path, "/reactions/users") {
 		s.handlePostReactions(w, r)
 		return
 	}
+	if strings.HasSuffix(r.URL.Path, "/attachments") {
+		s.handlePostAttachments(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/pin") || strings.HasSuffix(r.URL.Path, "/lock") {
+		s.handlePostModeration(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/bookmark") {
+		s.handlePostBookmark(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/poll/vote") {
+		s.handlePollVote(w, r)
+		return
+	}
 	if strings.HasSuffix(r.URL.Path, "/views") {
 		s.handlePostViews(w, r)
 		return
 	}
+	if strings.HasSuffix(r.URL.Path, "/accepted") {
+		s.handleAcceptedAnswer(w, r)
+		return
+	}
 	s.handlePostByID(w, r)
 }
 
+// postIDFromPath extracts {id} from /api/posts/{id}[/...].
+func postIDFromPath(path string) (int64, bool) {
+	rest := strings.TrimPrefix(path, "/api/posts/")
+	if idx := strings.IndexRune(rest, '/'); idx != -1 {
+		rest = rest[:idx]
+	}
+	id, err := strconv.ParseInt(rest, 10, 64)
+	return id, err == nil && id > 0
+}
+
 // ------------------------------------------------------------
 // POST BY ID: GET + PATCH
 // ------------------------------------------------------------
@@ -259,7 +515,7 @@ func (s *Server) handlePostByID(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 
 	case http.MethodGet:
-		post, err := s.posts.GetWithReactions(r.Context(), postID, viewerID)
+		post, err := s.loadPost(r.Context(), postID, viewerID)
 		if err != nil {
 			if errors.Is(err, sql.ErrNoRows) {
 				http.Error(w, "post not found", http.StatusNotFound)
@@ -270,17 +526,9 @@ func (s *Server) handlePostByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		comments, err := s.comments.ListByPost(r.Context(), postID)
-		if err != nil {
-			log.Println("[POST] Comments error:", err)
-			http.Error(w, "cannot load comments", http.StatusInternalServerError)
-			return
-		}
-
-		writeJSON(w, http.StatusOK, map[string]any{
-			"post":     post,
-			"comments": comments,
-		})
+		// Comments are paged separately (GET /api/posts/{id}/comments);
+		// post.comments_count says how many there are.
+		writeJSON(w, http.StatusOK, map[string]any{"post": post})
 		return
 
 	case http.MethodPatch:
@@ -290,15 +538,24 @@ func (s *Server) handlePostByID(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var req struct {
-			Title    *string `json:"title"`
-			Content  *string `json:"content"`
-			Category *string `json:"category"`
+			Title    *string   `json:"title"`
+			Content  *string   `json:"content"`
+			Category *string   `json:"category"`
+			Tags     *[]string `json:"tags"` // replaces all tags; [] clears them
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSON(w, r, &req); err != nil {
 			http.Error(w, "invalid json body", http.StatusBadRequest)
 			return
 		}
 
+		var tags []string
+		if req.Tags != nil {
+			var ok bool
+			if tags, ok = parseTags(w, *req.Tags); !ok {
+				return
+			}
+		}
+
 		if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
 			http.Error(w, "title cannot be empty", http.StatusBadRequest)
 			return
@@ -308,23 +565,75 @@ func (s *Server) handlePostByID(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := s.posts.UpdateByOwner(r.Context(), postID, viewerID, req.Title, req.Content, req.Category); err != nil {
+		// Authors edit within the edit window; moderators edit any time.
+		// Tags follow the same rule, but a tags-only PATCH leaves the post
+		// itself (and edited_at) alone, so it only checks the rule.
+		updateFields := req.Tags == nil || req.Title != nil || req.Content != nil || req.Category != nil
+		var err error
+		if updateFields {
+			err = s.posts.UpdateByOwner(r.Context(), postID, viewerID, req.Title, req.Content, req.Category)
+		} else {
+			err = s.posts.CanEdit(r.Context(), postID, viewerID)
+		}
+		asModerator := false
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, models.ErrEditWindowClosed) {
+			isMod, modErr := s.users.IsModerator(r.Context(), viewerID)
+			if modErr != nil {
+				log.Println("[POST] Role error:", modErr)
+				http.Error(w, "cannot update post", http.StatusInternalServerError)
+				return
+			}
+			if isMod {
+				asModerator, err = true, nil
+				if updateFields {
+					err = s.posts.UpdateAsModerator(r.Context(), postID, viewerID, req.Title, req.Content, req.Category)
+				}
+			}
+		}
+		if err != nil {
+			if errors.Is(err, models.ErrEditWindowClosed) {
+				http.Error(w, "edit window closed", http.StatusForbidden)
+				return
+			}
 			if errors.Is(err, sql.ErrNoRows) {
 				http.Error(w, "forbidden", http.StatusForbidden)
 				return
 			}
+			if errors.Is(err, models.ErrContentTooLong) {
+				http.Error(w, "content is too long", http.StatusBadRequest)
+				return
+			}
 			log.Println("[POST] Update error:", err)
 			http.Error(w, "cannot update post", http.StatusInternalServerError)
 			return
 		}
 
-		updated, err := s.posts.GetWithReactions(r.Context(), postID, viewerID)
+		if req.Tags != nil {
+			if asModerator {
+				err = s.tags.SetForPostAsModerator(r.Context(), postID, tags)
+			} else {
+				err = s.tags.SetForPost(r.Context(), postID, viewerID, tags)
+			}
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+				log.Println("[POST] Tags error:", err)
+				http.Error(w, "cannot update tags", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		updated, err := s.loadPost(r.Context(), postID, viewerID)
 		if err != nil {
 			log.Println("[POST] Reload error:", err)
 			http.Error(w, "cannot load updated post", http.StatusInternalServerError)
 			return
 		}
 
+		s.publishPost(r.Context(), postID, false)
+
 		writeJSON(w, http.StatusOK, map[string]any{"post": updated})
 		return
 
@@ -338,8 +647,44 @@ func (s *Server) handlePostByID(w http.ResponseWriter, r *http.Request) {
 // POST REACTIONS
 // ------------------------------------------------------------
 
+// loadPost returns a post with per-type reaction counts for viewer and
+// its attachments.
+func (s *Server) loadPost(ctx context.Context, postID, viewerID int64) (*models.Post, error) {
+	post, err := s.posts.GetWithReactions(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	one := []models.Post{*post}
+	if err := s.reactions.Attach(ctx, one, viewerID); err != nil {
+		return nil, err
+	}
+	if err := s.attachments.Attach(ctx, one); err != nil {
+		return nil, err
+	}
+	if err := s.tags.Attach(ctx, one); err != nil {
+		return nil, err
+	}
+
+	poll, err := s.polls.ForPost(ctx, postID, viewerID)
+	switch {
+	case err == nil:
+		one[0].Poll = poll
+	case !errors.Is(err, models.ErrPollNotFound):
+		return nil, err
+	}
+	return &one[0], nil
+}
+
+// handlePostReactions routes:
+//
+//	GET  /api/posts/{id}/reactions        -> counts per type + viewer's reactions
+//	POST /api/posts/{id}/reactions        -> toggle {"reaction": "love"}
+//	GET  /api/posts/{id}/reactions/users  -> who reacted (?reaction=&limit=&offset=)
 func (s *Server) handlePostReactions(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/posts/")
+	listUsers := strings.HasSuffix(path, "/reactions/users")
+	path = strings.TrimSuffix(path, "/users")
 	path = strings.TrimSuffix(path, "/reactions")
 
 	postID, err := strconv.ParseInt(path, 10, 64)
@@ -348,31 +693,48 @@ func (s *Server) handlePostReactions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reaction := "like"
-	if r.Method == http.MethodPost {
-		var req struct {
-			Reaction string `json:"reaction"`
-		}
-		_ = json.NewDecoder(r.Body).Decode(&req)
-		if strings.TrimSpace(req.Reaction) != "" {
-			reaction = strings.TrimSpace(req.Reaction)
-		}
+	if listUsers {
+		s.handlePostReactors(w, r, postID)
+		return
 	}
 
 	switch r.Method {
 	case http.MethodGet:
 		userID, _ := getUserIDFromContext(r)
-		count, iReacted, err := s.getReactionInfo(r.Context(), postID, userID, reaction)
+
+		// reaction, reactions_count and i_reacted predate per-type
+		// reactions and describe one type, "like" unless ?reaction= says
+		// otherwise; clients written since read reactions and my_reactions.
+		reaction := "like"
+		if v := r.URL.Query().Get("reaction"); v != "" {
+			if reaction, err = s.reactions.Normalize(v); err != nil {
+				http.Error(w, "unknown reaction", http.StatusBadRequest)
+				return
+			}
+		}
+
+		counts, err := s.reactions.Counts(r.Context(), postID)
 		if err != nil {
 			log.Println("[REACTIONS] Get error:", err)
 			http.Error(w, "cannot load reactions", http.StatusInternalServerError)
 			return
 		}
+		mine := []string{}
+		if userID > 0 {
+			if mine, err = s.reactions.ViewerReactions(r.Context(), postID, userID); err != nil {
+				log.Println("[REACTIONS] Get error:", err)
+				http.Error(w, "cannot load reactions", http.StatusInternalServerError)
+				return
+			}
+		}
 		writeJSON(w, http.StatusOK, map[string]any{
 			"post_id":         postID,
 			"reaction":        reaction,
-			"reactions_count": count,
-			"i_reacted":       iReacted,
+			"reactions_count": counts[reaction],
+			"i_reacted":       slices.Contains(mine, reaction),
+			"allowed":         s.reactions.AllowedReactions(),
+			"reactions":       counts,
+			"my_reactions":    mine,
 		})
 
 	case http.MethodPost:
@@ -381,17 +743,41 @@ func (s *Server) handlePostReactions(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "unauthorised", http.StatusUnauthorized)
 			return
 		}
-		reacted, count, err := s.toggleReaction(r.Context(), postID, userID, reaction)
+
+		reaction := "like"
+		var req struct {
+			Reaction string `json:"reaction"`
+		}
+		_ = decodeJSON(w, r, &req)
+		if strings.TrimSpace(req.Reaction) != "" {
+			reaction = req.Reaction
+		}
+
+		reacted, counts, err := s.reactions.Toggle(r.Context(), postID, userID, reaction)
 		if err != nil {
+			if errors.Is(err, models.ErrUnknownReaction) {
+				http.Error(w, "unknown reaction", http.StatusBadRequest)
+				return
+			}
 			log.Println("[REACTIONS] Toggle error:", err)
 			http.Error(w, "cannot react", http.StatusInternalServerError)
 			return
 		}
+		reaction, _ = s.reactions.Normalize(reaction)
+
+		s.publish(r.Context(), postID, ws.ReactionChangedEvent{
+			Type:      "reaction_changed",
+			PostID:    postID,
+			Reactions: counts,
+		})
+
 		writeJSON(w, http.StatusOK, map[string]any{
 			"post_id":         postID,
 			"reaction":        reaction,
 			"reacted":         reacted,
-			"reactions_count": count,
+			"reactions_count": counts[reaction],
+			"i_reacted":       reacted,
+			"reactions":       counts,
 		})
 
 	default:
@@ -399,67 +785,50 @@ func (s *Server) handlePostReactions(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) toggleReaction(ctx context.Context, postID, userID int64, reaction string) (bool, int64, error) {
-	delRes, err := s.db.ExecContext(ctx,
-		`DELETE FROM post_reactions WHERE post_id=? AND user_id=? AND reaction=?`,
-		postID, userID, reaction,
-	)
-	if err != nil {
-		return false, 0, err
+// handlePostReactors lists the users who reacted to a post.
+func (s *Server) handlePostReactors(w http.ResponseWriter, r *http.Request, postID int64) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	rows, _ := delRes.RowsAffected()
-	reactedNow := false
-
-	if rows == 0 {
-		_, err := s.db.ExecContext(ctx,
-			`INSERT INTO post_reactions(post_id, user_id, reaction) VALUES(?,?,?)`,
-			postID, userID, reaction,
-		)
-		if err != nil {
-			return false, 0, err
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
 		}
-		reactedNow = true
-	}
-
-	var count int64
-	if err := s.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM post_reactions WHERE post_id=? AND reaction=?`,
-		postID, reaction,
-	).Scan(&count); err != nil {
-		return reactedNow, 0, err
-	}
-
-	return reactedNow, count, nil
-}
-
-func (s *Server) getReactionInfo(ctx context.Context, postID, userID int64, reaction string) (count int64, iReacted bool, err error) {
-	if err := s.db.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM post_reactions WHERE post_id=? AND reaction=?`,
-		postID, reaction,
-	).Scan(&count); err != nil {
-		return 0, false, err
 	}
-
-	if userID <= 0 {
-		return count, false, nil
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
 	}
 
-	var exists int
-	if err := s.db.QueryRowContext(ctx,
-		`SELECT EXISTS(
-			SELECT 1 FROM post_reactions WHERE post_id=? AND user_id=? AND reaction=?
-		)`,
-		postID, userID, reaction,
-	).Scan(&exists); err != nil {
-		return count, false, err
+	reactors, hasMore, err := s.reactions.ListReactors(r.Context(), postID, r.URL.Query().Get("reaction"), limit, offset)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownReaction) {
+			http.Error(w, "unknown reaction", http.StatusBadRequest)
+			return
+		}
+		log.Println("[REACTIONS] List users error:", err)
+		http.Error(w, "cannot load reactions", http.StatusInternalServerError)
+		return
 	}
 
-	return count, exists == 1, nil
+	writeJSON(w, http.StatusOK, map[string]any{
+		"post_id":     postID,
+		"users":       reactors,
+		"has_more":    hasMore,
+		"next_offset": offset + len(reactors),
+	})
 }
 
 // ------------------------------------------------------------
 // COMMENTS on a post (list/create)
+//   GET  /api/posts/{id}/comments?after=&limit=&order=oldest|newest
+//   GET  /api/posts/{id}/comments?cursor=&limit=&order=top
+//   POST /api/posts/{id}/comments
 // ------------------------------------------------------------
 
 func (s *Server) handlePostComments(w http.ResponseWriter, r *http.Request) {
@@ -474,13 +843,57 @@ func (s *Server) handlePostComments(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		comments, err := s.comments.ListByPost(r.Context(), postID)
+		after, limit, ok := parsePage(w, r, 20, 100)
+		if !ok {
+			return
+		}
+		viewerID, _ := getUserIDFromContext(r)
+		q := models.CommentQuery{After: after, Limit: limit, Order: r.URL.Query().Get("order"), ViewerID: viewerID}
+		switch q.Order {
+		case "":
+			q.Order = models.CommentsOldest
+		case models.CommentsOldest, models.CommentsNewest:
+		case models.CommentsTop:
+			// Top pages resume from the rank in next_cursor, not an ID.
+			if after > 0 {
+				http.Error(w, "order=top pages with cursor, not after", http.StatusBadRequest)
+				return
+			}
+			if v := r.URL.Query().Get("cursor"); v != "" {
+				cursor, err := models.DecodeCommentCursor(v)
+				if err != nil {
+					http.Error(w, "invalid cursor", http.StatusBadRequest)
+					return
+				}
+				q.AfterTop = &cursor
+			}
+		default:
+			http.Error(w, "invalid order", http.StatusBadRequest)
+			return
+		}
+
+		comments, hasMore, err := s.comments.ListByPost(r.Context(), postID, q)
 		if err != nil {
 			log.Println("[COMMENTS] List error:", err)
 			http.Error(w, "cannot load comments", http.StatusInternalServerError)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"comments": comments})
+
+		// The page ends on its last root comment, not on a reply.
+		resp := map[string]any{"comments": comments, "has_more": hasMore}
+		if hasMore {
+			for i := len(comments) - 1; i >= 0; i-- {
+				if comments[i].ParentID == nil {
+					if q.Order == models.CommentsTop {
+						resp["next_cursor"] = comments[i].TopCursor().Encode()
+					} else {
+						resp["next_after"] = comments[i].ID
+					}
+					break
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
 
 	case http.MethodPost:
 		userID, ok := getUserIDFromContext(r)
@@ -490,9 +903,10 @@ func (s *Server) handlePostComments(w http.ResponseWriter, r *http.Request) {
 		}
 
 		var req struct {
-			Content string `json:"content"`
+			Content  string `json:"content"`
+			ParentID *int64 `json:"parent_id"` // set for replies
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSON(w, r, &req); err != nil {
 			http.Error(w, "bad request", http.StatusBadRequest)
 			return
 		}
@@ -503,17 +917,39 @@ func (s *Server) handlePostComments(w http.ResponseWriter, r *http.Request) {
 		}
 
 		comment := &models.Comment{
-			PostID:  postID,
-			UserID:  userID,
-			Content: req.Content,
+			PostID:   postID,
+			UserID:   userID,
+			ParentID: req.ParentID,
+			Content:  req.Content,
 		}
 
+		// Locked threads take no new comments (moderators excepted);
+		// Create checks the lock in the insert's transaction.
 		if err := s.comments.Create(r.Context(), comment); err != nil {
-			log.Println("[COMMENTS] Create error:", err)
-			http.Error(w, "cannot create comment", http.StatusInternalServerError)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				http.Error(w, "post not found", http.StatusNotFound)
+			case errors.Is(err, models.ErrThreadLocked):
+				http.Error(w, "thread is locked", http.StatusLocked)
+			case errors.Is(err, models.ErrInvalidParent):
+				http.Error(w, "invalid parent comment", http.StatusBadRequest)
+			case errors.Is(err, models.ErrReplyTooDeep):
+				http.Error(w, "replies are nested too deeply", http.StatusBadRequest)
+			case errors.Is(err, models.ErrContentTooLong):
+				http.Error(w, "content is too long", http.StatusBadRequest)
+			default:
+				log.Println("[COMMENTS] Create error:", err)
+				http.Error(w, "cannot create comment", http.StatusInternalServerError)
+			}
 			return
 		}
 
+		s.publish(r.Context(), postID, ws.CommentCreatedEvent{
+			Type:    "comment_created",
+			PostID:  postID,
+			Comment: comment,
+		})
+
 		writeJSON(w, http.StatusCreated, map[string]any{"comment": comment})
 
 	default:
@@ -549,6 +985,12 @@ func (s *Server) handlePostViews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.publish(r.Context(), postID, ws.PostUpdatedEvent{
+		Type:       "post_updated",
+		PostID:     postID,
+		ViewsCount: count,
+	})
+
 	writeJSON(w, http.StatusOK, map[string]any{
 		"post_id":     postID,
 		"views_count": count,
@@ -557,10 +999,29 @@ func (s *Server) handlePostViews(w http.ResponseWriter, r *http.Request) {
 
 // ------------------------------------------------------------
 // COMMENT BY ID (edit comment) -> PATCH /api/comments/{id}
+//                 (delete)       -> DELETE /api/comments/{id}
+//                 (history)      -> GET /api/comments/{id}/revisions
 // ------------------------------------------------------------
 
 func (s *Server) handleCommentByID(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPatch {
+	if strings.HasSuffix(r.URL.Path, "/replies") {
+		s.handleCommentReplies(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/reactions") {
+		s.handleCommentReactions(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/revisions") {
+		s.handleCommentRevisions(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/vote") {
+		s.handleCommentVote(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPatch && r.Method != http.MethodDelete {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -586,20 +1047,49 @@ func (s *Server) handleCommentByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.Method == http.MethodDelete {
+		s.handleCommentDelete(w, r, commentID, userID)
+		return
+	}
+
 	var req struct {
 		Content string `json:"content"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := decodeJSON(w, r, &req); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
+	// Authors edit within the edit window; moderators edit any time.
 	updated, err := s.comments.UpdateByOwner(r.Context(), commentID, userID, req.Content)
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, models.ErrEditWindowClosed) {
+		isMod, modErr := s.users.IsModerator(r.Context(), userID)
+		if modErr != nil {
+			log.Println("[COMMENT] role error:", modErr)
+			http.Error(w, "cannot update comment", http.StatusInternalServerError)
+			return
+		}
+		if isMod {
+			updated, err = s.comments.UpdateAsModerator(r.Context(), commentID, userID, req.Content)
+			if errors.Is(err, sql.ErrNoRows) {
+				http.Error(w, "comment not found", http.StatusNotFound)
+				return
+			}
+		}
+	}
 	if err != nil {
+		if errors.Is(err, models.ErrEditWindowClosed) {
+			http.Error(w, "edit window closed", http.StatusForbidden)
+			return
+		}
 		if errors.Is(err, sql.ErrNoRows) {
 			http.Error(w, "forbidden", http.StatusForbidden)
 			return
 		}
+		if errors.Is(err, models.ErrContentTooLong) {
+			http.Error(w, "content is too long", http.StatusBadRequest)
+			return
+		}
 		log.Println("[COMMENT] update error:", err)
 		http.Error(w, "cannot update comment", http.StatusInternalServerError)
 		return
@@ -661,14 +1151,24 @@ func (s *Server) Router() http.Handler {
 	mux.HandleFunc("/api/login", s.handleLogin)
 	mux.HandleFunc("/api/logout", s.handleLogout)
 	mux.HandleFunc("/api/me", s.handleCurrentUser)
+	mux.HandleFunc("/api/me/bookmarks", s.handleMyBookmarks)
+	mux.HandleFunc("/api/me/follows", s.handleMyFollows)
 
 	mux.HandleFunc("/api/posts", s.handlePosts)
 	mux.HandleFunc("/api/posts/", s.handlePostDetail)
+	mux.HandleFunc("/api/feed/following", s.handlePosts)
+	mux.HandleFunc("/api/categories/", s.handleCategoryFollow)
 	mux.HandleFunc("/api/comments/", s.handleCommentByID)
+	mux.HandleFunc("/api/search", s.handleSearch)
+	mux.HandleFunc("/api/attachments/", s.handleAttachment)
+	mux.HandleFunc("/api/drafts", s.handleDrafts)
+	mux.HandleFunc("/api/drafts/", s.handleDraftByID)
+	mux.HandleFunc("/api/tags", s.handleTags)
 
 	mux.HandleFunc("/ws/chat", s.handleChatWS)
 	mux.HandleFunc("/api/messages/", s.handleMessages)
 	mux.HandleFunc("/api/users", s.handleUsers)
+	mux.HandleFunc("/api/users/", s.handleUserFollow)
 
 	handler := s.withSessionMiddleware(mux)
 	return loggingMiddleware(handler)
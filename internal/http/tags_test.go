@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"real-time-forum/internal/models"
+)
+
+func TestPatchPostTags(t *testing.T) {
+	server := newTestServer(t)
+	ctx := context.Background()
+
+	owner, ownerCookie := newTestSession(t, server, "owner")
+	_, otherCookie := newTestSession(t, server, "other")
+
+	post := &models.Post{UserID: owner.ID, Title: "Tagged", Content: "body", Category: "General"}
+	if err := server.posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/posts/" + strconv.FormatInt(post.ID, 10)
+
+	if rec := doJSON(t, server, http.MethodPatch, base, `{"tags":["ok","not ok?"]}`, ownerCookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid tag: got %d", rec.Code)
+	}
+	if rec := doJSON(t, server, http.MethodPatch, base, `{"tags":["go"]}`, otherCookie); rec.Code != http.StatusForbidden {
+		t.Fatalf("non-owner: got %d", rec.Code)
+	}
+
+	rec := doJSON(t, server, http.MethodPatch, base, `{"tags":["#Go","Web Dev","go"]}`, ownerCookie)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch tags: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Post.Tags) != 2 || body.Post.Tags[0] != "go" || body.Post.Tags[1] != "web-dev" {
+		t.Fatalf("tags = %v", body.Post.Tags)
+	}
+
+	// Autocomplete matches the normalized prefix.
+	req := httptest.NewRequest(http.MethodGet, "/api/tags?q=%23WEB", nil)
+	rec = httptest.NewRecorder()
+	server.handleTags(rec, req)
+	var tags struct {
+		Tags []models.TagCount `json:"tags"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags.Tags) != 1 || tags.Tags[0].Name != "web-dev" || tags.Tags[0].Count != 1 {
+		t.Fatalf("suggest = %+v", tags.Tags)
+	}
+}
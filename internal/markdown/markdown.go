@@ -0,0 +1,308 @@
+// internal/markdown/markdown.go
+
+// Package markdown renders the small Markdown subset allowed in posts and
+// comments to safe HTML.
+//
+// Supported: paragraphs (single newlines become <br>), *em* / _em_,
+// **strong**, `code`, fenced ``` code blocks, [links](https://...),
+// "-"/"*" and "1." lists, and "> " quotes.
+//
+// Sanitization is by construction: every piece of user text is HTML-escaped,
+// and the renderer only ever emits the tags in AllowedTags. Link targets are
+// limited to http, https, mailto and site-relative paths.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// AllowedTags lists every element Render can produce.
+var AllowedTags = []string{"p", "br", "em", "strong", "code", "pre", "a", "ul", "ol", "li", "blockquote"}
+
+// maxQuoteDepth bounds nested "> > >" quotes (and the recursion behind them).
+const maxQuoteDepth = 4
+
+var (
+	orderedItem   = regexp.MustCompile(`^\d{1,9}[.)]\s+`)
+	unorderedItem = regexp.MustCompile(`^[-*+]\s+`)
+	fenceLang     = regexp.MustCompile(`^[A-Za-z0-9_+-]{1,32}$`)
+)
+
+// Render converts Markdown source to sanitized HTML.
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	var sb strings.Builder
+	renderBlocks(&sb, strings.Split(src, "\n"), 0)
+	return sb.String()
+}
+
+func renderBlocks(sb *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			i++
+			start := i
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				i++
+			}
+			code := strings.Join(lines[start:i], "\n")
+			if i < len(lines) {
+				i++ // closing fence
+			}
+
+			sb.WriteString("<pre><code")
+			if fenceLang.MatchString(lang) {
+				sb.WriteString(` class="language-` + strings.ToLower(lang) + `"`)
+			}
+			sb.WriteString(">")
+			sb.WriteString(html.EscapeString(code))
+			sb.WriteString("</code></pre>")
+
+		case strings.HasPrefix(trimmed, ">") && depth < maxQuoteDepth:
+			var inner []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				l := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				inner = append(inner, strings.TrimPrefix(l, " "))
+				i++
+			}
+			sb.WriteString("<blockquote>")
+			renderBlocks(sb, inner, depth+1)
+			sb.WriteString("</blockquote>")
+
+		case unorderedItem.MatchString(trimmed), orderedItem.MatchString(trimmed):
+			marker := unorderedItem
+			tag := "ul"
+			if !unorderedItem.MatchString(trimmed) {
+				marker = orderedItem
+				tag = "ol"
+			}
+
+			sb.WriteString("<" + tag + ">")
+			for i < len(lines) {
+				t := strings.TrimSpace(lines[i])
+				if !marker.MatchString(t) {
+					break
+				}
+				item := []string{marker.ReplaceAllString(t, "")}
+				i++
+				// Indented lines continue the current item.
+				for i < len(lines) && strings.TrimSpace(lines[i]) != "" &&
+					strings.HasPrefix(lines[i], "  ") && !marker.MatchString(strings.TrimSpace(lines[i])) {
+					item = append(item, strings.TrimSpace(lines[i]))
+					i++
+				}
+				sb.WriteString("<li>")
+				sb.WriteString(renderInline(strings.Join(item, "\n"), true))
+				sb.WriteString("</li>")
+			}
+			sb.WriteString("</" + tag + ">")
+
+		default:
+			var para []string
+			for i < len(lines) && isParagraphLine(lines[i], depth) {
+				para = append(para, strings.TrimSpace(lines[i]))
+				i++
+			}
+			sb.WriteString("<p>")
+			sb.WriteString(renderInline(strings.Join(para, "\n"), true))
+			sb.WriteString("</p>")
+		}
+	}
+}
+
+// isParagraphLine reports whether line continues a paragraph rather than
+// starting a different block.
+func isParagraphLine(line string, depth int) bool {
+	t := strings.TrimSpace(line)
+	switch {
+	case t == "":
+		return false
+	case strings.HasPrefix(t, "```"):
+		return false
+	case strings.HasPrefix(t, ">") && depth < maxQuoteDepth:
+		return false
+	case unorderedItem.MatchString(t), orderedItem.MatchString(t):
+		return false
+	}
+	return true
+}
+
+// renderInline escapes s and applies code spans, links and emphasis.
+// Newlines become <br>. allowLinks is false inside link text.
+//
+// It runs in linear time: a closer search that fails is remembered, so a
+// run of unmatched delimiters is scanned once, not once per delimiter.
+func renderInline(s string, allowLinks bool) string {
+	var sb strings.Builder
+
+	// noStrong: no "**" after the current position. noEm[d]: no closer for
+	// delimiter d ('*' or '_') before that index.
+	noStrong := false
+	noEm := map[byte]int{}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()>#+-.!", s[i+1]) >= 0:
+			sb.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '\n':
+			sb.WriteString("<br>")
+			i++
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				sb.WriteString("<code>")
+				sb.WriteString(html.EscapeString(s[i+1 : i+1+end]))
+				sb.WriteString("</code>")
+				i += end + 2
+				continue
+			}
+
+		case c == '[' && allowLinks:
+			if text, href, n, ok := parseLink(s[i:]); ok {
+				if safe, ok := safeURL(href); ok {
+					sb.WriteString(`<a href="` + html.EscapeString(safe) + `" rel="nofollow noopener noreferrer">`)
+					sb.WriteString(renderInline(text, false))
+					sb.WriteString("</a>")
+				} else {
+					sb.WriteString(renderInline(text, false))
+				}
+				i += n
+				continue
+			}
+
+		case c == '*' && strings.HasPrefix(s[i:], "**"):
+			if noStrong {
+				break
+			}
+			end := strings.Index(s[i+2:], "**")
+			if end < 0 {
+				noStrong = true
+			}
+			if end > 0 {
+				sb.WriteString("<strong>")
+				sb.WriteString(renderInline(s[i+2:i+2+end], allowLinks))
+				sb.WriteString("</strong>")
+				i += end + 4
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if !canOpenEmphasis(s, i) || i < noEm[c] {
+				break
+			}
+			end, stop := emphasisEnd(s, i)
+			if end < 0 {
+				noEm[c] = stop
+				break
+			}
+			sb.WriteString("<em>")
+			sb.WriteString(renderInline(s[i+1:end], allowLinks))
+			sb.WriteString("</em>")
+			i = end + 1
+			continue
+		}
+
+		sb.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+
+	return sb.String()
+}
+
+// canOpenEmphasis reports whether the delimiter at s[open] may start
+// emphasis. Underscores must sit on word boundaries so snake_case is left
+// alone.
+func canOpenEmphasis(s string, open int) bool {
+	d := s[open]
+	if open+1 >= len(s) || s[open+1] == ' ' || s[open+1] == d {
+		return false
+	}
+	return d != '_' || open == 0 || !isWordByte(s[open-1])
+}
+
+// emphasisEnd returns the index of the delimiter closing the one at s[open],
+// or -1 and the index where the search stopped. Whether s[j] closes does
+// not depend on open, so no opener before stop can find a closer either.
+func emphasisEnd(s string, open int) (end, stop int) {
+	d := s[open]
+	for j := open + 1; j < len(s); j++ {
+		if s[j] == '\n' {
+			return -1, j
+		}
+		if s[j] != d || s[j-1] == ' ' {
+			continue
+		}
+		if d == '_' && j+1 < len(s) && isWordByte(s[j+1]) {
+			continue
+		}
+		return j, j
+	}
+	return -1, len(s)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// parseLink matches "[text](href)" at the start of s and returns the number
+// of bytes consumed. Neither part may contain '[' or a newline, so a scan
+// never reaches past the next link's start.
+func parseLink(s string) (text, href string, n int, ok bool) {
+	closeText := -1
+	for j := 1; j+1 < len(s) && s[j] != '[' && s[j] != '\n'; j++ {
+		if s[j] == ']' && s[j+1] == '(' {
+			closeText = j
+			break
+		}
+	}
+	if closeText < 1 {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexAny(s[closeText+2:], ")[\n")
+	if closeHref < 1 || s[closeText+2+closeHref] != ')' {
+		return "", "", 0, false
+	}
+	href = strings.TrimSpace(s[closeText+2 : closeText+2+closeHref])
+	if strings.ContainsAny(href, " \n") {
+		return "", "", 0, false
+	}
+	return s[1:closeText], href, closeText + 3 + closeHref, true
+}
+
+// safeURL returns href when it is an http(s)/mailto URL or a site-relative path.
+func safeURL(href string) (string, bool) {
+	if strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//") {
+		return href, true
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+		return u.String(), true
+	case "mailto":
+		return u.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "hello", want: "<p>hello</p>"},
+		{name: "line breaks", in: "a\nb\n\nc", want: "<p>a<br>b</p><p>c</p>"},
+		{name: "emphasis", in: "*a* **b** _c_", want: "<p><em>a</em> <strong>b</strong> <em>c</em></p>"},
+		{name: "snake_case untouched", in: "use my_var_name", want: "<p>use my_var_name</p>"},
+		{name: "inline code escapes", in: "`<b>*x*</b>`", want: "<p><code>&lt;b&gt;*x*&lt;/b&gt;</code></p>"},
+		{name: "code block", in: "```go\nif a < b {}\n```", want: `<pre><code class="language-go">if a &lt; b {}</code></pre>`},
+		{name: "link", in: "[docs](https://go.dev/doc)", want: `<p><a href="https://go.dev/doc" rel="nofollow noopener noreferrer">docs</a></p>`},
+		{name: "javascript link dropped", in: "[x](javascript:alert)", want: "<p>x</p>"},
+		{name: "lists", in: "- a\n- b\n\n1. c", want: "<ul><li>a</li><li>b</li></ul><ol><li>c</li></ol>"},
+		{name: "quote", in: "> hi\n> *there*", want: "<blockquote><p>hi<br><em>there</em></p></blockquote>"},
+		{name: "raw html escaped", in: `<script>alert("x")</script>`, want: "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>"},
+		{name: "unmatched delimiters", in: "a* _b ** [c](d", want: "<p>a* _b ** [c](d</p>"},
+		{name: "emphasis after unmatched", in: "_a *b* c", want: "<p>_a <em>b</em> c</p>"},
+		{name: "no newline in href", in: "[a](\n)", want: "<p>[a](<br>)</p>"},
+		{name: "attribute injection", in: `[a](https://x.test/"onmouseover="1)`, want: `<p><a href="https://x.test/%22onmouseover=%221" rel="nofollow noopener noreferrer">a</a></p>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.in); got != tt.want {
+				t.Fatalf("Render(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderUnmatchedDelimitersIsLinear(t *testing.T) {
+	// Each of these used to rescan the rest of the line per delimiter.
+	for _, unit := range []string{"*a ", "_a ", "** ", "[a ", "[a](x y "} {
+		src := strings.Repeat(unit, 200_000/len(unit))
+		start := time.Now()
+		Render(src)
+		if took := time.Since(start); took > time.Second {
+			t.Errorf("Render(%q x %d) took %v", unit, 200_000/len(unit), took)
+		}
+	}
+}
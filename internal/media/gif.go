@@ -0,0 +1,86 @@
+// internal/media/gif.go
+package media
+
+// Limits on animated GIFs, checked before gif.DecodeAll. The logical screen
+// size only bounds each frame, and a small file can hold thousands of them.
+const (
+	MaxGIFFrames = 200
+	MaxGIFPixels = MaxPixels // summed over all frames
+)
+
+// gifFrames walks the GIF block structure without decoding any pixels and
+// returns the number of frames and their total pixel count. It stops early,
+// with ErrTooManyFrames or ErrTooLarge, once either limit is passed.
+func gifFrames(data []byte) (frames, pixels int, err error) {
+	// Header (6) and logical screen descriptor (7), then the optional
+	// global color table.
+	if len(data) < 13 {
+		return 0, 0, ErrCorrupt
+	}
+	pos := 13 + colorTableSize(data[10])
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: label, then data sub-blocks
+			if pos+2 > len(data) {
+				return 0, 0, ErrCorrupt
+			}
+			if pos, err = skipSubBlocks(data, pos+2); err != nil {
+				return 0, 0, err
+			}
+
+		case 0x2C: // image descriptor (10), local color table, LZW code size, data
+			if pos+10 > len(data) {
+				return 0, 0, ErrCorrupt
+			}
+			w := int(data[pos+5]) | int(data[pos+6])<<8
+			h := int(data[pos+7]) | int(data[pos+8])<<8
+
+			frames++
+			pixels += w * h
+			if frames > MaxGIFFrames {
+				return 0, 0, ErrTooManyFrames
+			}
+			if pixels > MaxGIFPixels {
+				return 0, 0, ErrTooLarge
+			}
+
+			pos += 10 + colorTableSize(data[pos+9]) + 1
+			if pos, err = skipSubBlocks(data, pos); err != nil {
+				return 0, 0, err
+			}
+
+		case 0x3B: // trailer
+			return frames, pixels, nil
+
+		default:
+			return 0, 0, ErrCorrupt
+		}
+	}
+	return 0, 0, ErrCorrupt
+}
+
+// colorTableSize returns the size in bytes of the color table announced by
+// a descriptor's packed flags byte.
+func colorTableSize(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << (flags&0x07 + 1)
+}
+
+// skipSubBlocks returns the position just after the sub-block chain that
+// starts at pos.
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, ErrCorrupt
+		}
+		n := int(data[pos])
+		pos++
+		if n == 0 {
+			return pos, nil
+		}
+		pos += n
+	}
+}
@@ -0,0 +1,136 @@
+// internal/media/media.go
+//
+// Package media validates uploaded images and re-encodes them. Re-encoding
+// from decoded pixels is how metadata is stripped: EXIF (including GPS
+// position), XMP, ICC comments and PNG text chunks never survive it, and
+// neither does anything appended after the image data.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+// Limits applied before a full decode, so a tiny file that claims huge
+// dimensions cannot make us allocate gigabytes.
+const (
+	MaxDimension = 8192
+	MaxPixels    = 40_000_000
+)
+
+// jpegQuality is used when re-encoding JPEG uploads.
+const jpegQuality = 88
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+	ErrTooManyFrames   = errors.New("animation has too many frames")
+	ErrCorrupt         = errors.New("image cannot be decoded")
+)
+
+// AllowedTypes are the content types accepted by Sanitize.
+var AllowedTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// Image is a sanitized image ready to be stored.
+type Image struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Sniff returns the content type detected from the first bytes of data,
+// ignoring whatever the client claimed.
+func Sniff(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Sanitize checks that data is a JPEG, PNG or GIF within the size limits
+// and returns it re-encoded in the same format with all metadata removed.
+func Sanitize(data []byte) (*Image, error) {
+	ct := Sniff(data)
+	if !allowed(ct) {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrCorrupt
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	var out bytes.Buffer
+	switch ct {
+	case "image/gif":
+		// DecodeAll keeps every frame so animations survive; comment and
+		// application extensions are dropped. It allocates every frame, so
+		// the frames are counted first (see gif.go).
+		if _, _, err := gifFrames(data); err != nil {
+			return nil, err
+		}
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		if err := gif.EncodeAll(&out, g); err != nil {
+			return nil, err
+		}
+
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		if err := png.Encode(&out, img); err != nil {
+			return nil, err
+		}
+
+	case "image/jpeg":
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Image{
+		Data:        out.Bytes(),
+		ContentType: ct,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+	}, nil
+}
+
+// Extension returns the file extension used when storing contentType.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
+
+func allowed(ct string) bool {
+	for _, a := range AllowedTypes {
+		if ct == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+	return img
+}
+
+// withEXIF inserts an APP1 "Exif" segment right after the JPEG SOI marker.
+func withEXIF(jpg []byte, payload string) []byte {
+	seg := append([]byte("Exif\x00\x00"), payload...)
+	n := len(seg) + 2
+	app1 := append([]byte{0xFF, 0xE1, byte(n >> 8), byte(n)}, seg...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, app1...)
+	return append(out, jpg[2:]...)
+}
+
+func TestSanitizeStripsEXIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(16, 8), nil); err != nil {
+		t.Fatal(err)
+	}
+	in := withEXIF(buf.Bytes(), "GPSLatitude=48.8584")
+
+	img, err := Sanitize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ContentType != "image/jpeg" || img.Width != 16 || img.Height != 8 {
+		t.Fatalf("got %s %dx%d", img.ContentType, img.Width, img.Height)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) || bytes.Contains(img.Data, []byte("GPSLatitude")) {
+		t.Fatal("EXIF segment survived re-encoding")
+	}
+}
+
+func TestSanitizeDropsTrailingData(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	in := append(buf.Bytes(), []byte("<script>alert(1)</script>")...)
+
+	img, err := Sanitize(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ContentType != "image/png" {
+		t.Fatalf("content type = %s", img.ContentType)
+	}
+	if bytes.Contains(img.Data, []byte("<script>")) {
+		t.Fatal("trailing payload survived re-encoding")
+	}
+}
+
+func TestSanitizeRejects(t *testing.T) {
+	// A PNG header claiming 100000x100000 pixels.
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	huge := append([]byte{}, buf.Bytes()...)
+	copy(huge[16:24], []byte{0, 1, 0x86, 0xA0, 0, 1, 0x86, 0xA0})
+	binary.BigEndian.PutUint32(huge[29:33], crc32.ChecksumIEEE(huge[12:29]))
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"html", []byte("<html><body>hi</body></html>"), ErrUnsupportedType},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ErrUnsupportedType},
+		{"truncated png", buf.Bytes()[:20], ErrCorrupt},
+		{"huge", huge, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := Sanitize(tt.data); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func testGIF(t *testing.T, frames, w, h int) []byte {
+	t.Helper()
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, w, h), color.Palette{color.Black, color.White}))
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSanitizeLimitsGIFFrames(t *testing.T) {
+	img, err := Sanitize(testGIF(t, 3, 4, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ContentType != "image/gif" {
+		t.Fatalf("content type = %s", img.ContentType)
+	}
+
+	if _, err := Sanitize(testGIF(t, MaxGIFFrames+1, 1, 1)); !errors.Is(err, ErrTooManyFrames) {
+		t.Fatalf("too many frames: err = %v", err)
+	}
+	// Each frame fits the screen, but together they are too many pixels.
+	if _, err := Sanitize(testGIF(t, MaxGIFPixels/(4000*4000)+1, 4000, 4000)); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("too many pixels: err = %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+// internal/models/attachments.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when no attachment has the given id.
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrAttachmentLimit is returned when a post already has the most
+	// attachments allowed.
+	ErrAttachmentLimit = errors.New("attachment limit reached")
+)
+
+// Attachment is an image uploaded to a post. The bytes live in storage
+// under StorageKey; only metadata is kept in the database.
+type Attachment struct {
+	ID          int64     `json:"id"`
+	PostID      int64     `json:"post_id"`
+	UserID      int64     `json:"user_id"`
+	StorageKey  string    `json:"-"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	SHA256      string    `json:"-"` // served as the ETag
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentModel struct {
+	DB *sql.DB
+}
+
+const attachmentSelect = `
+		SELECT id, post_id, user_id, storage_key, filename, content_type,
+		       size, width, height, sha256, created_at
+		FROM attachments`
+
+func scanAttachment(sc rowScanner) (Attachment, error) {
+	var a Attachment
+	err := sc.Scan(
+		&a.ID, &a.PostID, &a.UserID, &a.StorageKey, &a.Filename, &a.ContentType,
+		&a.Size, &a.Width, &a.Height, &a.SHA256, &a.CreatedAt,
+	)
+	a.URL = attachmentURL(a.ID)
+	return a, err
+}
+
+func attachmentURL(id int64) string {
+	return "/api/attachments/" + strconv.FormatInt(id, 10)
+}
+
+// Create inserts the metadata row and fills in ID, URL and CreatedAt.
+// The post may hold at most limit attachments (0 means no limit); the
+// count and the insert share a transaction, so concurrent uploads cannot
+// both take the last slot. Returns ErrAttachmentLimit when it is full.
+func (m *AttachmentModel) Create(ctx context.Context, a *Attachment, limit int) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	if limit > 0 {
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM attachments WHERE post_id = ?`, a.PostID).Scan(&n); err != nil {
+			return err
+		}
+		if n >= limit {
+			return ErrAttachmentLimit
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO attachments (post_id, user_id, storage_key, filename, content_type, size, width, height, sha256)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
+	`, a.PostID, a.UserID, a.StorageKey, a.Filename, a.ContentType, a.Size, a.Width, a.Height, a.SHA256)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT created_at FROM attachments WHERE id = ?`, id).Scan(&a.CreatedAt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	a.ID = id
+	a.URL = attachmentURL(id)
+	return nil
+}
+
+// Get returns one attachment or ErrAttachmentNotFound.
+func (m *AttachmentModel) Get(ctx context.Context, id int64) (*Attachment, error) {
+	a, err := scanAttachment(m.DB.QueryRowContext(ctx, attachmentSelect+` WHERE id = ?;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CountByPost returns how many attachments a post already has.
+func (m *AttachmentModel) CountByPost(ctx context.Context, postID int64) (int, error) {
+	var n int
+	err := m.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM attachments WHERE post_id = ?`, postID).Scan(&n)
+	return n, err
+}
+
+// ListByPost returns a post's attachments in upload order.
+func (m *AttachmentModel) ListByPost(ctx context.Context, postID int64) ([]Attachment, error) {
+	byPost, err := m.listFor(ctx, []int64{postID})
+	if err != nil {
+		return nil, err
+	}
+	if byPost[postID] == nil {
+		return []Attachment{}, nil
+	}
+	return byPost[postID], nil
+}
+
+// Attach fills Attachments on each post with one batched query.
+func (m *AttachmentModel) Attach(ctx context.Context, posts []Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	byPost, err := m.listFor(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Attachments = byPost[posts[i].ID]
+	}
+	return nil
+}
+
+func (m *AttachmentModel) listFor(ctx context.Context, postIDs []int64) (map[int64][]Attachment, error) {
+	in, args := inClause(postIDs)
+
+	rows, err := m.DB.QueryContext(ctx, attachmentSelect+`
+		WHERE post_id IN (`+in+`)
+		ORDER BY post_id, id;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byPost := make(map[int64][]Attachment, len(postIDs))
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		byPost[a.PostID] = append(byPost[a.PostID], a)
+	}
+	return byPost, rows.Err()
+}
@@ -0,0 +1,114 @@
+// internal/models/bookmarks.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type BookmarkModel struct {
+	DB *sql.DB
+}
+
+// BookmarkCursor is the position of the last post of a bookmarks page.
+type BookmarkCursor struct {
+	SavedAt time.Time
+	PostID  int64
+}
+
+// Encode returns the opaque string form handed to clients as next_cursor.
+func (c BookmarkCursor) Encode() string {
+	raw := c.SavedAt.UTC().Format(sqliteTimeLayout) + "|" + strconv.FormatInt(c.PostID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeBookmarkCursor parses a value produced by BookmarkCursor.Encode.
+func DecodeBookmarkCursor(s string) (BookmarkCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return BookmarkCursor{}, ErrInvalidCursor
+	}
+
+	savedAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return BookmarkCursor{}, ErrInvalidCursor
+	}
+
+	var c BookmarkCursor
+	if c.SavedAt, err = time.Parse(sqliteTimeLayout, savedAt); err != nil {
+		return BookmarkCursor{}, ErrInvalidCursor
+	}
+	if c.PostID, err = strconv.ParseInt(id, 10, 64); err != nil || c.PostID <= 0 {
+		return BookmarkCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Add bookmarks a post for userID. Bookmarking twice is a no-op.
+func (m *BookmarkModel) Add(ctx context.Context, userID, postID int64) error {
+	_, err := m.DB.ExecContext(ctx,
+		`INSERT OR IGNORE INTO bookmarks (user_id, post_id) VALUES (?, ?)`, userID, postID)
+	return err
+}
+
+// Remove drops a bookmark; removing a missing one is a no-op.
+func (m *BookmarkModel) Remove(ctx context.Context, userID, postID int64) error {
+	_, err := m.DB.ExecContext(ctx,
+		`DELETE FROM bookmarks WHERE user_id = ? AND post_id = ?`, userID, postID)
+	return err
+}
+
+// List returns userID's bookmarked posts, most recently saved first.
+// next is the cursor for the following page, or nil on the last one.
+func (m *BookmarkModel) List(ctx context.Context, userID int64, limit int64, after *BookmarkCursor) (posts []Post, next *BookmarkCursor, err error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	args := append(viewerArgs(userID), userID)
+	conds := []string{publishedOnly}
+	if after != nil {
+		conds = append(conds, "(bm.created_at, bm.post_id) < (?, ?)")
+		args = append(args, after.SavedAt.UTC().Format(sqliteTimeLayout), after.PostID)
+	}
+
+	// Fetch one extra row to know if there is more.
+	query := postWithReactionsColumns + `,
+      bm.created_at` + postWithReactionsFrom + `
+    JOIN bookmarks bm ON bm.post_id = p.id AND bm.user_id = ?
+    WHERE ` + strings.Join(conds, " AND ") + `
+    ORDER BY bm.created_at DESC, bm.post_id DESC
+    LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	posts = []Post{}
+	saved := []time.Time{}
+	for rows.Next() {
+		var savedAt time.Time
+		p, err := scanPostWithReactions(rows, &savedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		posts = append(posts, p)
+		saved = append(saved, savedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if int64(len(posts)) > limit {
+		posts = posts[:limit]
+		next = &BookmarkCursor{SavedAt: saved[limit-1], PostID: posts[limit-1].ID}
+	}
+	return posts, next, nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestBookmarksListAndFlag(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "author")
+	reader := newTestUser(t, db, "reader")
+	posts := &PostModel{DB: db}
+	bookmarks := &BookmarkModel{DB: db}
+
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		p := &Post{UserID: author.ID, Title: fmt.Sprintf("post %d", i), Content: "body", Category: "General"}
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, p.ID)
+		if err := bookmarks.Add(ctx, reader.ID, p.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bookmarks.Add(ctx, reader.ID, ids[0]); err != nil {
+		t.Fatalf("second add: %v", err)
+	}
+	// Saved one minute apart: post 1, post 0, post 2 (newest).
+	for i, id := range []int64{ids[1], ids[0], ids[2]} {
+		if _, err := db.Exec(`UPDATE bookmarks SET created_at = datetime('2024-01-01 10:00:00', ?) WHERE post_id = ?`,
+			fmt.Sprintf("+%d minutes", i), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var seen []int64
+	var after *BookmarkCursor
+	for {
+		page, next, err := bookmarks.List(ctx, reader.ID, 2, after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range page {
+			if !p.IBookmarked {
+				t.Fatalf("post %d listed without i_bookmarked", p.ID)
+			}
+			seen = append(seen, p.ID)
+		}
+		if next == nil {
+			break
+		}
+		c, err := DecodeBookmarkCursor(next.Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		after = &c
+	}
+	if want := []int64{ids[2], ids[0], ids[1]}; fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("bookmarks = %v, want %v", seen, want)
+	}
+
+	if err := bookmarks.Remove(ctx, reader.ID, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	got, err := posts.GetWithReactions(ctx, ids[0], reader.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IBookmarked {
+		t.Fatal("removed bookmark still flagged")
+	}
+	feed, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10}, reader.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := map[int64]bool{}
+	for _, p := range feed {
+		flags[p.ID] = p.IBookmarked
+	}
+	if flags[ids[0]] || !flags[ids[1]] || !flags[ids[2]] {
+		t.Fatalf("feed flags = %v", flags)
+	}
+
+	// Other viewers never see someone else's bookmarks.
+	if got, err = posts.GetWithReactions(ctx, ids[1], author.ID); err != nil || got.IBookmarked {
+		t.Fatalf("author view: %+v, %v", got, err)
+	}
+}
@@ -16,6 +16,7 @@ var (
 type Category struct {
 	ID        int64     `json:"id"`
 	Name      string    `json:"name"`
+	QA        bool      `json:"qa"` // Q&A mode, see qa.go
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -40,12 +41,12 @@ func (m *CategoryModel) Ensure(ctx context.Context, rawName string, maxTotal int
 	var cat Category
 	err := m.DB.QueryRowContext(
 		ctx,
-		`SELECT id, name, created_at
+		`SELECT id, name, qa_mode, created_at
 		 FROM categories
 		 WHERE LOWER(name) = LOWER(?)
 		 LIMIT 1`,
 		name,
-	).Scan(&cat.ID, &cat.Name, &cat.CreatedAt)
+	).Scan(&cat.ID, &cat.Name, &cat.QA, &cat.CreatedAt)
 
 	if err == nil {
 		// Found existing category, simply return it.
@@ -88,10 +89,30 @@ func (m *CategoryModel) Ensure(ctx context.Context, rawName string, maxTotal int
 	return &cat, nil
 }
 
+// GetByName finds a category case-insensitively (ErrCategoryNotFound).
+func (m *CategoryModel) GetByName(ctx context.Context, rawName string) (*Category, error) {
+	var cat Category
+	err := m.DB.QueryRowContext(
+		ctx,
+		`SELECT id, name, qa_mode, created_at
+		 FROM categories
+		 WHERE LOWER(name) = LOWER(?)
+		 LIMIT 1`,
+		normaliseName(rawName),
+	).Scan(&cat.ID, &cat.Name, &cat.QA, &cat.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cat, nil
+}
+
 // List returns all categories ordered by name.
 func (m *CategoryModel) List(ctx context.Context) ([]Category, error) {
 	rows, err := m.DB.QueryContext(ctx,
-		`SELECT id, name, created_at
+		`SELECT id, name, qa_mode, created_at
 		 FROM categories
 		 ORDER BY name ASC`,
 	)
@@ -103,7 +124,7 @@ func (m *CategoryModel) List(ctx context.Context) ([]Category, error) {
 	var items []Category
 	for rows.Next() {
 		var c Category
-		if err := rows.Scan(&c.ID, &c.Name, &c.CreatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.QA, &c.CreatedAt); err != nil {
 			return nil, err
 		}
 		items = append(items, c)
@@ -0,0 +1,100 @@
+// internal/models/chat_events.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// ChatEvent is one entry in a user's chat event log. Seq counts up from 1
+// for each user; Payload is the event as JSON, without its seq.
+type ChatEvent struct {
+	Seq     int64
+	Type    string
+	Payload string
+}
+
+// ChatEventModel keeps each user's log of chat events (messages, delivered
+// and seen receipts) so a reconnecting tab can catch up on what it missed.
+type ChatEventModel struct {
+	DB *sql.DB
+}
+
+// Append adds an event to userID's log and returns its sequence number.
+func (m *ChatEventModel) Append(ctx context.Context, userID int64, typ, payload string) (int64, error) {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var seq int64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(seq), 0) + 1 FROM chat_events WHERE user_id = ?`, userID,
+	).Scan(&seq); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO chat_events (user_id, seq, type, payload) VALUES (?, ?, ?, ?)`,
+		userID, seq, typ, payload,
+	); err != nil {
+		return 0, err
+	}
+	return seq, tx.Commit()
+}
+
+// Since returns up to limit of userID's events after seq since, oldest
+// first, and the user's latest seq. complete is false when the log cannot
+// fill the gap: more than limit events were missed, some were pruned, or
+// since is ahead of the log.
+// A limit of 0 only looks up the latest seq.
+func (m *ChatEventModel) Since(ctx context.Context, userID, since int64, limit int) (events []ChatEvent, last int64, complete bool, err error) {
+	var first int64
+	if err := m.DB.QueryRowContext(ctx,
+		`SELECT COALESCE(MIN(seq), 0), COALESCE(MAX(seq), 0) FROM chat_events WHERE user_id = ?`, userID,
+	).Scan(&first, &last); err != nil {
+		return nil, 0, false, err
+	}
+	switch {
+	case limit <= 0 || since == last:
+		return nil, last, true, nil
+	case since > last || since < first-1 || last-since > int64(limit):
+		// since > last: the client saw a log that no longer exists.
+		return nil, last, false, nil
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT seq, type, payload
+		FROM chat_events
+		WHERE user_id = ? AND seq > ?
+		ORDER BY seq ASC;
+	`, userID, since)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ev ChatEvent
+		if err := rows.Scan(&ev.Seq, &ev.Type, &ev.Payload); err != nil {
+			return nil, 0, false, err
+		}
+		events = append(events, ev)
+	}
+	return events, last, true, rows.Err()
+}
+
+// Prune deletes events logged before cutoff. Each user's latest event is
+// kept so sequence numbers never start over.
+func (m *ChatEventModel) Prune(ctx context.Context, cutoff time.Time) error {
+	_, err := m.DB.ExecContext(ctx, `
+		DELETE FROM chat_events
+		WHERE created_at < ?
+		  AND seq < (SELECT MAX(seq) FROM chat_events e WHERE e.user_id = chat_events.user_id);
+	`, cutoff.UTC().Format(sqliteTimeLayout))
+	return err
+}
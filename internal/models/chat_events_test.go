@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChatEventLogSinceAndPrune(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	alice := newTestUser(t, db, "alice")
+	bob := newTestUser(t, db, "bob")
+	events := &ChatEventModel{DB: db}
+
+	appendEvent := func(userID int64, want int64) {
+		t.Helper()
+		seq, err := events.Append(ctx, userID, "message", `{"type":"message"}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seq != want {
+			t.Fatalf("seq = %d, want %d", seq, want)
+		}
+	}
+	appendEvent(alice.ID, 1)
+	appendEvent(alice.ID, 2)
+	appendEvent(bob.ID, 1) // numbered per user
+	appendEvent(alice.ID, 3)
+
+	since := func(from int64, limit int) ([]int64, int64, bool) {
+		t.Helper()
+		evs, last, complete, err := events.Since(ctx, alice.ID, from, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seqs := []int64{}
+		for _, ev := range evs {
+			seqs = append(seqs, ev.Seq)
+		}
+		return seqs, last, complete
+	}
+
+	if seqs, last, complete := since(1, 10); len(seqs) != 2 || seqs[0] != 2 || seqs[1] != 3 || last != 3 || !complete {
+		t.Fatalf("since 1 = %v, last %d, complete %v", seqs, last, complete)
+	}
+	if seqs, last, complete := since(-1, 0); len(seqs) != 0 || last != 3 || !complete {
+		t.Fatalf("latest only = %v, last %d, complete %v", seqs, last, complete)
+	}
+	if _, _, complete := since(0, 2); complete {
+		t.Fatal("a gap bigger than the limit was reported complete")
+	}
+	if _, _, complete := since(7, 10); complete {
+		t.Fatal("since ahead of the log was reported complete")
+	}
+
+	// Pruning keeps each user's latest event, so numbering carries on.
+	if err := events.Prune(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, complete := since(1, 10); complete {
+		t.Fatal("a gap over pruned events was reported complete")
+	}
+	if seqs, _, complete := since(2, 10); len(seqs) != 1 || seqs[0] != 3 || !complete {
+		t.Fatalf("since 2 after prune = %v, complete %v", seqs, complete)
+	}
+	appendEvent(alice.ID, 4)
+	appendEvent(bob.ID, 2)
+}
@@ -0,0 +1,72 @@
+// internal/models/comment_revisions.go
+package models
+
+import "context"
+
+// CommentRevision is a comment's text after one edit. Revision 1 is the
+// comment as originally posted; comments never edited have no revisions.
+type CommentRevision struct {
+	ID        int64  `json:"id"`
+	CommentID int64  `json:"comment_id"`
+	Revision  int64  `json:"revision"`
+	EditorID  int64  `json:"editor_id"`
+	Editor    string `json:"editor"` // resolved from joined users table
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// snapshotOriginalComment saves a comment's current text as revision 1,
+// unless it already has a history.
+func snapshotOriginalComment(ctx context.Context, db execer, commentID int64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO comment_revisions (comment_id, revision, editor_id, content, created_at)
+		SELECT c.id, 1, c.user_id, c.content, c.created_at
+		FROM comments c
+		WHERE c.id = ? AND c.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM comment_revisions r WHERE r.comment_id = c.id);
+	`, commentID)
+	return err
+}
+
+// snapshotCommentRevision records a comment's current text as its next
+// revision.
+func snapshotCommentRevision(ctx context.Context, db execer, commentID, editorID int64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO comment_revisions (comment_id, revision, editor_id, content)
+		SELECT
+			c.id,
+			COALESCE((SELECT MAX(revision) FROM comment_revisions WHERE comment_id = c.id), 0) + 1,
+			?,
+			c.content
+		FROM comments c
+		WHERE c.id = ?;
+	`, editorID, commentID)
+	return err
+}
+
+// ListRevisions returns every revision of a comment, oldest first.
+func (m *CommentModel) ListRevisions(ctx context.Context, commentID int64) ([]CommentRevision, error) {
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT r.id, r.comment_id, r.revision, r.editor_id, u.nickname, r.content, r.created_at
+		FROM comment_revisions r
+		JOIN users u ON u.id = r.editor_id
+		WHERE r.comment_id = ?
+		ORDER BY r.revision ASC;
+	`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revs := []CommentRevision{}
+	for rows.Next() {
+		var rev CommentRevision
+		if err := rows.Scan(
+			&rev.ID, &rev.CommentID, &rev.Revision, &rev.EditorID, &rev.Editor, &rev.Content, &rev.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revs = append(revs, rev)
+	}
+	return revs, rows.Err()
+}
@@ -4,76 +4,428 @@ package models
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"strconv"
 	"strings"
+	"time"
+
+	"real-time-forum/internal/markdown"
+)
+
+// MaxCommentDepth is how deep replies nest: root comments have depth 0 and a
+// comment at MaxCommentDepth takes no replies.
+const MaxCommentDepth = 3
+
+// RepliesPreview is how many replies ListByPost loads under each root
+// comment; the rest are paged with ListReplies.
+const RepliesPreview = 3
+
+var (
+	ErrInvalidParent = errors.New("parent comment not found on this post")
+	ErrReplyTooDeep  = errors.New("replies are nested too deeply")
+	ErrThreadLocked  = errors.New("thread is locked")
 )
 
+// DeletedCommentText replaces the content of a tombstone.
+const DeletedCommentText = "[deleted]"
+
 type Comment struct {
-	ID        int64  `json:"id"`
-	PostID    int64  `json:"post_id"`
-	UserID    int64  `json:"user_id"`
-	Author    string `json:"author"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_at"`
+	ID           int64  `json:"id"`
+	PostID       int64  `json:"post_id"`
+	UserID       int64  `json:"user_id"`
+	ParentID     *int64 `json:"parent_id"` // nil for root comments
+	RootID       *int64 `json:"root_id"`   // root of the thread; nil for root comments
+	Depth        int    `json:"depth"`
+	RepliesCount int64  `json:"replies_count"` // direct replies
+	MoreReplies  bool   `json:"more_replies,omitempty"`
+	Deleted      bool   `json:"deleted"` // tombstone: author and text are gone
+
+	// Q&A posts only (see qa.go): vote total, the viewer's vote (-1, 0, 1),
+	// and whether the post author accepted this comment as the answer.
+	Score    int64 `json:"score"`
+	MyVote   int   `json:"my_vote"`
+	Accepted bool  `json:"accepted"`
+
+	ReactionsCount int64            `json:"reactions_count"`
+	Reactions      map[string]int64 `json:"reactions,omitempty"`    // per type; see ReactionModel.AttachComments
+	MyReactions    []string         `json:"my_reactions,omitempty"` // viewer's own reactions
+	Author         string           `json:"author"`
+	Content        string           `json:"content"`      // Markdown source, editable
+	ContentHTML    string           `json:"content_html"` // rendered + sanitized, see package markdown
+	CreatedAt      string           `json:"created_at"`
+	EditedAt       *string          `json:"edited_at,omitempty"` // nil until edited; see ListRevisions
+
+	// rank is what CommentsTop sorts on, kept even for tombstones, which
+	// show no score (see TopCursor).
+	rank CommentCursor
 }
 
 type CommentModel struct {
-	DB *sql.DB
+	DB        *sql.DB
+	Reactions *ReactionModel // allowed reaction types; defaults when nil
+
+	// EditWindow is how long after posting the author may still edit a
+	// comment; zero means no limit. Moderators are not bound by it.
+	EditWindow time.Duration
 }
 
-// ListByPost returns all comments on a post (with the author's nickname).
-func (m *CommentModel) ListByPost(ctx context.Context, postID int64) ([]*Comment, error) {
-	const query = `
+func (m *CommentModel) reactions() *ReactionModel {
+	if m.Reactions == nil {
+		return &ReactionModel{DB: m.DB}
+	}
+	return m.Reactions
+}
+
+// commentSelect is the projection shared by every comment query.
+const commentSelect = `
 		SELECT
 			c.id,
 			c.post_id,
 			c.user_id,
+			c.parent_comment_id,
+			c.root_comment_id,
+			c.depth,
+			c.replies_count,
+			c.reactions_count,
+			c.score,
+			EXISTS(SELECT 1 FROM posts ap WHERE ap.id = c.post_id AND ap.accepted_comment_id = c.id),
 			u.nickname AS author,
 			c.content,
-			c.created_at
+			COALESCE(c.content_html, ''),
+			c.created_at,
+			c.edited_at,
+			c.deleted_at IS NOT NULL
 		FROM comments c
-		JOIN users u ON u.id = c.user_id
-		WHERE c.post_id = ?
-		ORDER BY c.created_at ASC;
-	`
+		JOIN users u ON u.id = c.user_id`
 
-	rows, err := m.DB.QueryContext(ctx, query, postID)
-	if err != nil {
+func scanComment(sc rowScanner) (*Comment, error) {
+	var c Comment
+	var parentID, rootID sql.NullInt64
+	var editedAt sql.NullString
+	if err := sc.Scan(
+		&c.ID,
+		&c.PostID,
+		&c.UserID,
+		&parentID,
+		&rootID,
+		&c.Depth,
+		&c.RepliesCount,
+		&c.ReactionsCount,
+		&c.Score,
+		&c.Accepted,
+		&c.Author,
+		&c.Content,
+		&c.ContentHTML,
+		&c.CreatedAt,
+		&editedAt,
+		&c.Deleted,
+	); err != nil {
 		return nil, err
 	}
+	if editedAt.Valid {
+		c.EditedAt = &editedAt.String
+	}
+	c.rank = CommentCursor{Accepted: c.Accepted, Score: c.Score, ID: c.ID}
+	if c.Deleted {
+		c.UserID, c.Author = 0, ""
+		c.Content, c.ContentHTML = DeletedCommentText, ""
+		c.ReactionsCount, c.Score, c.Accepted = 0, 0, false
+		c.EditedAt = nil
+	}
+	if parentID.Valid {
+		c.ParentID = &parentID.Int64
+	}
+	if rootID.Valid {
+		c.RootID = &rootID.Int64
+	}
+	return &c, nil
+}
+
+// scanComments collects every row of a commentSelect query.
+func scanComments(rows *sql.Rows) ([]*Comment, error) {
 	defer rows.Close()
 
 	var comments []*Comment
-
 	for rows.Next() {
-		var c Comment
-		if err := rows.Scan(
-			&c.ID,
-			&c.PostID,
-			&c.UserID,
-			&c.Author,
-			&c.Content,
-			&c.CreatedAt,
-		); err != nil {
+		c, err := scanComment(rows)
+		if err != nil {
 			return nil, err
 		}
-		comments = append(comments, &c)
+		comments = append(comments, c)
 	}
+	return comments, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+// Orders for CommentQuery.
+const (
+	CommentsOldest = "oldest"
+	CommentsNewest = "newest"
+	CommentsTop    = "top" // accepted answer first, then by score (Q&A)
+)
+
+// acceptedFirst is 1 for the post's accepted answer and 0 otherwise.
+const acceptedFirst = `(c.id IS (SELECT accepted_comment_id FROM posts WHERE id = c.post_id))`
+
+// CommentQuery selects one page of a post's threads.
+type CommentQuery struct {
+	After    int64          // root comment ID the previous page ended on; 0 for the first page
+	AfterTop *CommentCursor // CommentsTop's After; nil for the first page
+	Limit    int            // root comments per page
+	Order    string         // CommentsOldest (default), CommentsNewest or CommentsTop
+	ViewerID int64          // fills MyReactions and MyVote; 0 for anonymous viewers
+}
+
+// CommentCursor is the position of the last root comment of a CommentsTop
+// page: whether it was the accepted answer, its score and its ID, as they
+// were when the page was read. The next page resumes from those values, so
+// it still works after that comment is deleted.
+type CommentCursor struct {
+	Accepted bool
+	Score    int64
+	ID       int64
+}
+
+// TopCursor returns the CommentsTop cursor for a page ending on root c.
+func (c *Comment) TopCursor() CommentCursor {
+	return c.rank
+}
+
+// Encode returns the opaque string form handed to clients as next_cursor.
+func (c CommentCursor) Encode() string {
+	accepted := "0"
+	if c.Accepted {
+		accepted = "1"
+	}
+	raw := accepted + "|" + strconv.FormatInt(c.Score, 10) + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCommentCursor parses a value produced by CommentCursor.Encode.
+func DecodeCommentCursor(s string) (CommentCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return CommentCursor{}, ErrInvalidCursor
 	}
 
-	return comments, nil
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || (parts[0] != "0" && parts[0] != "1") {
+		return CommentCursor{}, ErrInvalidCursor
+	}
+
+	c := CommentCursor{Accepted: parts[0] == "1"}
+	if c.Score, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return CommentCursor{}, ErrInvalidCursor
+	}
+	if c.ID, err = strconv.ParseInt(parts[2], 10, 64); err != nil || c.ID <= 0 {
+		return CommentCursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// ListByPost returns one page of a post's threads as a flat list: each root
+// comment, in q.Order, followed by up to RepliesPreview of its replies (any
+// depth, oldest first). Replies carry ParentID and Depth so the client can
+// nest them; roots with more replies have MoreReplies set and the rest are
+// paged with ListReplies. IDs grow with creation time, so pages are keyed on
+// the root ID; CommentsTop pages resume after q.AfterTop, the rank the last
+// root had when its page was read, so a vote cast between pages can move an
+// answer across the page boundary.
+// hasMore reports whether another page of roots exists.
+// Every comment carries its reaction counts and q.ViewerID's reactions
+// and vote.
+func (m *CommentModel) ListByPost(ctx context.Context, postID int64, q CommentQuery) (comments []*Comment, hasMore bool, err error) {
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+
+	cond, order := "c.id > ?", "c.id ASC"
+	args := []any{postID, q.After}
+	switch q.Order {
+	case CommentsNewest:
+		order = "c.id DESC"
+		if q.After > 0 {
+			cond = "c.id < ?"
+		}
+	case CommentsTop:
+		order = acceptedFirst + " DESC, c.score DESC, c.id DESC"
+		cond = "1"
+		args = args[:1]
+		if q.AfterTop != nil {
+			cond = "(" + acceptedFirst + ", c.score, c.id) < (?, ?, ?)"
+			args = append(args, q.AfterTop.Accepted, q.AfterTop.Score, q.AfterTop.ID)
+		}
+	}
+
+	rows, err := m.DB.QueryContext(ctx, commentSelect+`
+		WHERE c.post_id = ? AND c.parent_comment_id IS NULL AND `+cond+`
+		ORDER BY `+order+`
+		LIMIT ?;
+	`, append(args, q.Limit+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	roots, err := scanComments(rows)
+	if err != nil || len(roots) == 0 {
+		return []*Comment{}, false, err
+	}
+	if hasMore = len(roots) > q.Limit; hasMore {
+		roots = roots[:q.Limit]
+	}
+
+	rootIDs := make([]int64, len(roots))
+	for i, root := range roots {
+		rootIDs[i] = root.ID
+	}
+	in, inArgs := inClause(rootIDs)
+
+	// One extra reply per thread tells whether more remain.
+	rows, err = m.DB.QueryContext(ctx, commentSelect+`
+		WHERE c.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY root_comment_id ORDER BY id) AS n
+				FROM comments
+				WHERE root_comment_id IN (`+in+`)
+			) WHERE n <= ?
+		)
+		ORDER BY c.id ASC;
+	`, append(inArgs, RepliesPreview+1)...)
+	if err != nil {
+		return nil, false, err
+	}
+	replies, err := scanComments(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	byRoot := make(map[int64][]*Comment, len(roots))
+	for _, r := range replies {
+		byRoot[*r.RootID] = append(byRoot[*r.RootID], r)
+	}
+
+	comments = make([]*Comment, 0, len(roots)+len(replies))
+	for _, root := range roots {
+		thread := byRoot[root.ID]
+		if len(thread) > RepliesPreview {
+			thread = thread[:RepliesPreview]
+			root.MoreReplies = true
+		}
+		comments = append(comments, root)
+		comments = append(comments, thread...)
+	}
+	if err := m.reactions().AttachComments(ctx, comments, q.ViewerID); err != nil {
+		return nil, false, err
+	}
+	if err := m.attachVotes(ctx, comments, q.ViewerID); err != nil {
+		return nil, false, err
+	}
+	return comments, hasMore, nil
+}
+
+// ListReplies pages through the replies under a root comment, oldest first,
+// starting after the reply with ID after (0 for the first page). Parents
+// always come before their replies. hasMore reports whether another page
+// exists. Reactions and votes are attached as in ListByPost.
+func (m *CommentModel) ListReplies(ctx context.Context, rootID, after int64, limit int, viewerID int64) ([]*Comment, bool, error) {
+	rows, err := m.DB.QueryContext(ctx, commentSelect+`
+		WHERE c.root_comment_id = ? AND c.id > ?
+		ORDER BY c.id ASC
+		LIMIT ?;
+	`, rootID, after, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	replies, err := scanComments(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(replies) > limit
+	if hasMore {
+		replies = replies[:limit]
+	}
+	if replies == nil {
+		replies = []*Comment{}
+	}
+	if err := m.reactions().AttachComments(ctx, replies, viewerID); err != nil {
+		return nil, false, err
+	}
+	if err := m.attachVotes(ctx, replies, viewerID); err != nil {
+		return nil, false, err
+	}
+	return replies, hasMore, nil
 }
 
-// Create inserts a new comment and fills in ID, CreatedAt, and Author.
+// Create inserts a new comment and fills in ID, CreatedAt, Author and ContentHTML.
+// A reply sets ParentID; the parent must be on the same post
+// (ErrInvalidParent) and shallower than MaxCommentDepth (ErrReplyTooDeep).
+// Locked threads take comments from moderators only (ErrThreadLocked); the
+// lock is read in the same transaction as the insert. Returns sql.ErrNoRows
+// if the post does not exist.
 func (m *CommentModel) Create(ctx context.Context, c *Comment) error {
-	res, err := m.DB.ExecContext(ctx,
-		`INSERT INTO comments (post_id, user_id, content) VALUES (?, ?, ?)`,
+	if contentTooLong(c.Content) {
+		return ErrContentTooLong
+	}
+	c.ContentHTML = markdown.Render(c.Content)
+	c.RootID, c.Depth = nil, 0
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { // safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var locked bool
+	var role sql.NullString
+	err = tx.QueryRowContext(ctx,
+		`SELECT p.locked, (SELECT role FROM users WHERE id = ?) FROM posts p WHERE p.id = ?`,
+		c.UserID, c.PostID,
+	).Scan(&locked, &role)
+	if err != nil {
+		return err
+	}
+	if locked && role.String != RoleModerator {
+		return ErrThreadLocked
+	}
+
+	if c.ParentID != nil {
+		var parentPostID int64
+		var parentRootID sql.NullInt64
+		var parentDepth int
+		err := tx.QueryRowContext(ctx,
+			`SELECT post_id, root_comment_id, depth FROM comments WHERE id = ?`, *c.ParentID,
+		).Scan(&parentPostID, &parentRootID, &parentDepth)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && parentPostID != c.PostID) {
+			return ErrInvalidParent
+		}
+		if err != nil {
+			return err
+		}
+		if parentDepth >= MaxCommentDepth {
+			return ErrReplyTooDeep
+		}
+
+		rootID := *c.ParentID
+		if parentRootID.Valid {
+			rootID = parentRootID.Int64
+		}
+		c.RootID = &rootID
+		c.Depth = parentDepth + 1
+	}
+
+	// Triggers bump the post's comments_count and last_activity_at, and the
+	// parent's replies_count (see db/counters.go).
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO comments (post_id, user_id, parent_comment_id, root_comment_id, depth, content, content_html) VALUES (?, ?, ?, ?, ?, ?, ?)`,
 		c.PostID,
 		c.UserID,
+		c.ParentID,
+		c.RootID,
+		c.Depth,
 		c.Content,
+		c.ContentHTML,
 	)
 	if err != nil {
 		return err
@@ -85,7 +437,7 @@ func (m *CommentModel) Create(ctx context.Context, c *Comment) error {
 	}
 
 	// Retrieve the author’s created_at and nickname
-	row := m.DB.QueryRowContext(ctx, `
+	row := tx.QueryRowContext(ctx, `
 		SELECT c.created_at, u.nickname
 		FROM comments c
 		JOIN users u ON u.id = c.user_id
@@ -94,59 +446,172 @@ func (m *CommentModel) Create(ctx context.Context, c *Comment) error {
 
 	_ = row.Scan(&c.CreatedAt, &c.Author)
 
-	return nil
+	return tx.Commit()
 }
 
 func (m *CommentModel) GetByID(ctx context.Context, commentID int64) (*Comment, error) {
-	const q = `
-    SELECT c.id, c.post_id, c.user_id, u.nickname AS author, c.content, c.created_at
-    FROM comments c
-    JOIN users u ON u.id = c.user_id
-    WHERE c.id = ?;
-  `
-	var c Comment
-	if err := m.DB.QueryRowContext(ctx, q, commentID).Scan(
-		&c.ID, &c.PostID, &c.UserID, &c.Author, &c.Content, &c.CreatedAt,
-	); err != nil {
-		return nil, err
-	}
-	return &c, nil
+	return scanComment(m.DB.QueryRowContext(ctx, commentSelect+`
+		WHERE c.id = ?;`, commentID))
 }
+
+// UpdateByOwner replaces the text of the owner's comment and keeps the
+// previous text in comment_revisions. Returns sql.ErrNoRows if the comment
+// is missing, a tombstone or someone else's, and ErrEditWindowClosed once
+// it is older than EditWindow.
 func (m *CommentModel) UpdateByOwner(ctx context.Context, commentID, ownerID int64, content string) (*Comment, error) {
+	return m.update(ctx, commentID, ownerID, true, content)
+}
+
+// UpdateAsModerator is UpdateByOwner without the owner check and the edit
+// window. Permission checks are the caller's job.
+func (m *CommentModel) UpdateAsModerator(ctx context.Context, commentID, moderatorID int64, content string) (*Comment, error) {
+	return m.update(ctx, commentID, moderatorID, false, content)
+}
+
+func (m *CommentModel) update(ctx context.Context, commentID, editorID int64, byOwner bool, content string) (*Comment, error) {
 	content = strings.TrimSpace(content)
 	if content == "" {
 		return nil, errors.New("content is required")
 	}
+	if contentTooLong(content) {
+		return nil, ErrContentTooLong
+	}
+
+	q := `UPDATE comments
+		SET content = ?, content_html = ?, edited_at = datetime('now')
+		WHERE id = ? AND deleted_at IS NULL`
+	args := []any{content, markdown.Render(content), commentID}
+	if byOwner {
+		cutoff := editCutoff(m.EditWindow)
+		q += ` AND user_id = ? AND (? = '' OR datetime(created_at) >= datetime('now', ?))`
+		args = append(args, editorID, cutoff, cutoff)
+	}
 
-	// Si NO tienes edited_at en DB, quita esa parte del SET.
-	res, err := m.DB.ExecContext(ctx, `
-		UPDATE comments
-		SET content = ?
-		WHERE id = ? AND user_id = ?;
-	`, content, commentID, ownerID)
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	// The first edit saves the original text as revision 1.
+	if err := snapshotOriginalComment(ctx, tx, commentID); err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	aff, _ := res.RowsAffected()
 	if aff == 0 {
-		return nil, sql.ErrNoRows // not found or not owner
+		if !byOwner {
+			return nil, sql.ErrNoRows
+		}
+		// Tell "not yours" apart from "too late".
+		var owner int64
+		err := tx.QueryRowContext(ctx,
+			`SELECT user_id FROM comments WHERE id = ? AND deleted_at IS NULL`, commentID,
+		).Scan(&owner)
+		if err != nil || owner != editorID {
+			return nil, sql.ErrNoRows
+		}
+		return nil, ErrEditWindowClosed
+	}
+
+	if err := snapshotCommentRevision(ctx, tx, commentID, editorID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
 	// Return updated comment with author
-	var c Comment
-	err = m.DB.QueryRowContext(ctx, `
-		SELECT
-			c.id, c.post_id, c.user_id,
-			u.nickname AS author,
-			c.content, c.created_at
-		FROM comments c
-		JOIN users u ON u.id = c.user_id
-		WHERE c.id = ?;
-	`, commentID).Scan(&c.ID, &c.PostID, &c.UserID, &c.Author, &c.Content, &c.CreatedAt)
+	return m.GetByID(ctx, commentID)
+}
+
+// CommentDeletion is what Delete did to a thread.
+type CommentDeletion struct {
+	Tombstoned bool    // the comment was kept as a tombstone
+	Removed    []int64 // comments removed outright, including emptied tombstones
+}
+
+// Delete removes a comment, or clears it into a tombstone while replies
+// still hang off it. Removing the last reply under a tombstone removes the
+// tombstone too, up the thread. Returns sql.ErrNoRows if the comment does
+// not exist or is already a tombstone.
+func (m *CommentModel) Delete(ctx context.Context, commentID int64) (*CommentDeletion, error) {
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
 
-	return &c, nil
+	var replies int64
+	var parentID sql.NullInt64
+	if err := tx.QueryRowContext(ctx,
+		`SELECT replies_count, parent_comment_id FROM comments WHERE id = ? AND deleted_at IS NULL`, commentID,
+	).Scan(&replies, &parentID); err != nil {
+		return nil, err
+	}
+
+	// Earlier versions and votes go with the text, and a deleted answer is
+	// no longer accepted.
+	for _, q := range []string{
+		`DELETE FROM comment_revisions WHERE comment_id = ?`,
+		`DELETE FROM comment_votes WHERE comment_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, q, commentID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE posts SET accepted_comment_id = NULL WHERE id = (SELECT post_id FROM comments WHERE id = ?) AND accepted_comment_id = ?`,
+		commentID, commentID,
+	); err != nil {
+		return nil, err
+	}
+
+	if replies > 0 {
+		// Clearing content also drops it from the search index (see db triggers).
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE comments SET content = '', content_html = '', deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, commentID,
+		); err != nil {
+			return nil, err
+		}
+		return &CommentDeletion{Tombstoned: true}, tx.Commit()
+	}
+
+	// Triggers keep comments_count and the parent's replies_count in step.
+	del := &CommentDeletion{}
+	for id := commentID; ; {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comment_reactions WHERE comment_id = ?`, id); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE id = ?`, id); err != nil {
+			return nil, err
+		}
+		del.Removed = append(del.Removed, id)
+
+		if !parentID.Valid {
+			break
+		}
+		id = parentID.Int64
+		err := tx.QueryRowContext(ctx,
+			`SELECT parent_comment_id FROM comments WHERE id = ? AND deleted_at IS NOT NULL AND replies_count = 0`, id,
+		).Scan(&parentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			break // the parent is live or still has other replies
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return del, tx.Commit()
 }
@@ -0,0 +1,283 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCommentRepliesNestAndPage(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "threader")
+	posts := &PostModel{DB: db}
+	comments := &CommentModel{DB: db}
+
+	post := &Post{UserID: user.ID, Title: "thread", Content: "body", Category: "General"}
+	other := &Post{UserID: user.ID, Title: "other", Content: "body", Category: "General"}
+	for _, p := range []*Post{post, other} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add := func(postID int64, parent *Comment) (*Comment, error) {
+		c := &Comment{PostID: postID, UserID: user.ID, Content: "reply"}
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		return c, comments.Create(ctx, c)
+	}
+
+	root, err := add(post.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A chain down to the depth limit, all in root's thread.
+	parent := root
+	for depth := 1; depth <= MaxCommentDepth; depth++ {
+		c, err := add(post.ID, parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Depth != depth || *c.ParentID != parent.ID || *c.RootID != root.ID {
+			t.Fatalf("reply at depth %d = %+v", depth, c)
+		}
+		parent = c
+	}
+	if _, err := add(post.ID, parent); err != ErrReplyTooDeep {
+		t.Fatalf("reply past the limit: err = %v, want ErrReplyTooDeep", err)
+	}
+	if _, err := add(other.ID, root); err != ErrInvalidParent {
+		t.Fatalf("reply on another post: err = %v, want ErrInvalidParent", err)
+	}
+
+	// Two more direct replies: root now has 3 direct, 5 total.
+	for i := 0; i < 2; i++ {
+		if _, err := add(post.ID, root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lone, err := add(post.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, _, err := comments.ListByPost(ctx, post.ID, CommentQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2+RepliesPreview {
+		t.Fatalf("ListByPost returned %d comments, want %d", len(list), 2+RepliesPreview)
+	}
+	if list[0].ID != root.ID || !list[0].MoreReplies || list[0].RepliesCount != 3 {
+		t.Fatalf("first root = %+v", list[0])
+	}
+	last := list[len(list)-1]
+	if last.ID != lone.ID || last.MoreReplies || last.RepliesCount != 0 {
+		t.Fatalf("last root = %+v", last)
+	}
+
+	page, hasMore, err := comments.ListReplies(ctx, root.ID, 0, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 3 || !hasMore {
+		t.Fatalf("first page = %d replies, hasMore=%v", len(page), hasMore)
+	}
+	page, hasMore, err = comments.ListReplies(ctx, root.ID, page[2].ID, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || hasMore {
+		t.Fatalf("second page = %d replies, hasMore=%v", len(page), hasMore)
+	}
+}
+
+func TestCommentDeleteLeavesTombstonesOnlyWhileNeeded(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "deleter")
+	posts := &PostModel{DB: db}
+	comments := &CommentModel{DB: db}
+
+	post := &Post{UserID: user.ID, Title: "thread", Content: "body", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	root := &Comment{PostID: post.ID, UserID: user.ID, Content: "root"}
+	if err := comments.Create(ctx, root); err != nil {
+		t.Fatal(err)
+	}
+	reply := &Comment{PostID: post.ID, UserID: user.ID, Content: "reply", ParentID: &root.ID}
+	if err := comments.Create(ctx, reply); err != nil {
+		t.Fatal(err)
+	}
+
+	// With a reply under it, the root becomes a tombstone.
+	del, err := comments.Delete(ctx, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !del.Tombstoned || len(del.Removed) != 0 {
+		t.Fatalf("delete root = %+v, want a tombstone", del)
+	}
+	got, err := comments.GetByID(ctx, root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Deleted || got.Content != DeletedCommentText || got.Author != "" || got.UserID != 0 {
+		t.Fatalf("tombstone = %+v", got)
+	}
+	if _, err := comments.UpdateByOwner(ctx, root.ID, user.ID, "back"); err != sql.ErrNoRows {
+		t.Fatalf("editing a tombstone: err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := comments.Delete(ctx, root.ID); err != sql.ErrNoRows {
+		t.Fatalf("deleting a tombstone: err = %v, want sql.ErrNoRows", err)
+	}
+
+	// Removing the last reply takes the emptied tombstone with it.
+	del, err = comments.Delete(ctx, reply.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if del.Tombstoned || len(del.Removed) != 2 || del.Removed[0] != reply.ID || del.Removed[1] != root.ID {
+		t.Fatalf("delete reply = %+v, want reply and root removed", del)
+	}
+
+	list, _, err := comments.ListByPost(ctx, post.ID, CommentQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("comments left = %+v", list)
+	}
+	p, err := posts.GetWithReactions(ctx, post.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.CommentsCount != 0 {
+		t.Fatalf("comments_count = %d, want 0", p.CommentsCount)
+	}
+}
+
+func TestListByPostPagesRootComments(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "pager")
+	comments := &CommentModel{DB: db}
+
+	post := &Post{UserID: user.ID, Title: "busy", Content: "body", Category: "General"}
+	if err := (&PostModel{DB: db}).Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	var roots []int64
+	for i := 0; i < 5; i++ {
+		c := &Comment{PostID: post.ID, UserID: user.ID, Content: "root"}
+		if err := comments.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, c.ID)
+		// Replies ride along with their root and do not count toward the limit.
+		reply := &Comment{PostID: post.ID, UserID: user.ID, Content: "reply", ParentID: &c.ID}
+		if err := comments.Create(ctx, reply); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		order string
+		want  []int64
+	}{
+		{CommentsOldest, roots},
+		{CommentsNewest, []int64{roots[4], roots[3], roots[2], roots[1], roots[0]}},
+	} {
+		var got []int64
+		q := CommentQuery{Limit: 2, Order: tc.order}
+		for page := 0; ; page++ {
+			list, hasMore, err := comments.ListByPost(ctx, post.ID, q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 4 && hasMore {
+				t.Fatalf("%s page %d has %d comments, want 2 roots + 2 replies", tc.order, page, len(list))
+			}
+			for _, c := range list {
+				if c.ParentID == nil {
+					got = append(got, c.ID)
+					q.After = c.ID
+				}
+			}
+			if !hasMore {
+				break
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Fatalf("%s roots = %v, want %v", tc.order, got, tc.want)
+		}
+	}
+}
+
+func TestCommentEditWindowAndRevisions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	alice := newTestUser(t, db, "alice")
+	mod := newTestUser(t, db, "mod")
+
+	post := &Post{UserID: alice.ID, Title: "t", Content: "c", Category: "General"}
+	if err := (&PostModel{DB: db}).Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	comments := &CommentModel{DB: db, EditWindow: DefaultEditWindow}
+	c := &Comment{PostID: post.ID, UserID: alice.ID, Content: "first"}
+	if err := comments.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := comments.UpdateByOwner(ctx, c.ID, alice.ID, "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EditedAt == nil || got.Content != "second" {
+		t.Fatalf("after edit: %+v", got)
+	}
+	if _, err := comments.UpdateByOwner(ctx, c.ID, mod.ID, "hijack"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("edit by non-owner: err = %v, want sql.ErrNoRows", err)
+	}
+
+	// Two days later only a moderator may edit.
+	if _, err := db.Exec(`UPDATE comments SET created_at = datetime('now', '-2 days') WHERE id = ?`, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := comments.UpdateByOwner(ctx, c.ID, alice.ID, "third"); !errors.Is(err, ErrEditWindowClosed) {
+		t.Fatalf("late edit: err = %v, want ErrEditWindowClosed", err)
+	}
+	if _, err := comments.UpdateAsModerator(ctx, c.ID, mod.ID, "moderated"); err != nil {
+		t.Fatal(err)
+	}
+
+	revs, err := comments.ListRevisions(ctx, c.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, r := range revs {
+		texts = append(texts, fmt.Sprintf("%d:%s:%s", r.Revision, r.Editor, r.Content))
+	}
+	want := []string{"1:alice:first", "2:alice:second", "3:mod:moderated"}
+	if fmt.Sprint(texts) != fmt.Sprint(want) {
+		t.Fatalf("revisions = %v, want %v", texts, want)
+	}
+
+	// Deleting the comment drops its history with the text.
+	if _, err := comments.Delete(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	if revs, err := comments.ListRevisions(ctx, c.ID); err != nil || len(revs) != 0 {
+		t.Fatalf("revisions after delete = %v, %v", revs, err)
+	}
+}
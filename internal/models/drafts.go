@@ -0,0 +1,291 @@
+// internal/models/drafts.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/markdown"
+)
+
+// Post statuses. Only published posts appear in listings, search and other
+// users' views; drafts and scheduled posts belong to their author alone.
+const (
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
+	PostStatusPublished = "published"
+)
+
+var (
+	ErrInvalidStatus     = errors.New("invalid post status")
+	ErrPublishAtRequired = errors.New("publish_at is required for scheduled posts")
+)
+
+// publishedOnly is the condition every listing query must include.
+const publishedOnly = "p.status = 'published'"
+
+// ParsePostStatus validates a status from a request ("" means published).
+func ParsePostStatus(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", PostStatusPublished:
+		return PostStatusPublished, nil
+	case PostStatusDraft:
+		return PostStatusDraft, nil
+	case PostStatusScheduled:
+		return PostStatusScheduled, nil
+	}
+	return "", ErrInvalidStatus
+}
+
+func validateStatus(status string, publishAt *time.Time) error {
+	switch status {
+	case PostStatusDraft, PostStatusPublished:
+		return nil
+	case PostStatusScheduled:
+		if publishAt == nil || publishAt.IsZero() {
+			return ErrPublishAtRequired
+		}
+		return nil
+	}
+	return ErrInvalidStatus
+}
+
+// sqliteTime formats t the way CURRENT_TIMESTAMP stores times (nil stays NULL).
+func sqliteTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// VisibleTo reports whether viewerID may see the post: published posts are
+// public, anything else only to its author. Missing posts are not visible.
+func (m *PostModel) VisibleTo(ctx context.Context, postID, viewerID int64) (bool, error) {
+	var status string
+	var ownerID int64
+	err := m.DB.QueryRowContext(ctx,
+		`SELECT status, user_id FROM posts WHERE id = ?`, postID,
+	).Scan(&status, &ownerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return status == PostStatusPublished || (viewerID > 0 && ownerID == viewerID), nil
+}
+
+// PublishedCategory returns a published post's category. Drafts and
+// scheduled posts answer sql.ErrNoRows like missing ones.
+func (m *PostModel) PublishedCategory(ctx context.Context, postID int64) (string, error) {
+	var category string
+	err := m.DB.QueryRowContext(ctx,
+		`SELECT category FROM posts WHERE id = ? AND status = 'published'`, postID,
+	).Scan(&category)
+	return category, err
+}
+
+// ListDrafts returns the author's drafts and scheduled posts, most recently
+// created first.
+func (m *PostModel) ListDrafts(ctx context.Context, userID int64) ([]Post, error) {
+	query := postWithReactionsSelect + `
+    WHERE p.user_id = ? AND p.status IN ('draft', 'scheduled')
+    ORDER BY p.created_at DESC, p.id DESC;`
+
+	rows, err := m.DB.QueryContext(ctx, query, append(viewerArgs(userID), userID)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		p, err := scanPostWithReactions(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// DraftUpdate holds the fields of a draft to change; nil means unchanged.
+// Setting Status to published publishes the draft immediately. Tags, when
+// set, replace the draft's tags (already normalized, see NormalizeTags).
+type DraftUpdate struct {
+	Title     *string
+	Content   *string
+	Category  *string
+	Status    *string
+	PublishAt *time.Time
+	Tags      *[]string
+}
+
+// UpdateDraft edits one of ownerID's unpublished posts. It returns
+// sql.ErrNoRows if the post is missing, not owned by ownerID or already
+// published (published posts are edited with UpdateByOwner).
+func (m *PostModel) UpdateDraft(ctx context.Context, postID, ownerID int64, upd DraftUpdate) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var status string
+	var publishAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT status, publish_at FROM posts WHERE id = ? AND user_id = ? AND status != 'published'`,
+		postID, ownerID,
+	).Scan(&status, &publishAt)
+	if err != nil {
+		return err
+	}
+
+	if upd.Status != nil {
+		status = *upd.Status
+	}
+	var at *time.Time
+	if upd.PublishAt != nil {
+		at = upd.PublishAt
+	} else if publishAt.Valid {
+		at = &publishAt.Time
+	}
+	if err := validateStatus(status, at); err != nil {
+		return err
+	}
+	if status != PostStatusScheduled {
+		at = nil
+	}
+
+	setParts := []string{"publish_at = ?"}
+	args := []any{sqliteTime(at)}
+
+	// Publishing goes through publish below, which also stamps created_at.
+	if status != PostStatusPublished {
+		setParts = append(setParts, "status = ?")
+		args = append(args, status)
+	}
+
+	if upd.Title != nil {
+		t := strings.TrimSpace(*upd.Title)
+		if t == "" {
+			return errors.New("title cannot be empty")
+		}
+		setParts = append(setParts, "title = ?")
+		args = append(args, t)
+	}
+	if upd.Content != nil {
+		c := strings.TrimSpace(*upd.Content)
+		if c == "" {
+			return errors.New("content cannot be empty")
+		}
+		if contentTooLong(c) {
+			return ErrContentTooLong
+		}
+		setParts = append(setParts, "content = ?", "content_html = ?")
+		args = append(args, c, markdown.Render(c))
+	}
+	if upd.Category != nil {
+		setParts = append(setParts, "category = ?")
+		args = append(args, strings.TrimSpace(*upd.Category))
+	}
+
+	args = append(args, postID)
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE posts SET `+strings.Join(setParts, ", ")+` WHERE id = ?`, args...,
+	); err != nil {
+		return err
+	}
+
+	if upd.Tags != nil {
+		if err := setTags(ctx, tx, postID, *upd.Tags); err != nil {
+			return err
+		}
+	}
+
+	if status == PostStatusPublished {
+		if err := publish(ctx, tx, postID, ownerID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// publish makes an unpublished post public. created_at becomes the
+// publication time so the post lands at the top of the "new" feed, and the
+// published text is recorded as revision 1.
+func publish(ctx context.Context, tx *sql.Tx, postID, editorID int64) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts
+		SET status = 'published',
+		    publish_at = NULL,
+		    created_at = CURRENT_TIMESTAMP,
+		    last_activity_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status != 'published';
+	`, postID); err != nil {
+		return err
+	}
+	return snapshotRevision(ctx, tx, postID, editorID, nil)
+}
+
+// PublishDue publishes every scheduled post whose publish_at is not after
+// now and returns their IDs. cmd/server calls it periodically.
+func (m *PostModel) PublishDue(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT id, user_id FROM posts
+		WHERE status = 'scheduled' AND publish_at <= ?
+		ORDER BY publish_at, id;
+	`, now.UTC().Format(sqliteTimeLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	type due struct{ postID, ownerID int64 }
+	var posts []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.postID, &d.ownerID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		posts = append(posts, d)
+	}
+	// publish runs in a transaction that needs the connection this cursor
+	// is holding, so collect the due posts before starting it.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, nil
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	ids := make([]int64, 0, len(posts))
+	for _, d := range posts {
+		if err := publish(ctx, tx, d.postID, d.ownerID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, d.postID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDraftsAreExcludedFromListings(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+
+	public := &Post{UserID: user.ID, Title: "public", Content: "body", Category: "General"}
+	draft := &Post{UserID: user.ID, Title: "draft", Content: "body", Category: "General", Status: PostStatusDraft}
+	at := time.Now().Add(time.Hour)
+	scheduled := &Post{UserID: user.ID, Title: "later", Content: "body", Category: "General", Status: PostStatusScheduled, PublishAt: &at}
+	for _, p := range []*Post{public, draft, scheduled} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := posts.List(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != public.ID {
+		t.Fatalf("List returned %+v", list)
+	}
+
+	for _, sort := range []string{SortNew, SortHot, SortTop, SortDiscussed} {
+		page, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, Sort: sort}, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 1 || page[0].ID != public.ID {
+			t.Fatalf("%s feed returned %d posts", sort, len(page))
+		}
+	}
+
+	drafts, err := posts.ListDrafts(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts) != 2 {
+		t.Fatalf("ListDrafts returned %d posts, want 2", len(drafts))
+	}
+
+	other := newTestUser(t, db, "other")
+	if ok, _ := posts.VisibleTo(ctx, draft.ID, other.ID); ok {
+		t.Fatal("draft visible to another user")
+	}
+	if ok, _ := posts.VisibleTo(ctx, draft.ID, user.ID); !ok {
+		t.Fatal("draft not visible to its author")
+	}
+
+	revs, err := posts.ListRevisions(ctx, draft.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 0 {
+		t.Fatalf("draft has %d revisions, want 0", len(revs))
+	}
+}
+
+func TestPublishDue(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+
+	soon := time.Now().Add(time.Minute)
+	later := time.Now().Add(time.Hour)
+	a := &Post{UserID: user.ID, Title: "soon", Content: "body", Category: "General", Status: PostStatusScheduled, PublishAt: &soon}
+	b := &Post{UserID: user.ID, Title: "later", Content: "body", Category: "General", Status: PostStatusScheduled, PublishAt: &later}
+	for _, p := range []*Post{a, b} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := posts.PublishDue(ctx, time.Now().Add(10*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != a.ID {
+		t.Fatalf("published %v, want [%d]", ids, a.ID)
+	}
+
+	got, err := posts.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != PostStatusPublished || got.PublishAt != nil {
+		t.Fatalf("after publish: status=%s publish_at=%v", got.Status, got.PublishAt)
+	}
+	if revs, _ := posts.ListRevisions(ctx, a.ID); len(revs) != 1 {
+		t.Fatalf("published post has %d revisions, want 1", len(revs))
+	}
+
+	// Running again publishes nothing new.
+	if ids, err := posts.PublishDue(ctx, time.Now().Add(10*time.Minute)); err != nil || len(ids) != 0 {
+		t.Fatalf("second run: ids=%v err=%v", ids, err)
+	}
+}
+
+func TestUpdateDraftPublishes(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	other := newTestUser(t, db, "other")
+	posts := &PostModel{DB: db}
+
+	draft := &Post{UserID: user.ID, Title: "draft", Content: "body", Category: "General", Status: PostStatusDraft}
+	if err := posts.Create(ctx, draft); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "final title"
+	if err := posts.UpdateDraft(ctx, draft.ID, other.ID, DraftUpdate{Title: &title}); err == nil {
+		t.Fatal("another user edited the draft")
+	}
+
+	scheduled := PostStatusScheduled
+	if err := posts.UpdateDraft(ctx, draft.ID, user.ID, DraftUpdate{Status: &scheduled}); err != ErrPublishAtRequired {
+		t.Fatalf("scheduling without publish_at: err = %v", err)
+	}
+
+	published := PostStatusPublished
+	if err := posts.UpdateDraft(ctx, draft.ID, user.ID, DraftUpdate{Title: &title, Status: &published}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := posts.Get(ctx, draft.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != PostStatusPublished || got.Title != title {
+		t.Fatalf("got status=%s title=%q", got.Status, got.Title)
+	}
+
+	// Published posts are no longer drafts.
+	if err := posts.UpdateDraft(ctx, draft.ID, user.ID, DraftUpdate{Title: &title}); err == nil {
+		t.Fatal("UpdateDraft accepted a published post")
+	}
+}
+
+func TestUpdateDraftWithTagsIsAtomic(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+	tagModel := &TagModel{DB: db}
+
+	draft := &Post{UserID: user.ID, Title: "draft", Content: "body", Category: "General", Status: PostStatusDraft, Tags: []string{"old"}}
+	if err := posts.Create(ctx, draft); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`CREATE TRIGGER tags_fail BEFORE INSERT ON tags BEGIN SELECT RAISE(ABORT, 'boom'); END;`); err != nil {
+		t.Fatal(err)
+	}
+	title, tags := "new title", []string{"new"}
+	if err := posts.UpdateDraft(ctx, draft.ID, user.ID, DraftUpdate{Title: &title, Tags: &tags}); err == nil {
+		t.Fatal("UpdateDraft succeeded although the tags could not be stored")
+	}
+	list := []Post{{ID: draft.ID}}
+	if err := tagModel.Attach(ctx, list); err != nil {
+		t.Fatal(err)
+	}
+	got, err := posts.Get(ctx, draft.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "draft" || len(list[0].Tags) != 1 || list[0].Tags[0] != "old" {
+		t.Fatalf("after a failed update: title=%q tags=%v, want both unchanged", got.Title, list[0].Tags)
+	}
+
+	if _, err := db.Exec(`DROP TRIGGER tags_fail`); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.UpdateDraft(ctx, draft.ID, user.ID, DraftUpdate{Title: &title, Tags: &tags}); err != nil {
+		t.Fatal(err)
+	}
+	list = []Post{{ID: draft.ID}}
+	if err := tagModel.Attach(ctx, list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list[0].Tags) != 1 || list[0].Tags[0] != "new" {
+		t.Fatalf("tags = %v, want [new]", list[0].Tags)
+	}
+}
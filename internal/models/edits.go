@@ -0,0 +1,25 @@
+// internal/models/edits.go
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEditWindowClosed is returned when an author edits a post or comment
+// after the edit window has passed. Moderators can still edit it.
+var ErrEditWindowClosed = errors.New("edit window closed")
+
+// DefaultEditWindow is how long authors may edit what they published when
+// nothing else is configured.
+const DefaultEditWindow = 24 * time.Hour
+
+// editCutoff turns an edit window into a datetime('now', ?) modifier such
+// as "-86400 seconds". It is "" when the window is zero (no limit).
+func editCutoff(window time.Duration) string {
+	if window <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("-%d seconds", int64(window/time.Second))
+}
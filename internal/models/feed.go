@@ -0,0 +1,344 @@
+// internal/models/feed.go
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCursor is returned for a malformed pagination cursor, or one
+	// that was issued for a different sort mode.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidWindow = errors.New("invalid window")
+)
+
+// Feed sort modes.
+const (
+	SortNew       = "new"       // newest first
+	SortHot       = "hot"       // decaying score over reactions, comments and views
+	SortTop       = "top"       // most reactions within a time window
+	SortDiscussed = "discussed" // most comments
+	SortActive    = "active"    // most recent activity (new post or comment)
+)
+
+// Time windows for SortTop.
+const (
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowAll   = "all"
+)
+
+// windowModifiers maps a window to the datetime() modifier of its lower bound.
+var windowModifiers = map[string]string{
+	WindowDay:   "-1 day",
+	WindowWeek:  "-7 days",
+	WindowMonth: "-1 month",
+}
+
+// scoreColumns are the post_scores columns each ranked sort orders by.
+// Every post has a row there (seeded on create and by the migration).
+var scoreColumns = map[string]string{
+	SortHot:       "s.hot",
+	SortTop:       "s.reactions",
+	SortDiscussed: "s.comments",
+}
+
+// ParseSort validates a ?sort= value; "" means SortNew.
+func ParseSort(v string) (string, error) {
+	switch v {
+	case "":
+		return SortNew, nil
+	case SortNew, SortHot, SortTop, SortDiscussed, SortActive:
+		return v, nil
+	}
+	return "", ErrInvalidSort
+}
+
+// ParseWindow validates a ?window= value; "" means WindowAll.
+func ParseWindow(v string) (string, error) {
+	switch v {
+	case "":
+		return WindowAll, nil
+	case WindowDay, WindowWeek, WindowMonth, WindowAll:
+		return v, nil
+	}
+	return "", ErrInvalidWindow
+}
+
+// FeedQuery describes one page of the post feed.
+//
+// After selects keyset pagination: only posts strictly after the cursor in
+// the chosen order are returned, so pages stay stable while new posts arrive.
+// Offset is the deprecated LIMIT/OFFSET fallback and is ignored when After is set.
+type FeedQuery struct {
+	Limit    int64
+	Sort     string // SortNew when empty
+	Window   string // only used by SortTop; WindowAll when empty
+	Category string // optional; also enables category pins
+	Tag      string // optional, normalized (see NormalizeTag)
+	Solved   string // optional: Solved or Unsolved questions only (see qa.go)
+
+	// FollowedBy restricts the feed to authors and categories this user
+	// follows (see follows.go).
+	FollowedBy int64
+	After      *FeedCursor
+	Offset     int64
+}
+
+// FeedCursor is the position of the last post of a page: (created_at, id)
+// for SortNew, (last_activity_at, id) for SortActive, (score, id) for the
+// ranked sorts.
+type FeedCursor struct {
+	Sort           string
+	CreatedAt      time.Time
+	LastActivityAt time.Time
+	Score          float64
+	ID             int64
+}
+
+// sqliteTimeLayout matches CURRENT_TIMESTAMP, which is how created_at is stored.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// Encode returns the opaque string form handed to clients as next_cursor.
+func (c FeedCursor) Encode() string {
+	var key string
+	switch c.Sort {
+	case SortNew:
+		key = c.CreatedAt.UTC().Format(sqliteTimeLayout)
+	case SortActive:
+		key = c.LastActivityAt.UTC().Format(sqliteTimeLayout)
+	default:
+		key = strconv.FormatFloat(c.Score, 'g', -1, 64)
+	}
+	raw := c.Sort + "|" + key + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeFeedCursor parses a value produced by FeedCursor.Encode.
+func DecodeFeedCursor(s string) (FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	c := FeedCursor{Sort: parts[0]}
+	if _, err := ParseSort(c.Sort); err != nil || c.Sort == "" {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	switch c.Sort {
+	case SortNew:
+		c.CreatedAt, err = time.Parse(sqliteTimeLayout, parts[1])
+	case SortActive:
+		c.LastActivityAt, err = time.Parse(sqliteTimeLayout, parts[1])
+	default:
+		c.Score, err = strconv.ParseFloat(parts[1], 64)
+	}
+	if err != nil {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	c.ID, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || c.ID <= 0 {
+		return FeedCursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ListWithReactionsPage returns one page of the feed with author + reactions
+// info for viewer. next is the cursor for the following page, or nil when
+// this is the last one.
+//
+// Every order ends with the post id DESC so ties keep a stable order. SortNew is
+// served by idx_posts_created_id and SortActive by idx_posts_activity_id; the
+// ranked sorts read the precomputed post_scores table (see RefreshScores)
+// instead of aggregating per request.
+func (m *PostModel) ListWithReactionsPage(ctx context.Context, q FeedQuery, viewerID int64) (posts []Post, next *FeedCursor, err error) {
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+	if q.Sort, err = ParseSort(q.Sort); err != nil {
+		return nil, nil, err
+	}
+	if q.Window, err = ParseWindow(q.Window); err != nil {
+		return nil, nil, err
+	}
+	if q.After != nil && q.After.Sort != q.Sort {
+		return nil, nil, ErrInvalidCursor
+	}
+	if q.Solved, err = ParseSolved(q.Solved); err != nil {
+		return nil, nil, err
+	}
+
+	args := viewerArgs(viewerID)
+	conds := []string{publishedOnly}
+
+	if q.Category != "" {
+		conds = append(conds, "LOWER(p.category) = LOWER(?)")
+		args = append(args, q.Category)
+	}
+	if q.Tag != "" {
+		conds = append(conds, tagCond)
+		args = append(args, q.Tag)
+	}
+	if q.FollowedBy > 0 {
+		conds = append(conds, followingCond)
+		args = append(args, q.FollowedBy, q.FollowedBy)
+	}
+	if q.Solved != "" {
+		conds = append(conds, solvedConds[q.Solved])
+	}
+
+	// Pinned posts in scope lead the first page and are kept out of the
+	// ordered listing, so they never show up twice while paginating.
+	pinned := pinnedCond(q.Category != "")
+	if q.After == nil && q.Offset == 0 {
+		pins, err := m.listPinned(ctx, conds, args, pinned)
+		if err != nil {
+			return nil, nil, err
+		}
+		posts = pins
+	}
+	conds = append(conds, "NOT "+pinned)
+
+	scoreExpr := "0.0"
+	order := "p.created_at DESC, p.id DESC"
+	from := postWithReactionsFrom
+
+	switch q.Sort {
+	case SortNew:
+	case SortActive:
+		order = "p.last_activity_at DESC, p.id DESC"
+	default:
+		scoreExpr = scoreColumns[q.Sort]
+		order = scoreExpr + " DESC, s.post_id DESC"
+		from += `
+    JOIN post_scores s ON s.post_id = p.id`
+	}
+
+	if q.Sort == SortTop && q.Window != WindowAll {
+		conds = append(conds, "p.created_at >= datetime('now', ?)")
+		args = append(args, windowModifiers[q.Window])
+	}
+
+	if q.After != nil {
+		switch q.Sort {
+		case SortNew:
+			conds = append(conds, "(p.created_at, p.id) < (?, ?)")
+			args = append(args, q.After.CreatedAt.UTC().Format(sqliteTimeLayout), q.After.ID)
+		case SortActive:
+			conds = append(conds, "(p.last_activity_at, p.id) < (?, ?)")
+			args = append(args, q.After.LastActivityAt.UTC().Format(sqliteTimeLayout), q.After.ID)
+		default:
+			conds = append(conds, "("+scoreExpr+", s.post_id) < (?, ?)")
+			args = append(args, q.After.Score, q.After.ID)
+		}
+	}
+
+	where := `
+    WHERE ` + strings.Join(conds, " AND ")
+
+	// Fetch one extra row to know if there is more.
+	query := postWithReactionsColumns + `,
+      ` + scoreExpr + ` AS sort_score` + from + where + `
+    ORDER BY ` + order + `
+    LIMIT ?`
+	args = append(args, q.Limit+1)
+
+	if q.After == nil && q.Offset > 0 {
+		query += ` OFFSET ?`
+		args = append(args, q.Offset)
+	}
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	page := []Post{}
+	scores := []float64{}
+	for rows.Next() {
+		var score float64
+		p, err := scanPostWithReactions(rows, &score)
+		if err != nil {
+			return nil, nil, err
+		}
+		page = append(page, p)
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if int64(len(page)) > q.Limit {
+		page = page[:q.Limit] // cut -> extra
+		last := page[len(page)-1]
+		next = &FeedCursor{
+			Sort:           q.Sort,
+			CreatedAt:      last.CreatedAt,
+			LastActivityAt: last.LastActivityAt,
+			Score:          scores[len(page)-1],
+			ID:             last.ID,
+		}
+	}
+
+	if posts == nil {
+		posts = []Post{}
+	}
+	return append(posts, page...), next, nil
+}
+
+// PinnedInScope reports whether p is one of the pinned posts that lead
+// this feed (the Go twin of pinnedCond).
+func (q FeedQuery) PinnedInScope(p Post) bool {
+	return p.PinScope == PinScopeGlobal || (q.Category != "" && p.PinScope == PinScopeCategory)
+}
+
+// pinnedCond matches the posts pinned in a feed's scope: global pins
+// always, category pins only when the feed is filtered by category.
+func pinnedCond(byCategory bool) string {
+	if byCategory {
+		return "COALESCE(p.pin_scope, '') IN ('global', 'category')"
+	}
+	return "COALESCE(p.pin_scope, '') = 'global'"
+}
+
+// listPinned returns the pinned posts matching conds, most recently pinned
+// first. args starts with viewerArgs.
+func (m *PostModel) listPinned(ctx context.Context, conds []string, args []any, pinned string) ([]Post, error) {
+	query := postWithReactionsSelect + `
+    WHERE ` + strings.Join(append(append([]string{}, conds...), pinned), " AND ") + `
+    ORDER BY p.pinned_at DESC, p.id DESC`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := []Post{}
+	for rows.Next() {
+		p, err := scanPostWithReactions(rows)
+		if err != nil {
+			return nil, err
+		}
+		pins = append(pins, p)
+	}
+	return pins, rows.Err()
+}
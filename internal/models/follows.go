@@ -0,0 +1,132 @@
+// internal/models/follows.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrSelfFollow = errors.New("cannot follow yourself")
+
+// Follows is everything a user follows.
+type Follows struct {
+	Users      []UserLite `json:"users"`
+	Categories []Category `json:"categories"`
+}
+
+type FollowModel struct {
+	DB *sql.DB
+}
+
+// FollowUser makes followerID follow followeeID (ErrUserNotFound,
+// ErrSelfFollow). Following twice is a no-op.
+func (m *FollowModel) FollowUser(ctx context.Context, followerID, followeeID int64) error {
+	if followerID == followeeID {
+		return ErrSelfFollow
+	}
+
+	res, err := m.DB.ExecContext(ctx, `
+		INSERT OR IGNORE INTO user_follows (follower_id, followee_id)
+		SELECT ?, id FROM users WHERE id = ?;
+	`, followerID, followeeID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	// Nothing inserted: already following, or no such user.
+	var exists bool
+	if err := m.DB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE id = ?)`, followeeID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UnfollowUser is a no-op when followerID does not follow followeeID.
+func (m *FollowModel) UnfollowUser(ctx context.Context, followerID, followeeID int64) error {
+	_, err := m.DB.ExecContext(ctx,
+		`DELETE FROM user_follows WHERE follower_id = ? AND followee_id = ?`, followerID, followeeID)
+	return err
+}
+
+// FollowCategory is a no-op when userID already follows the category.
+func (m *FollowModel) FollowCategory(ctx context.Context, userID, categoryID int64) error {
+	_, err := m.DB.ExecContext(ctx,
+		`INSERT OR IGNORE INTO category_follows (user_id, category_id) VALUES (?, ?)`, userID, categoryID)
+	return err
+}
+
+// UnfollowCategory is a no-op when userID does not follow the category.
+func (m *FollowModel) UnfollowCategory(ctx context.Context, userID, categoryID int64) error {
+	_, err := m.DB.ExecContext(ctx,
+		`DELETE FROM category_follows WHERE user_id = ? AND category_id = ?`, userID, categoryID)
+	return err
+}
+
+// List returns the users (by nickname) and categories (by name) userID follows.
+func (m *FollowModel) List(ctx context.Context, userID int64) (*Follows, error) {
+	f := &Follows{Users: []UserLite{}, Categories: []Category{}}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT u.id, u.nickname
+		FROM user_follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = ?
+		ORDER BY u.nickname COLLATE NOCASE;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var u UserLite
+		if err := rows.Scan(&u.ID, &u.Nickname); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		f.Users = append(f.Users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = m.DB.QueryContext(ctx, `
+		SELECT c.id, c.name, c.created_at
+		FROM category_follows f
+		JOIN categories c ON c.id = f.category_id
+		WHERE f.user_id = ?
+		ORDER BY c.name;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		f.Categories = append(f.Categories, c)
+	}
+	return f, rows.Err()
+}
+
+// followingCond matches posts by authors the viewer follows or in
+// categories they follow. It takes the viewer ID twice.
+const followingCond = `(
+      p.user_id IN (SELECT followee_id FROM user_follows WHERE follower_id = ?)
+      OR LOWER(p.category) IN (
+        SELECT LOWER(c.name) FROM category_follows cf
+        JOIN categories c ON c.id = cf.category_id
+        WHERE cf.user_id = ?
+      )
+    )`
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFollowingFeed(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	reader := newTestUser(t, db, "reader")
+	alice := newTestUser(t, db, "alice")
+	bob := newTestUser(t, db, "bob")
+	posts := &PostModel{DB: db}
+	follows := &FollowModel{DB: db}
+	categories := &CategoryModel{DB: db}
+
+	create := func(author *User, category string) int64 {
+		p := &Post{UserID: author.ID, Title: "t", Content: "body", Category: category}
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		return p.ID
+	}
+	byAlice := create(alice, "General")
+	bobGo := create(bob, "Go")
+	create(bob, "General")
+
+	if err := follows.FollowUser(ctx, reader.ID, reader.ID); !errors.Is(err, ErrSelfFollow) {
+		t.Fatalf("self follow: err = %v", err)
+	}
+	if err := follows.FollowUser(ctx, reader.ID, 9999); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("missing user: err = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := follows.FollowUser(ctx, reader.ID, alice.ID); err != nil {
+			t.Fatalf("follow alice (%d): %v", i, err)
+		}
+	}
+	goCat, err := categories.GetByName(ctx, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := follows.FollowCategory(ctx, reader.ID, goCat.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	feedIDs := func() string {
+		t.Helper()
+		page, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, FollowedBy: reader.ID}, reader.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, p := range page {
+			ids = append(ids, p.ID)
+		}
+		return fmt.Sprint(ids)
+	}
+
+	if got, want := feedIDs(), fmt.Sprint([]int64{bobGo, byAlice}); got != want {
+		t.Fatalf("following feed = %s, want %s", got, want)
+	}
+
+	f, err := follows.List(ctx, reader.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Users) != 1 || f.Users[0].Nickname != "alice" || len(f.Categories) != 1 || f.Categories[0].Name != "Go" {
+		t.Fatalf("follows = %+v", f)
+	}
+
+	if err := follows.UnfollowCategory(ctx, reader.ID, goCat.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := follows.UnfollowUser(ctx, reader.ID, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := feedIDs(); got != "[]" {
+		t.Fatalf("after unfollow = %s", got)
+	}
+}
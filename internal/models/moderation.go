@@ -0,0 +1,66 @@
+// internal/models/moderation.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Pin scopes. A globally pinned post tops every feed; a category pin only
+// applies when the feed is filtered to that post's category.
+const (
+	PinScopeGlobal   = "global"
+	PinScopeCategory = "category"
+)
+
+var ErrInvalidPinScope = errors.New("invalid pin scope")
+
+// ParsePinScope validates a pin scope from a request ("" means global).
+func ParsePinScope(v string) (string, error) {
+	switch v {
+	case "", PinScopeGlobal:
+		return PinScopeGlobal, nil
+	case PinScopeCategory:
+		return PinScopeCategory, nil
+	}
+	return "", ErrInvalidPinScope
+}
+
+// SetPinned pins a post in scope, or unpins it when scope is "".
+// Returns sql.ErrNoRows if the post does not exist.
+// Permission checks (moderators only) are the caller's job.
+func (m *PostModel) SetPinned(ctx context.Context, postID int64, scope string) error {
+	var res sql.Result
+	var err error
+	if scope == "" {
+		res, err = m.DB.ExecContext(ctx,
+			`UPDATE posts SET pin_scope = NULL, pinned_at = NULL WHERE id = ?`, postID)
+	} else {
+		if _, err := ParsePinScope(scope); err != nil {
+			return err
+		}
+		res, err = m.DB.ExecContext(ctx,
+			`UPDATE posts SET pin_scope = ?, pinned_at = CURRENT_TIMESTAMP WHERE id = ?`, scope, postID)
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetLocked locks or unlocks a post's comment thread.
+// Returns sql.ErrNoRows if the post does not exist.
+func (m *PostModel) SetLocked(ctx context.Context, postID int64, locked bool) error {
+	res, err := m.DB.ExecContext(ctx, `UPDATE posts SET locked = ? WHERE id = ?`, locked, postID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPinnedPostsLeadTheFeed(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		cat := "General"
+		if i%2 == 1 {
+			cat = "Go"
+		}
+		p := &Post{UserID: user.ID, Title: fmt.Sprintf("post %d", i), Content: "body", Category: cat}
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, p.ID)
+	}
+	if _, err := db.Exec(`UPDATE posts SET created_at = datetime('2024-01-01 10:00:00', '+' || id || ' minutes')`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Oldest post pinned globally, post 1 (Go) pinned in its category.
+	if err := posts.SetPinned(ctx, ids[0], PinScopeGlobal); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.SetPinned(ctx, ids[1], PinScopeCategory); err != nil {
+		t.Fatal(err)
+	}
+
+	// Global feed: the global pin first, then everything else by date,
+	// across pages without repeats.
+	var seen []int64
+	q := FeedQuery{Limit: 2}
+	for {
+		page, next, err := posts.ListWithReactionsPage(ctx, q, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range page {
+			seen = append(seen, p.ID)
+		}
+		if next == nil {
+			break
+		}
+		q.After = next
+	}
+	want := []int64{ids[0], ids[4], ids[3], ids[2], ids[1]}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("global feed = %v, want %v", seen, want)
+	}
+
+	// Go category: the category pin leads; the global pin is not in Go.
+	page, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, Category: "go"}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].ID != ids[1] || !page[0].Pinned || page[1].ID != ids[3] {
+		t.Fatalf("Go feed = %+v", page)
+	}
+
+	if err := posts.SetPinned(ctx, ids[0], ""); err != nil {
+		t.Fatal(err)
+	}
+	page, _, err = posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 1}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != ids[4] {
+		t.Fatalf("after unpin, first post = %+v", page)
+	}
+}
+
+func TestLockedThreadRejectsCommentsInCreate(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "author")
+	mod := newTestUser(t, db, "warden")
+	users := &UserModel{DB: db}
+	posts := &PostModel{DB: db}
+	comments := &CommentModel{DB: db}
+
+	if err := users.SetRoleByNickname(ctx, "warden", RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	post := &Post{UserID: author.ID, Title: "closed", Content: "body", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.SetLocked(ctx, post.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	err := comments.Create(ctx, &Comment{PostID: post.ID, UserID: author.ID, Content: "late"})
+	if !errors.Is(err, ErrThreadLocked) {
+		t.Fatalf("comment on locked thread: err = %v, want ErrThreadLocked", err)
+	}
+	if err := comments.Create(ctx, &Comment{PostID: post.ID, UserID: mod.ID, Content: "closing note"}); err != nil {
+		t.Fatalf("moderator comment on locked thread: %v", err)
+	}
+	err = comments.Create(ctx, &Comment{PostID: post.ID + 100, UserID: author.ID, Content: "nowhere"})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("comment on missing post: err = %v, want sql.ErrNoRows", err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT comments_count FROM posts WHERE id = ?`, post.ID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("comments_count = %d, want 1", count)
+	}
+}
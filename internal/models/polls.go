@@ -0,0 +1,333 @@
+// internal/models/polls.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Poll limits.
+const (
+	MinPollOptions     = 2
+	MaxPollOptions     = 10
+	MaxPollQuestionLen = 200
+	MaxPollOptionLen   = 100
+)
+
+var (
+	ErrPollNotFound   = errors.New("poll not found")
+	ErrPollClosed     = errors.New("poll is closed")
+	ErrAlreadyVoted   = errors.New("already voted")
+	ErrInvalidPoll    = errors.New("invalid poll")
+	ErrInvalidChoices = errors.New("invalid choices")
+)
+
+// Poll is a question attached to a post. Results are always visible;
+// for public polls each option also lists who picked it.
+type Poll struct {
+	ID         int64        `json:"id"`
+	PostID     int64        `json:"post_id"`
+	Question   string       `json:"question"`
+	Multiple   bool         `json:"multiple"`
+	Anonymous  bool         `json:"anonymous"`
+	ClosesAt   *time.Time   `json:"closes_at,omitempty"`
+	Closed     bool         `json:"closed"`
+	TotalVotes int64        `json:"total_votes"` // number of voters, not choices
+	Options    []PollOption `json:"options"`
+	MyVotes    []int64      `json:"my_votes"` // option IDs the viewer picked
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type PollOption struct {
+	ID     int64    `json:"id"`
+	Label  string   `json:"label"`
+	Votes  int64    `json:"votes"`
+	Voters []string `json:"voters,omitempty"` // public polls only
+}
+
+// PollResults is the per-option tally pushed to clients after a vote.
+type PollResults struct {
+	PollID     int64           `json:"poll_id"`
+	PostID     int64           `json:"post_id"`
+	TotalVotes int64           `json:"total_votes"`
+	Votes      map[int64]int64 `json:"votes"` // option ID -> votes
+}
+
+type PollModel struct {
+	DB *sql.DB
+}
+
+// NewPoll validates and normalizes a poll before it is created.
+func NewPoll(question string, options []string, multiple, anonymous bool, closesAt *time.Time) (*Poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" || utf8.RuneCountInString(question) > MaxPollQuestionLen {
+		return nil, ErrInvalidPoll
+	}
+
+	p := &Poll{Question: question, Multiple: multiple, Anonymous: anonymous}
+	seen := map[string]bool{}
+	for _, label := range options {
+		label = strings.TrimSpace(label)
+		if label == "" || utf8.RuneCountInString(label) > MaxPollOptionLen || seen[strings.ToLower(label)] {
+			return nil, ErrInvalidPoll
+		}
+		seen[strings.ToLower(label)] = true
+		p.Options = append(p.Options, PollOption{Label: label})
+	}
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return nil, ErrInvalidPoll
+	}
+
+	if closesAt != nil {
+		if !closesAt.After(time.Now()) {
+			return nil, ErrInvalidPoll
+		}
+		t := closesAt.UTC()
+		p.ClosesAt = &t
+	}
+	return p, nil
+}
+
+// Create stores p (built by NewPoll) for postID and fills in the IDs.
+func (m *PollModel) Create(ctx context.Context, postID int64, p *Poll) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	if err := insertPoll(ctx, tx, postID, p); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertPoll stores p and its options inside tx and fills in their IDs.
+func insertPoll(ctx context.Context, tx *sql.Tx, postID int64, p *Poll) error {
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO polls (post_id, question, multiple, anonymous, closes_at) VALUES (?, ?, ?, ?, ?)`,
+		postID, p.Question, p.Multiple, p.Anonymous, sqliteTime(p.ClosesAt),
+	)
+	if err != nil {
+		return err
+	}
+	if p.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+	p.PostID = postID
+
+	for i := range p.Options {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO poll_options (poll_id, position, label) VALUES (?, ?, ?)`,
+			p.ID, i, p.Options[i].Label,
+		)
+		if err != nil {
+			return err
+		}
+		if p.Options[i].ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT created_at FROM polls WHERE id = ?`, p.ID).Scan(&p.CreatedAt); err != nil {
+		return err
+	}
+	p.MyVotes = []int64{}
+	return nil
+}
+
+// ForPost returns the post's poll with results for viewerID, or
+// ErrPollNotFound when the post has none.
+func (m *PollModel) ForPost(ctx context.Context, postID, viewerID int64) (*Poll, error) {
+	var p Poll
+	var closesAt sql.NullTime
+	err := m.DB.QueryRowContext(ctx, `
+		SELECT id, post_id, question, multiple, anonymous, closes_at, created_at,
+		       (SELECT COUNT(DISTINCT user_id) FROM poll_votes v WHERE v.poll_id = polls.id)
+		FROM polls
+		WHERE post_id = ?;
+	`, postID).Scan(&p.ID, &p.PostID, &p.Question, &p.Multiple, &p.Anonymous, &closesAt, &p.CreatedAt, &p.TotalVotes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPollNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if closesAt.Valid {
+		p.ClosesAt = &closesAt.Time
+		p.Closed = !closesAt.Time.After(time.Now())
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT o.id, o.label, COUNT(v.user_id)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.poll_id = ?
+		GROUP BY o.id
+		ORDER BY o.position;
+	`, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	p.Options = []PollOption{}
+	for rows.Next() {
+		var o PollOption
+		if err := rows.Scan(&o.ID, &o.Label, &o.Votes); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.Options = append(p.Options, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Voters (public polls) and the viewer's own picks come from one pass.
+	rows, err = m.DB.QueryContext(ctx, `
+		SELECT v.option_id, v.user_id, u.nickname
+		FROM poll_votes v
+		JOIN users u ON u.id = v.user_id
+		WHERE v.poll_id = ?
+		ORDER BY v.created_at, u.nickname;
+	`, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int, len(p.Options))
+	for i, o := range p.Options {
+		index[o.ID] = i
+	}
+	p.MyVotes = []int64{}
+	for rows.Next() {
+		var optionID, userID int64
+		var nick string
+		if err := rows.Scan(&optionID, &userID, &nick); err != nil {
+			return nil, err
+		}
+		if viewerID > 0 && userID == viewerID {
+			p.MyVotes = append(p.MyVotes, optionID)
+		}
+		if !p.Anonymous {
+			i := index[optionID]
+			p.Options[i].Voters = append(p.Options[i].Voters, nick)
+		}
+	}
+	return &p, rows.Err()
+}
+
+// Vote records userID's ballot on the post's poll. Single-choice polls take
+// exactly one option; every user votes once (ErrAlreadyVoted afterwards).
+func (m *PollModel) Vote(ctx context.Context, postID, userID int64, optionIDs []int64) (*PollResults, error) {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var pollID int64
+	var multiple bool
+	var closesAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, multiple, closes_at FROM polls WHERE post_id = ?`, postID,
+	).Scan(&pollID, &multiple, &closesAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPollNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if closesAt.Valid && !closesAt.Time.After(time.Now()) {
+		return nil, ErrPollClosed
+	}
+
+	if len(optionIDs) == 0 || (!multiple && len(optionIDs) != 1) {
+		return nil, ErrInvalidChoices
+	}
+	picked := map[int64]bool{}
+	for _, id := range optionIDs {
+		if picked[id] {
+			return nil, ErrInvalidChoices
+		}
+		picked[id] = true
+	}
+
+	in, args := inClause(optionIDs)
+	var valid int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM poll_options WHERE poll_id = ? AND id IN (`+in+`)`,
+		append([]any{pollID}, args...)...,
+	).Scan(&valid); err != nil {
+		return nil, err
+	}
+	if valid != len(optionIDs) {
+		return nil, ErrInvalidChoices
+	}
+
+	var voted bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM poll_votes WHERE poll_id = ? AND user_id = ?)`, pollID, userID,
+	).Scan(&voted); err != nil {
+		return nil, err
+	}
+	if voted {
+		return nil, ErrAlreadyVoted
+	}
+
+	for _, id := range optionIDs {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO poll_votes (poll_id, option_id, user_id) VALUES (?, ?, ?)`, pollID, id, userID,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := pollResults(ctx, tx, pollID, postID)
+	if err != nil {
+		return nil, err
+	}
+	return results, tx.Commit()
+}
+
+func pollResults(ctx context.Context, tx *sql.Tx, pollID, postID int64) (*PollResults, error) {
+	r := &PollResults{PollID: pollID, PostID: postID, Votes: map[int64]int64{}}
+
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT user_id) FROM poll_votes WHERE poll_id = ?`, pollID,
+	).Scan(&r.TotalVotes); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT o.id, COUNT(v.user_id)
+		FROM poll_options o
+		LEFT JOIN poll_votes v ON v.option_id = o.id
+		WHERE o.poll_id = ?
+		GROUP BY o.id;
+	`, pollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, n int64
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		r.Votes[id] = n
+	}
+	return r, rows.Err()
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPollVoting(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "author")
+	alice := newTestUser(t, db, "alice")
+	bob := newTestUser(t, db, "bob")
+
+	posts := &PostModel{DB: db}
+	polls := &PollModel{DB: db}
+
+	if _, err := NewPoll("Tabs?", []string{"yes", "Yes "}, false, true, nil); !errors.Is(err, ErrInvalidPoll) {
+		t.Fatalf("duplicate options: err = %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if _, err := NewPoll("Tabs?", []string{"yes", "no"}, false, true, &past); !errors.Is(err, ErrInvalidPoll) {
+		t.Fatalf("past close time: err = %v", err)
+	}
+
+	post := &Post{UserID: author.ID, Title: "Indentation", Content: "vote", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	poll, err := NewPoll("Tabs or spaces?", []string{"tabs", "spaces", "both"}, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := polls.Create(ctx, post.ID, poll); err != nil {
+		t.Fatal(err)
+	}
+	tabs, spaces := poll.Options[0].ID, poll.Options[1].ID
+
+	// Single choice takes exactly one known option.
+	if _, err := polls.Vote(ctx, post.ID, alice.ID, []int64{tabs, spaces}); !errors.Is(err, ErrInvalidChoices) {
+		t.Fatalf("two choices on single poll: err = %v", err)
+	}
+	if _, err := polls.Vote(ctx, post.ID, alice.ID, []int64{tabs + 100}); !errors.Is(err, ErrInvalidChoices) {
+		t.Fatalf("unknown option: err = %v", err)
+	}
+
+	res, err := polls.Vote(ctx, post.ID, alice.ID, []int64{tabs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalVotes != 1 || res.Votes[tabs] != 1 || res.Votes[spaces] != 0 {
+		t.Fatalf("results = %+v", res)
+	}
+	if _, err := polls.Vote(ctx, post.ID, alice.ID, []int64{spaces}); !errors.Is(err, ErrAlreadyVoted) {
+		t.Fatalf("second vote: err = %v", err)
+	}
+	if _, err := polls.Vote(ctx, post.ID, bob.ID, []int64{tabs}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := polls.ForPost(ctx, post.ID, bob.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TotalVotes != 2 || got.Options[0].Votes != 2 || len(got.Options[0].Voters) != 2 {
+		t.Fatalf("poll = %+v", got)
+	}
+	if len(got.MyVotes) != 1 || got.MyVotes[0] != tabs {
+		t.Fatalf("my votes = %v", got.MyVotes)
+	}
+
+	if _, err := polls.ForPost(ctx, post.ID+1, bob.ID); !errors.Is(err, ErrPollNotFound) {
+		t.Fatalf("post without poll: err = %v", err)
+	}
+}
+
+func TestMultipleChoiceAndClosedPolls(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "author")
+	alice := newTestUser(t, db, "alice")
+
+	posts := &PostModel{DB: db}
+	polls := &PollModel{DB: db}
+
+	post := &Post{UserID: author.ID, Title: "Languages", Content: "pick any", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	closes := time.Now().Add(time.Hour)
+	poll, err := NewPoll("Which do you use?", []string{"Go", "Rust", "Zig"}, true, true, &closes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := polls.Create(ctx, post.ID, poll); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int64{poll.Options[0].ID, poll.Options[2].ID}
+	if _, err := polls.Vote(ctx, post.ID, alice.ID, []int64{ids[0], ids[0]}); !errors.Is(err, ErrInvalidChoices) {
+		t.Fatalf("duplicate choice: err = %v", err)
+	}
+	res, err := polls.Vote(ctx, post.ID, alice.ID, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalVotes != 1 || res.Votes[ids[0]] != 1 || res.Votes[ids[1]] != 1 {
+		t.Fatalf("results = %+v", res)
+	}
+
+	got, err := polls.ForPost(ctx, post.ID, author.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Options[0].Voters != nil || len(got.MyVotes) != 0 || got.Closed {
+		t.Fatalf("anonymous poll leaked voters or state: %+v", got)
+	}
+
+	if _, err := db.Exec(`UPDATE polls SET closes_at = datetime('now', '-1 minute')`); err != nil {
+		t.Fatal(err)
+	}
+	bob := newTestUser(t, db, "bob")
+	if _, err := polls.Vote(ctx, post.ID, bob.ID, ids[:1]); !errors.Is(err, ErrPollClosed) {
+		t.Fatalf("vote on closed poll: err = %v", err)
+	}
+	if got, err = polls.ForPost(ctx, post.ID, bob.ID); err != nil || !got.Closed {
+		t.Fatalf("closed = %v, err = %v", got.Closed, err)
+	}
+}
+
+func TestCreatePostWithPollIsAtomic(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+
+	poll, err := NewPoll("Tabs or spaces?", []string{"tabs", "spaces"}, false, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TRIGGER polls_fail BEFORE INSERT ON polls BEGIN SELECT RAISE(ABORT, 'boom'); END;`); err != nil {
+		t.Fatal(err)
+	}
+
+	post := &Post{UserID: author.ID, Title: "Indentation", Content: "vote", Category: "General", Tags: []string{"style"}, Poll: poll}
+	if err := posts.Create(ctx, post); err == nil {
+		t.Fatal("Create succeeded although the poll could not be stored")
+	}
+	var n int
+	if err := db.QueryRow(`SELECT (SELECT COUNT(*) FROM posts) + (SELECT COUNT(*) FROM post_tags) + (SELECT COUNT(*) FROM post_revisions)`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Fatalf("%d rows left behind by the failed create", n)
+	}
+
+	if _, err := db.Exec(`DROP TRIGGER polls_fail`); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	if post.ID == 0 || poll.PostID != post.ID || poll.Options[1].ID == 0 {
+		t.Fatalf("post %d, poll %+v", post.ID, poll)
+	}
+}
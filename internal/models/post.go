@@ -7,27 +7,82 @@ import (
 	"errors"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"real-time-forum/internal/markdown"
 )
 
+// MaxContentLen caps post, comment and draft bodies, in characters, so
+// rendering and storing them stays cheap.
+const MaxContentLen = 20000
+
+// ErrContentTooLong is returned for content over MaxContentLen.
+var ErrContentTooLong = errors.New("content is too long")
+
+func contentTooLong(content string) bool {
+	return utf8.RuneCountInString(content) > MaxContentLen
+}
+
 // Post represents a forum post created by a user.
 type Post struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	Category  string    `json:"category"`
-	CreatedAt time.Time `json:"created_at"`
-	Author    string    `json:"author"` // resolved from joined users table
-
-	// Reactions (like for now)
-	ReactionsCount int64 `json:"reactions_count"`
-	IReacted       bool  `json:"i_reacted"`
-	ViewsCount     int64 `json:"views_count"`
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`      // Markdown source, editable
+	ContentHTML string    `json:"content_html"` // rendered + sanitized, see package markdown
+	Category    string    `json:"category"`
+	CreatedAt   time.Time `json:"created_at"`
+	Author      string    `json:"author"` // resolved from joined users table
+
+	// EditedAt is when the title, content or category last changed; nil
+	// for posts never edited. The earlier versions are in post_revisions.
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+
+	// Status is "published" for every post other users can see. Drafts and
+	// scheduled posts are visible to their author only (see drafts.go).
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+
+	// QA is set for questions: posts in a category in Q&A mode. The author
+	// may accept one root comment as the answer (see qa.go).
+	QA                bool   `json:"qa"`
+	AcceptedCommentID *int64 `json:"accepted_comment_id,omitempty"`
+
+	// Moderation flags (see moderation.go).
+	Pinned   bool   `json:"pinned"`
+	PinScope string `json:"pin_scope,omitempty"` // "global" or "category"
+	Locked   bool   `json:"locked"`
+
+	// Reactions: total across the allowed types, plus per-type detail
+	// (filled by ReactionModel.Attach, which also settles IReacted).
+	ReactionsCount int64            `json:"reactions_count"`
+	IReacted       bool             `json:"i_reacted"`
+	IBookmarked    bool             `json:"i_bookmarked"`
+	Reactions      map[string]int64 `json:"reactions,omitempty"`
+	MyReactions    []string         `json:"my_reactions,omitempty"`
+	ViewsCount     int64            `json:"views_count"`
+	CommentsCount  int64            `json:"comments_count"`
+
+	// LastActivityAt is the newest of created_at and the latest comment.
+	LastActivityAt time.Time `json:"last_activity_at"`
+
+	// Images uploaded to the post (filled by AttachmentModel.Attach).
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Tags in alphabetical order (filled by TagModel.Attach).
+	Tags []string `json:"tags,omitempty"`
+
+	// Poll with results, on the post detail only (see PollModel.ForPost).
+	Poll *Poll `json:"poll,omitempty"`
 }
 
 // PostModel provides database operations for posts.
 type PostModel struct {
 	DB *sql.DB
+
+	// EditWindow is how long after publishing the author may still edit a
+	// post; zero means no limit. Moderators are not bound by it.
+	EditWindow time.Duration
 }
 
 // Get returns a post by ID, with the author's nickname.
@@ -38,28 +93,64 @@ func (m *PostModel) Get(ctx context.Context, id int64) (*Post, error) {
 			p.user_id,
 			p.title,
 			p.content,
+			COALESCE(p.content_html, ''),
 			p.category,
 			p.created_at,
-			u.nickname AS author
+			u.nickname AS author,
+			p.status,
+			p.publish_at,
+			COALESCE(p.pin_scope, ''),
+			p.locked,
+			p.comments_count,
+			p.last_activity_at,
+			p.edited_at,
+			` + qaCond + `,
+			p.accepted_comment_id
 		FROM posts p
 		JOIN users u ON u.id = p.user_id
 		WHERE p.id = ?;
 	`
 
 	var p Post
+	var publishAt, lastActivity, editedAt sql.NullTime
+	var accepted sql.NullInt64
 
 	err := m.DB.QueryRowContext(ctx, query, id).Scan(
 		&p.ID,
 		&p.UserID,
 		&p.Title,
 		&p.Content,
+		&p.ContentHTML,
 		&p.Category,
 		&p.CreatedAt,
 		&p.Author,
+		&p.Status,
+		&publishAt,
+		&p.PinScope,
+		&p.Locked,
+		&p.CommentsCount,
+		&lastActivity,
+		&editedAt,
+		&p.QA,
+		&accepted,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if accepted.Valid {
+		p.AcceptedCommentID = &accepted.Int64
+	}
+	if publishAt.Valid {
+		p.PublishAt = &publishAt.Time
+	}
+	if editedAt.Valid {
+		p.EditedAt = &editedAt.Time
+	}
+	p.Pinned = p.PinScope != ""
+	p.LastActivityAt = p.CreatedAt
+	if lastActivity.Valid {
+		p.LastActivityAt = lastActivity.Time
+	}
 
 	return &p, nil
 }
@@ -68,10 +159,11 @@ func (m *PostModel) Get(ctx context.Context, id int64) (*Post, error) {
 // The returned list size is limited by the provided limit value.
 func (m *PostModel) List(ctx context.Context, limit int) ([]Post, error) {
 	query := `
-	SELECT p.id, p.user_id, p.title, p.content, p.category, p.created_at,
-	       u.nickname as author
+	SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.content_html, ''), p.category, p.created_at,
+	       u.nickname as author, p.comments_count, p.last_activity_at
 	FROM posts p
 	JOIN users u ON u.id = p.user_id
+	WHERE ` + publishedOnly + `
 	ORDER BY p.created_at DESC
 	LIMIT ?`
 
@@ -83,7 +175,8 @@ func (m *PostModel) List(ctx context.Context, limit int) ([]Post, error) {
 
 	var posts []Post
 	for rows.Next() {
-		var p Post
+		p := Post{Status: PostStatusPublished}
+		var lastActivity sql.NullTime
 
 		// Map row data into the Post struct.
 		if err := rows.Scan(
@@ -91,12 +184,19 @@ func (m *PostModel) List(ctx context.Context, limit int) ([]Post, error) {
 			&p.UserID,
 			&p.Title,
 			&p.Content,
+			&p.ContentHTML,
 			&p.Category,
 			&p.CreatedAt,
 			&p.Author,
+			&p.CommentsCount,
+			&lastActivity,
 		); err != nil {
 			return nil, err
 		}
+		p.LastActivityAt = p.CreatedAt
+		if lastActivity.Valid {
+			p.LastActivityAt = lastActivity.Time
+		}
 
 		posts = append(posts, p)
 	}
@@ -105,14 +205,41 @@ func (m *PostModel) List(ctx context.Context, limit int) ([]Post, error) {
 	return posts, rows.Err()
 }
 
-// Create inserts a new post for the given user into the database.
+// Create inserts a new post for the given user into the database, along
+// with p.Tags and p.Poll when set, in one transaction: a post is never
+// left behind without the tags or poll it was created with.
+// An empty Status means published; scheduled posts need PublishAt.
 func (m *PostModel) Create(ctx context.Context, p *Post) error {
+	if p.Status == "" {
+		p.Status = PostStatusPublished
+	}
+	if err := validateStatus(p.Status, p.PublishAt); err != nil {
+		return err
+	}
+	if p.Status != PostStatusScheduled {
+		p.PublishAt = nil
+	}
+	if contentTooLong(p.Content) {
+		return ErrContentTooLong
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
 	query := `
-		INSERT INTO posts (user_id, title, content, category)
-		VALUES (?, ?, ?, ?)`
+		INSERT INTO posts (user_id, title, content, content_html, category, status, publish_at, last_activity_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
 
-	res, err := m.DB.ExecContext(ctx, query,
-		p.UserID, p.Title, p.Content, p.Category,
+	contentHTML := markdown.Render(p.Content)
+
+	res, err := tx.ExecContext(ctx, query,
+		p.UserID, p.Title, p.Content, contentHTML, p.Category, p.Status, sqliteTime(p.PublishAt),
 	)
 	if err != nil {
 		return err
@@ -123,256 +250,246 @@ func (m *PostModel) Create(ctx context.Context, p *Post) error {
 		return err
 	}
 
-	p.ID = id
-
 	// Load the created_at value from the database so the struct is complete.
-	row := m.DB.QueryRowContext(ctx,
-		`SELECT created_at FROM posts WHERE id = ?`, p.ID,
-	)
+	var createdAt time.Time
+	if err := tx.QueryRowContext(ctx,
+		`SELECT created_at FROM posts WHERE id = ?`, id,
+	).Scan(&createdAt); err != nil {
+		return err
+	}
 
-	if err := row.Scan(&p.CreatedAt); err != nil {
+	// Revision 1 keeps the original text for the edit history
+	// (drafts get theirs when they are published).
+	if err := snapshotRevision(ctx, tx, id, p.UserID, nil); err != nil {
 		return err
 	}
+	if err := initScore(ctx, tx, id); err != nil {
+		return err
+	}
+	if len(p.Tags) > 0 {
+		if err := setTags(ctx, tx, id, p.Tags); err != nil {
+			return err
+		}
+	}
+	if p.Poll != nil {
+		if err := insertPoll(ctx, tx, id, p.Poll); err != nil {
+			return err
+		}
+	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	p.ID, p.ContentHTML, p.CreatedAt = id, contentHTML, createdAt
 	return nil
 }
 
-// GetWithReactions returns a post by ID, with author + reactions info for viewer.
-func (m *PostModel) GetWithReactions(ctx context.Context, id int64, viewerID int64) (*Post, error) {
-	const query = `
+// postWithReactionsColumns is the projection shared by every feed/detail query.
+// It takes the viewer ID three times (see viewerArgs).
+const postWithReactionsColumns = `
     SELECT
       p.id,
       p.user_id,
       p.title,
       p.content,
+      COALESCE(p.content_html, ''),
       p.category,
       p.created_at,
       u.nickname AS author,
-	  p.views_count,
-
-      -- total likes
-      (SELECT COUNT(*) FROM post_reactions r
-        WHERE r.post_id = p.id AND r.reaction = 'like'
-      ) AS reactions_count,
-
-      -- did viewer like it?
+      p.status,
+      p.publish_at,
+      COALESCE(p.pin_scope, ''),
+      p.locked,
+      p.views_count,
+      p.comments_count,
+      p.last_activity_at,
+      p.edited_at,
+      ` + qaCond + ` AS qa,
+      p.accepted_comment_id,
+      p.reactions_count,
+
+      -- did viewer react at all? (primary key lookup)
       CASE
         WHEN ? <= 0 THEN 0
         ELSE EXISTS(
           SELECT 1 FROM post_reactions r2
-          WHERE r2.post_id = p.id AND r2.user_id = ? AND r2.reaction = 'like'
+          WHERE r2.post_id = p.id AND r2.user_id = ?
         )
-      END AS i_reacted
+      END AS i_reacted,
+
+      -- did viewer bookmark it? (no user has ID <= 0)
+      EXISTS(
+        SELECT 1 FROM bookmarks b
+        WHERE b.post_id = p.id AND b.user_id = ?
+      ) AS i_bookmarked`
+
+// viewerArgs are the leading query arguments of postWithReactionsColumns.
+func viewerArgs(viewerID int64) []any {
+	return []any{viewerID, viewerID, viewerID}
+}
+
+// postWithReactionsFrom completes postWithReactionsColumns; callers append
+// WHERE/ORDER BY.
+const postWithReactionsFrom = `
     FROM posts p
-    JOIN users u ON u.id = p.user_id
-    WHERE p.id = ?;
-  `
+    JOIN users u ON u.id = p.user_id`
+
+const postWithReactionsSelect = postWithReactionsColumns + postWithReactionsFrom
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+// scanPostWithReactions maps a row produced by postWithReactionsSelect.
+// extra receives any columns the caller appended after the projection.
+func scanPostWithReactions(sc rowScanner, extra ...any) (Post, error) {
 	var p Post
 	var iReactedInt int // SQLite returns 0/1
+	var publishAt, lastActivity, editedAt sql.NullTime
+	var accepted sql.NullInt64
 
-	err := m.DB.QueryRowContext(ctx, query, viewerID, viewerID, id).Scan(
+	dest := []any{
 		&p.ID,
 		&p.UserID,
 		&p.Title,
 		&p.Content,
+		&p.ContentHTML,
 		&p.Category,
 		&p.CreatedAt,
 		&p.Author,
+		&p.Status,
+		&publishAt,
+		&p.PinScope,
+		&p.Locked,
 		&p.ViewsCount,
+		&p.CommentsCount,
+		&lastActivity,
+		&editedAt,
+		&p.QA,
+		&accepted,
 		&p.ReactionsCount,
 		&iReactedInt,
-	)
-	if err != nil {
-		return nil, err
+		&p.IBookmarked,
 	}
-
+	err := sc.Scan(append(dest, extra...)...)
 	p.IReacted = iReactedInt == 1
-	return &p, nil
-}
-
-// ListWithReactions returns posts with author + reactions info for viewer.
-func (m *PostModel) ListWithReactions(ctx context.Context, limit int, viewerID int64) ([]Post, error) {
-	const query = `
-    SELECT
-      p.id,
-      p.user_id,
-      p.title,
-      p.content,
-      p.category,
-      p.created_at,
-      u.nickname AS author,
-	  p.views_count,
-
-      (SELECT COUNT(*) FROM post_reactions r
-        WHERE r.post_id = p.id AND r.reaction = 'like'
-      ) AS reactions_count,
-
-      CASE
-        WHEN ? <= 0 THEN 0
-        ELSE EXISTS(
-          SELECT 1 FROM post_reactions r2
-          WHERE r2.post_id = p.id AND r2.user_id = ? AND r2.reaction = 'like'
-        )
-      END AS i_reacted
-    FROM posts p
-    JOIN users u ON u.id = p.user_id
-    ORDER BY p.created_at DESC
-    LIMIT ?;
-  `
-
-	rows, err := m.DB.QueryContext(ctx, query, viewerID, viewerID, limit)
-	if err != nil {
-		return nil, err
+	p.Pinned = p.PinScope != ""
+	if publishAt.Valid {
+		p.PublishAt = &publishAt.Time
 	}
-	defer rows.Close()
-
-	posts := []Post{}
-	for rows.Next() {
-		var p Post
-		var iReactedInt int
-
-		if err := rows.Scan(
-			&p.ID,
-			&p.UserID,
-			&p.Title,
-			&p.Content,
-			&p.Category,
-			&p.CreatedAt,
-			&p.Author,
-			&p.ViewsCount,
-			&p.ReactionsCount,
-			&iReactedInt,
-		); err != nil {
-			return nil, err
-		}
-
-		p.IReacted = iReactedInt == 1
-		posts = append(posts, p)
+	if editedAt.Valid {
+		p.EditedAt = &editedAt.Time
 	}
-
-	return posts, rows.Err()
-}
-
-// ListWithReactionsPage returns posts paginated with author + reactions info for viewer.
-// Uses LIMIT/OFFSET and also returns hasMore.
-func (m *PostModel) ListWithReactionsPage(ctx context.Context, limit, offset int64, viewerID int64) ([]Post, bool, error) {
-	// Pedimos 1 extra para saber si hay más
-	fetch := limit + 1
-
-	const query = `
-    SELECT
-      p.id,
-      p.user_id,
-      p.title,
-      p.content,
-      p.category,
-      p.created_at,
-      u.nickname AS author,
-	  p.views_count,
-
-      (SELECT COUNT(*) FROM post_reactions r
-        WHERE r.post_id = p.id AND r.reaction = 'like'
-      ) AS reactions_count,
-
-      CASE
-        WHEN ? <= 0 THEN 0
-        ELSE EXISTS(
-          SELECT 1 FROM post_reactions r2
-          WHERE r2.post_id = p.id AND r2.user_id = ? AND r2.reaction = 'like'
-        )
-      END AS i_reacted
-    FROM posts p
-    JOIN users u ON u.id = p.user_id
-    ORDER BY p.created_at DESC
-    LIMIT ? OFFSET ?;
-  `
-
-	rows, err := m.DB.QueryContext(ctx, query, viewerID, viewerID, fetch, offset)
-	if err != nil {
-		return nil, false, err
+	if accepted.Valid {
+		p.AcceptedCommentID = &accepted.Int64
 	}
-	defer rows.Close()
-
-	posts := []Post{}
-	for rows.Next() {
-		var p Post
-		var iReactedInt int
-
-		if err := rows.Scan(
-			&p.ID,
-			&p.UserID,
-			&p.Title,
-			&p.Content,
-			&p.Category,
-			&p.CreatedAt,
-			&p.Author,
-			&p.ViewsCount,
-			&p.ReactionsCount,
-			&iReactedInt,
-		); err != nil {
-			return nil, false, err
-		}
-
-		p.IReacted = iReactedInt == 1
-		posts = append(posts, p)
+	p.LastActivityAt = p.CreatedAt
+	if lastActivity.Valid {
+		p.LastActivityAt = lastActivity.Time
 	}
+	return p, err
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, false, err
-	}
+// GetWithReactions returns a post by ID, with author + reactions info for viewer.
+func (m *PostModel) GetWithReactions(ctx context.Context, id int64, viewerID int64) (*Post, error) {
+	query := postWithReactionsSelect + `
+    WHERE p.id = ?;`
 
-	hasMore := int64(len(posts)) > limit
-	if hasMore {
-		posts = posts[:limit] // cut -> extra
+	p, err := scanPostWithReactions(m.DB.QueryRowContext(ctx, query, append(viewerArgs(viewerID), id)...))
+	if err != nil {
+		return nil, err
 	}
+	return &p, nil
+}
 
-	return posts, hasMore, nil
+// ListWithReactions returns posts with author + reactions info for viewer.
+func (m *PostModel) ListWithReactions(ctx context.Context, limit int, viewerID int64) ([]Post, error) {
+	posts, _, err := m.ListWithReactionsPage(ctx, FeedQuery{Limit: int64(limit)}, viewerID)
+	return posts, err
 }
+
+// RegisterView records that viewerID opened the post and returns its view
+// count. Each user counts once; anonymous viewers only read the count.
 func (m *PostModel) RegisterView(ctx context.Context, postID, viewerID int64) (int64, error) {
-	// If there is no user (not logged in), we do not count (to maintain ‘1 per user’)..
+	var count int64
 	if viewerID <= 0 {
-		var count int64
 		err := m.DB.QueryRowContext(ctx, `SELECT views_count FROM posts WHERE id=?`, postID).Scan(&count)
 		return count, err
 	}
 
-	// 1) Single insert (if it already existed, does nothing)
-	res, err := m.DB.ExecContext(ctx,
-		`INSERT OR IGNORE INTO post_views(post_id, user_id) VALUES(?, ?)`,
-		postID, viewerID,
-	)
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
 
-	rows, _ := res.RowsAffected()
-
-	// 2) If inserted (first time), increase counter
-	if rows > 0 {
-		_, err = m.DB.ExecContext(ctx,
-			`UPDATE posts SET views_count = views_count + 1 WHERE id=?`,
-			postID,
-		)
-		if err != nil {
-			return 0, err
-		}
+	// The post_views_count_ai trigger bumps views_count on a first view.
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR IGNORE INTO post_views(post_id, user_id) VALUES(?, ?)`,
+		postID, viewerID,
+	); err != nil {
+		return 0, err
 	}
-
-	// 3) Returns current count
-	var count int64
-	err = m.DB.QueryRowContext(ctx, `SELECT views_count FROM posts WHERE id=?`, postID).Scan(&count)
-	return count, err
+	if err := tx.QueryRowContext(ctx, `SELECT views_count FROM posts WHERE id=?`, postID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
 }
+
 func (m *PostModel) CountViews(ctx context.Context, postID int64) (int64, error) {
 	var n int64
 	err := m.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM post_views WHERE post_id=?`, postID).Scan(&n)
 	return n, err
 }
 
-// UpdateByOwner updates ONLY provided fields, and ONLY if owner matches.
-// Returns sql.ErrNoRows if not found or not owner.
+// UpdateByOwner updates ONLY provided fields, and ONLY if owner matches,
+// and records the result in post_revisions.
+// Returns sql.ErrNoRows if not found or not owner, and ErrEditWindowClosed
+// once a published post is older than EditWindow.
 func (m *PostModel) UpdateByOwner(ctx context.Context, postID, ownerID int64, title, content, category *string) error {
+	return m.update(ctx, postID, ownerID, true, title, content, category)
+}
+
+// UpdateAsModerator is UpdateByOwner for a moderator editing someone
+// else's post: no owner check and no edit window. Permission checks are
+// the caller's job. Returns sql.ErrNoRows if the post does not exist.
+func (m *PostModel) UpdateAsModerator(ctx context.Context, postID, moderatorID int64, title, content, category *string) error {
+	return m.update(ctx, postID, moderatorID, false, title, content, category)
+}
+
+// postEditWindowOpen is the SQL condition for an author still being able to
+// edit a post; it takes editCutoff twice. Drafts and scheduled posts stay
+// editable until they go out.
+const postEditWindowOpen = `(status != 'published' OR ? = '' OR datetime(created_at) >= datetime('now', ?))`
+
+// CanEdit applies UpdateByOwner's rule without changing anything, for edits
+// that leave the post row alone (its tags). It returns nil when ownerID may
+// edit the post now, sql.ErrNoRows if it is missing or not theirs, and
+// ErrEditWindowClosed once the window has passed.
+func (m *PostModel) CanEdit(ctx context.Context, postID, ownerID int64) error {
+	cutoff := editCutoff(m.EditWindow)
+	var owner int64
+	var open bool
+	err := m.DB.QueryRowContext(ctx,
+		`SELECT user_id, `+postEditWindowOpen+` FROM posts WHERE id = ?`, cutoff, cutoff, postID,
+	).Scan(&owner, &open)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && owner != ownerID) {
+		return sql.ErrNoRows
+	}
+	if err != nil {
+		return err
+	}
+	if !open {
+		return ErrEditWindowClosed
+	}
+	return nil
+}
+
+func (m *PostModel) update(ctx context.Context, postID, editorID int64, byOwner bool, title, content, category *string) error {
 	setParts := []string{}
 	args := []any{}
 
@@ -390,8 +507,11 @@ func (m *PostModel) UpdateByOwner(ctx context.Context, postID, ownerID int64, ti
 		if c == "" {
 			return errors.New("content cannot be empty")
 		}
-		setParts = append(setParts, "content = ?")
-		args = append(args, c)
+		if contentTooLong(c) {
+			return ErrContentTooLong
+		}
+		setParts = append(setParts, "content = ?", "content_html = ?")
+		args = append(args, c, markdown.Render(c))
 	}
 
 	if category != nil {
@@ -403,21 +523,47 @@ func (m *PostModel) UpdateByOwner(ctx context.Context, postID, ownerID int64, ti
 		return errors.New("no fields to update")
 	}
 
-	// Optional edited marker
 	setParts = append(setParts, "edited_at = datetime('now')")
 
-	args = append(args, postID, ownerID)
+	q := `UPDATE posts SET ` + strings.Join(setParts, ", ") + ` WHERE id = ?`
+	args = append(args, postID)
+	if byOwner {
+		cutoff := editCutoff(m.EditWindow)
+		q += ` AND user_id = ? AND ` + postEditWindowOpen
+		args = append(args, editorID, cutoff, cutoff)
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
 
-	q := `UPDATE posts SET ` + strings.Join(setParts, ", ") + ` WHERE id = ? AND user_id = ?`
-	res, err := m.DB.ExecContext(ctx, q, args...)
+	res, err := tx.ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
 
 	aff, _ := res.RowsAffected()
 	if aff == 0 {
-		return sql.ErrNoRows
+		if !byOwner {
+			return sql.ErrNoRows
+		}
+		// Tell "not yours" apart from "too late".
+		var owner int64
+		if err := tx.QueryRowContext(ctx, `SELECT user_id FROM posts WHERE id = ?`, postID).Scan(&owner); err != nil || owner != editorID {
+			return sql.ErrNoRows
+		}
+		return ErrEditWindowClosed
 	}
 
-	return nil
+	// Every edit becomes a revision, with whoever made it as editor.
+	if err := snapshotRevision(ctx, tx, postID, editorID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
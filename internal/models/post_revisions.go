@@ -0,0 +1,153 @@
+// internal/models/post_revisions.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"real-time-forum/internal/markdown"
+)
+
+// ErrRevisionNotFound is returned when a post has no revision with that number.
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// PostRevision is a snapshot of a post after one edit. Revision 1 is the
+// post as originally published.
+type PostRevision struct {
+	ID           int64     `json:"id"`
+	PostID       int64     `json:"post_id"`
+	Revision     int64     `json:"revision"`
+	EditorID     int64     `json:"editor_id"`
+	Editor       string    `json:"editor"` // resolved from joined users table
+	Title        string    `json:"title"`
+	Content      string    `json:"content"`
+	Category     string    `json:"category"`
+	RevertedFrom *int64    `json:"reverted_from,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// snapshotRevision records the current state of a post as its next revision.
+// Unpublished posts have no history yet, so nothing is recorded for them.
+func snapshotRevision(ctx context.Context, db execer, postID, editorID int64, revertedFrom *int64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO post_revisions (post_id, revision, editor_id, title, content, category, reverted_from)
+		SELECT
+			p.id,
+			COALESCE((SELECT MAX(revision) FROM post_revisions WHERE post_id = p.id), 0) + 1,
+			?,
+			p.title, p.content, p.category,
+			?
+		FROM posts p
+		WHERE p.id = ? AND `+publishedOnly+`;
+	`, editorID, revertedFrom, postID)
+	return err
+}
+
+// ListRevisions returns every revision of a post, oldest first.
+func (m *PostModel) ListRevisions(ctx context.Context, postID int64) ([]PostRevision, error) {
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT r.id, r.post_id, r.revision, r.editor_id, u.nickname,
+		       r.title, r.content, r.category, r.reverted_from, r.created_at
+		FROM post_revisions r
+		JOIN users u ON u.id = r.editor_id
+		WHERE r.post_id = ?
+		ORDER BY r.revision ASC;
+	`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revs := []PostRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, rev)
+	}
+	return revs, rows.Err()
+}
+
+// GetRevision returns one revision of a post.
+func (m *PostModel) GetRevision(ctx context.Context, postID, revision int64) (*PostRevision, error) {
+	rev, err := scanRevision(m.DB.QueryRowContext(ctx, `
+		SELECT r.id, r.post_id, r.revision, r.editor_id, u.nickname,
+		       r.title, r.content, r.category, r.reverted_from, r.created_at
+		FROM post_revisions r
+		JOIN users u ON u.id = r.editor_id
+		WHERE r.post_id = ? AND r.revision = ?;
+	`, postID, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func scanRevision(sc rowScanner) (PostRevision, error) {
+	var rev PostRevision
+	var revertedFrom sql.NullInt64
+	err := sc.Scan(
+		&rev.ID, &rev.PostID, &rev.Revision, &rev.EditorID, &rev.Editor,
+		&rev.Title, &rev.Content, &rev.Category, &revertedFrom, &rev.CreatedAt,
+	)
+	if revertedFrom.Valid {
+		v := revertedFrom.Int64
+		rev.RevertedFrom = &v
+	}
+	return rev, err
+}
+
+// RevertToRevision restores title, content and category from an older
+// revision and records the result as a new revision by editorID.
+// Permission checks (moderators only) are the caller's job.
+func (m *PostModel) RevertToRevision(ctx context.Context, postID, revision, editorID int64) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var title, content, category string
+	err = tx.QueryRowContext(ctx,
+		`SELECT title, content, category FROM post_revisions WHERE post_id = ? AND revision = ?`,
+		postID, revision,
+	).Scan(&title, &content, &category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRevisionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE posts
+		SET title = ?,
+		    content = ?,
+		    content_html = ?,
+		    category = ?,
+		    edited_at = datetime('now')
+		WHERE id = ?;
+	`, title, content, markdown.Render(content), category, postID); err != nil {
+		return err
+	}
+
+	if err := snapshotRevision(ctx, tx, postID, editorID, &revision); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,166 @@
+// internal/models/post_scores.go
+package models
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Weights of each signal in the hot score. A comment is worth more than a
+// reaction, and a view is worth a tenth of a reaction.
+const (
+	hotReactionWeight = 1.0
+	hotCommentWeight  = 2.0
+	hotViewWeight     = 0.1
+
+	// hotGravity controls how fast posts sink as they age.
+	hotGravity = 1.5
+)
+
+// HotScore is a time-decayed popularity score: engagement divided by
+// (age in hours + 2) ^ gravity, so a fresh post with a few reactions can
+// outrank an old post with many.
+func HotScore(reactions, comments, views int64, age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	points := 1 +
+		hotReactionWeight*float64(reactions) +
+		hotCommentWeight*float64(comments) +
+		hotViewWeight*float64(views)
+	return points / math.Pow(age.Hours()+2, hotGravity)
+}
+
+// Bounds on RefreshScores. Past scoreHorizon a post's hot score has decayed
+// so far that it only moves when its counters do; scoreBatchSize posts are
+// rescored per transaction, so requests get the connection in between.
+const (
+	scoreHorizon   = 30 * 24 * time.Hour
+	scoreBatchSize = 500
+)
+
+// RefreshScores recomputes post_scores for the posts whose scores can have
+// moved: those created within scoreHorizon, which are still decaying, and
+// those marked stale because a counter changed (see the post_scores_stale_au
+// trigger). Hot scores decay with time, so this runs periodically (see
+// cmd/server) rather than on each write; feed sorting then only reads
+// indexed columns.
+func (m *PostModel) RefreshScores(ctx context.Context) error {
+	now := time.Now().UTC()
+	since := now.Add(-scoreHorizon).Format(sqliteTimeLayout)
+
+	var after int64
+	for {
+		n, last, err := m.refreshScoreBatch(ctx, now, since, after)
+		if err != nil {
+			return err
+		}
+		if n < scoreBatchSize {
+			return nil
+		}
+		after = last
+	}
+}
+
+// refreshScoreBatch rescores up to scoreBatchSize due posts with IDs above
+// after, in one transaction. It returns how many it rescored and the last
+// ID. Reading inside the transaction keeps a counter change from landing
+// between the read and the write, which would clear stale on a score
+// computed from the old counts.
+func (m *PostModel) refreshScoreBatch(ctx context.Context, now time.Time, since string, after int64) (int, int64, error) {
+	const q = `
+SELECT
+  p.id,
+  p.created_at,
+  p.views_count,
+  p.reactions_count,
+  p.comments_count
+FROM posts p
+WHERE p.id > ?
+  AND p.id IN (
+    SELECT id FROM posts WHERE created_at >= ?
+    UNION
+    SELECT post_id FROM post_scores WHERE stale = 1
+  )
+ORDER BY p.id
+LIMIT ?;
+`
+	type score struct {
+		postID              int64
+		reactions, comments int64
+		hot                 float64
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, q, after, since, scoreBatchSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var scores []score
+	for rows.Next() {
+		var s score
+		var createdAt time.Time
+		var views int64
+		if err := rows.Scan(&s.postID, &createdAt, &views, &s.reactions, &s.comments); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		s.hot = HotScore(s.reactions, s.comments, views, now.Sub(createdAt))
+		scores = append(scores, s)
+	}
+	// The transaction has the only connection (see db.Open), and the
+	// upserts below cannot run on it while the cursor is open.
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(scores) == 0 {
+		return 0, after, nil
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+INSERT INTO post_scores (post_id, reactions, comments, hot, computed_at, stale)
+VALUES (?, ?, ?, ?, ?, 0)
+ON CONFLICT(post_id) DO UPDATE SET
+  reactions = excluded.reactions,
+  comments = excluded.comments,
+  hot = excluded.hot,
+  computed_at = excluded.computed_at,
+  stale = 0;
+`)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer stmt.Close()
+
+	for _, s := range scores {
+		if _, err := stmt.ExecContext(ctx, s.postID, s.reactions, s.comments, s.hot, now); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return len(scores), scores[len(scores)-1].postID, nil
+}
+
+// initScore seeds post_scores for a brand new post so it shows up in the
+// ranked feeds before the next refresh.
+func initScore(ctx context.Context, db execer, postID int64) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO post_scores (post_id, hot) VALUES (?, ?)`,
+		postID, HotScore(0, 0, 0, 0),
+	)
+	return err
+}
@@ -0,0 +1,386 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	appdb "real-time-forum/internal/db"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := appdb.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := appdb.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestUser(t *testing.T, db *sql.DB, nickname string) *User {
+	t.Helper()
+
+	u := &User{
+		Nickname:  nickname,
+		Age:       30,
+		Gender:    "other",
+		FirstName: nickname,
+		LastName:  "Tester",
+		Email:     nickname + "@example.com",
+	}
+	if err := (&UserModel{DB: db}).Create(context.Background(), u, "password"); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestListWithReactionsPageCursorIsStable(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	posts := &PostModel{DB: db}
+
+	// Five posts sharing one timestamp: only the id breaks the tie.
+	for i := 0; i < 5; i++ {
+		p := &Post{UserID: user.ID, Title: fmt.Sprintf("post %d", i), Content: "body", Category: "General"}
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE posts SET created_at = '2024-01-01 10:00:00'`); err != nil {
+		t.Fatal(err)
+	}
+
+	page1, next, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page1) != 2 || next == nil {
+		t.Fatalf("page1 len=%d next=%v, want 2 and a cursor", len(page1), next)
+	}
+
+	// A new post must not shift the next page.
+	if err := posts.Create(ctx, &Post{UserID: user.ID, Title: "late", Content: "body", Category: "General"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := DecodeFeedCursor(next.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	page2, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, After: &cursor}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int64{3, 2, 1}
+	if len(page2) != len(want) {
+		t.Fatalf("page2 len=%d, want %d", len(page2), len(want))
+	}
+	for i, p := range page2 {
+		if p.ID != want[i] {
+			t.Fatalf("page2[%d].ID = %d, want %d", i, p.ID, want[i])
+		}
+	}
+}
+
+func TestDecodeFeedCursorRejectsGarbage(t *testing.T) {
+	for _, v := range []string{"", "not-base64!", "bm9waXBl", "Ym9ndXN8MXwy"} {
+		if _, err := DecodeFeedCursor(v); err != ErrInvalidCursor {
+			t.Errorf("DecodeFeedCursor(%q) err = %v, want ErrInvalidCursor", v, err)
+		}
+	}
+}
+
+func TestListWithReactionsPageSortsByCachedScores(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "ranker")
+	posts := &PostModel{DB: db}
+
+	quiet := &Post{UserID: user.ID, Title: "quiet", Content: "body", Category: "General"}
+	busy := &Post{UserID: user.ID, Title: "busy", Content: "body", Category: "General"}
+	for _, p := range []*Post{busy, quiet} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	comments := &CommentModel{DB: db}
+	for i := 0; i < 3; i++ {
+		if err := comments.Create(ctx, &Comment{PostID: busy.ID, UserID: user.ID, Content: "reply"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := posts.RefreshScores(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sort := range []string{SortHot, SortDiscussed} {
+		got, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, Sort: sort}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0].ID != busy.ID {
+			t.Fatalf("sort=%s first post = %+v, want %d", sort, got, busy.ID)
+		}
+	}
+
+	// The newest post still comes first without a sort.
+	got, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].ID != quiet.ID {
+		t.Fatalf("sort=new first post = %d, want %d", got[0].ID, quiet.ID)
+	}
+}
+
+func TestListWithReactionsPageSortsByLastActivity(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "replier")
+	posts := &PostModel{DB: db}
+
+	old := &Post{UserID: user.ID, Title: "old", Content: "body", Category: "General"}
+	fresh := &Post{UserID: user.ID, Title: "fresh", Content: "body", Category: "General"}
+	for _, p := range []*Post{old, fresh} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Backdate both threads so the reply below is unambiguously newer.
+	if _, err := db.Exec(`UPDATE posts SET created_at = datetime('now', '-1 hour'), last_activity_at = datetime('now', '-1 hour')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&CommentModel{DB: db}).Create(ctx, &Comment{PostID: old.ID, UserID: user.ID, Content: "bump"}); err != nil {
+		t.Fatal(err)
+	}
+
+	q := FeedQuery{Limit: 1, Sort: SortActive}
+	first, next, err := posts.ListWithReactionsPage(ctx, q, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].ID != old.ID {
+		t.Fatalf("first page = %+v, want post %d", first, old.ID)
+	}
+	if first[0].CommentsCount != 1 {
+		t.Fatalf("comments_count = %d, want 1", first[0].CommentsCount)
+	}
+	if !first[0].LastActivityAt.After(first[0].CreatedAt) {
+		t.Fatalf("last_activity_at %v not after created_at %v", first[0].LastActivityAt, first[0].CreatedAt)
+	}
+	if next == nil {
+		t.Fatal("expected a next cursor")
+	}
+
+	cursor, err := DecodeFeedCursor(next.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.After = &cursor
+	second, _, err := posts.ListWithReactionsPage(ctx, q, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 1 || second[0].ID != fresh.ID || second[0].CommentsCount != 0 {
+		t.Fatalf("second page = %+v, want post %d with no comments", second, fresh.ID)
+	}
+}
+
+func TestPostCountersFollowSourceTables(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	author := newTestUser(t, db, "counted")
+	reader := newTestUser(t, db, "counter")
+	posts := &PostModel{DB: db}
+
+	post := &Post{UserID: author.ID, Title: "counted", Content: "body", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	reactions := &ReactionModel{DB: db}
+	for _, r := range []string{"like", "love", "like"} { // the second like removes the first
+		if _, _, err := reactions.Toggle(ctx, post.ID, reader.ID, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := (&CommentModel{DB: db}).Create(ctx, &Comment{PostID: post.ID, UserID: reader.ID, Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ { // one view per user
+		if _, err := posts.RegisterView(ctx, post.ID, reader.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(when string) {
+		t.Helper()
+		got, err := posts.GetWithReactions(ctx, post.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ReactionsCount != 1 || got.CommentsCount != 1 || got.ViewsCount != 1 {
+			t.Fatalf("%s: reactions=%d comments=%d views=%d, want 1/1/1",
+				when, got.ReactionsCount, got.CommentsCount, got.ViewsCount)
+		}
+	}
+	check("after writes")
+
+	if _, err := db.Exec(`UPDATE posts SET reactions_count = 7, comments_count = 0, views_count = 42 WHERE id = ?`, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	fixed, err := appdb.RepairCounters(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 1 {
+		t.Fatalf("RepairCounters fixed %d posts, want 1", fixed)
+	}
+	check("after repair")
+
+	// Migrations stay idempotent once the triggers exist.
+	if err := appdb.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	check("after re-migrating")
+}
+
+func TestDeletingCommentRewindsActivity(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "rewinder")
+	posts := &PostModel{DB: db}
+	comments := &CommentModel{DB: db}
+
+	post := &Post{UserID: user.ID, Title: "t", Content: "body", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	first := &Comment{PostID: post.ID, UserID: user.ID, Content: "first"}
+	last := &Comment{PostID: post.ID, UserID: user.ID, Content: "last"}
+	for _, c := range []*Comment{first, last} {
+		if err := comments.Create(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`UPDATE posts SET created_at = '2024-01-01 00:00:00' WHERE id = ?`, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE comments SET created_at = '2024-01-02 00:00:00' WHERE id = ?`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	activity := func() string {
+		t.Helper()
+		var at string
+		if err := db.QueryRow(`SELECT strftime('%Y-%m-%d %H:%M:%S', last_activity_at) FROM posts WHERE id = ?`, post.ID).Scan(&at); err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+
+	if _, err := db.Exec(`DELETE FROM comments WHERE id = ?`, last.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := activity(); got != "2024-01-02 00:00:00" {
+		t.Fatalf("after deleting the newest comment: last_activity_at = %s, want the remaining one", got)
+	}
+	if _, err := db.Exec(`DELETE FROM comments WHERE id = ?`, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := activity(); got != "2024-01-01 00:00:00" {
+		t.Fatalf("after deleting every comment: last_activity_at = %s, want created_at", got)
+	}
+
+	// RepairCounters puts a drifted last_activity_at back too.
+	if _, err := db.Exec(`UPDATE posts SET last_activity_at = '2030-01-01 00:00:00' WHERE id = ?`, post.ID); err != nil {
+		t.Fatal(err)
+	}
+	if fixed, err := appdb.RepairCounters(ctx, db); err != nil || fixed != 1 {
+		t.Fatalf("RepairCounters: fixed %d, err %v; want 1 row", fixed, err)
+	}
+	if got := activity(); got != "2024-01-01 00:00:00" {
+		t.Fatalf("after repair: last_activity_at = %s, want created_at", got)
+	}
+}
+
+func TestRefreshScoresSkipsSettledPosts(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "scorer")
+	posts := &PostModel{DB: db}
+
+	old := &Post{UserID: user.ID, Title: "old", Content: "body", Category: "General"}
+	if err := posts.Create(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE posts SET created_at = datetime('now', '-60 days') WHERE id = ?`, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.RefreshScores(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	state := func() (comments int64, stale bool) {
+		t.Helper()
+		if err := db.QueryRow(`SELECT comments, stale FROM post_scores WHERE post_id = ?`, old.ID).Scan(&comments, &stale); err != nil {
+			t.Fatal(err)
+		}
+		return comments, stale
+	}
+	if _, stale := state(); stale {
+		t.Fatal("score still stale after a refresh")
+	}
+
+	// An old post nothing happened to keeps its score...
+	if _, err := db.Exec(`UPDATE post_scores SET hot = -1 WHERE post_id = ?`, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := posts.RefreshScores(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var hot float64
+	if err := db.QueryRow(`SELECT hot FROM post_scores WHERE post_id = ?`, old.ID).Scan(&hot); err != nil {
+		t.Fatal(err)
+	}
+	if hot != -1 {
+		t.Fatalf("settled post was rescored: hot = %f", hot)
+	}
+
+	// ...until one of its counters changes.
+	if err := (&CommentModel{DB: db}).Create(ctx, &Comment{PostID: old.ID, UserID: user.ID, Content: "revived"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, stale := state(); !stale {
+		t.Fatal("a new comment did not mark the score stale")
+	}
+	if err := posts.RefreshScores(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if comments, stale := state(); comments != 1 || stale {
+		t.Fatalf("after the comment: comments = %d, stale = %v; want 1, false", comments, stale)
+	}
+}
+
+func TestHotScoreDecaysWithAge(t *testing.T) {
+	fresh := HotScore(5, 1, 10, time.Hour)
+	old := HotScore(5, 1, 10, 48*time.Hour)
+	if fresh <= old {
+		t.Fatalf("fresh score %f <= old score %f", fresh, old)
+	}
+	if HotScore(0, 1, 0, time.Hour) <= HotScore(1, 0, 0, time.Hour) {
+		t.Fatal("a comment should weigh more than a reaction")
+	}
+}
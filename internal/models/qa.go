@@ -0,0 +1,200 @@
+// internal/models/qa.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// Categories in Q&A mode turn posts into questions: root comments are
+// answers, readers vote them up or down, and the post author accepts one.
+// An accepted answer marks the question as solved.
+
+var (
+	ErrNotQA         = errors.New("post is not in a Q&A category")
+	ErrInvalidAnswer = errors.New("only a root comment on this post can be accepted")
+	ErrInvalidVote   = errors.New("vote must be 1, -1 or 0")
+	ErrInvalidSolved = errors.New("invalid solved filter")
+)
+
+// Values for FeedQuery.Solved; "" means no filter.
+const (
+	Solved   = "solved"
+	Unsolved = "unsolved"
+)
+
+// ParseSolved validates a ?solved= value.
+func ParseSolved(v string) (string, error) {
+	switch v {
+	case "", Solved, Unsolved:
+		return v, nil
+	}
+	return "", ErrInvalidSolved
+}
+
+// qaCond matches posts whose category is in Q&A mode.
+const qaCond = `EXISTS(
+        SELECT 1 FROM categories qc
+        WHERE LOWER(qc.name) = LOWER(p.category) AND qc.qa_mode = 1
+      )`
+
+// solvedConds are the feed conditions for each FeedQuery.Solved filter.
+// Unsolved only makes sense for questions, so both imply qaCond.
+var solvedConds = map[string]string{
+	Solved:   "p.accepted_comment_id IS NOT NULL AND " + qaCond,
+	Unsolved: "p.accepted_comment_id IS NULL AND " + qaCond,
+}
+
+// SetQA switches a category's Q&A mode (ErrCategoryNotFound).
+func (m *CategoryModel) SetQA(ctx context.Context, rawName string, on bool) (*Category, error) {
+	cat, err := m.GetByName(ctx, rawName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.DB.ExecContext(ctx, `UPDATE categories SET qa_mode = ? WHERE id = ?`, on, cat.ID); err != nil {
+		return nil, err
+	}
+	cat.QA = on
+	return cat, nil
+}
+
+// SetAcceptedAnswer marks commentID as the accepted answer to a question,
+// replacing any earlier one; commentID 0 clears it. Only the post author
+// may do this: sql.ErrNoRows means the post is missing or someone else's.
+func (m *PostModel) SetAcceptedAnswer(ctx context.Context, postID, ownerID, commentID int64) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var owner int64
+	var qa bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT p.user_id, `+qaCond+` FROM posts p WHERE p.id = ?`, postID,
+	).Scan(&owner, &qa); err != nil {
+		return err
+	}
+	if owner != ownerID {
+		return sql.ErrNoRows
+	}
+	if !qa {
+		return ErrNotQA
+	}
+
+	var accepted any // NULL clears
+	if commentID > 0 {
+		var ok bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM comments
+				WHERE id = ? AND post_id = ? AND parent_comment_id IS NULL AND deleted_at IS NULL
+			)`, commentID, postID,
+		).Scan(&ok); err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidAnswer
+		}
+		accepted = commentID
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE posts SET accepted_comment_id = ? WHERE id = ?`, accepted, postID,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Vote records userID's vote on a comment in a Q&A post: 1 up, -1 down,
+// 0 withdraws it. Voting again replaces the earlier vote. It returns the
+// comment's new score. sql.ErrNoRows means the comment is missing or a
+// tombstone.
+func (m *CommentModel) Vote(ctx context.Context, commentID, userID int64, value int) (int64, error) {
+	if value < -1 || value > 1 {
+		return 0, ErrInvalidVote
+	}
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	var qa bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT `+qaCond+`
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		WHERE c.id = ? AND c.deleted_at IS NULL`, commentID,
+	).Scan(&qa); err != nil {
+		return 0, err
+	}
+	if !qa {
+		return 0, ErrNotQA
+	}
+
+	// Triggers keep comments.score in step (see db/counters.go).
+	if value == 0 {
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM comment_votes WHERE comment_id = ? AND user_id = ?`, commentID, userID)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO comment_votes (comment_id, user_id, value) VALUES (?, ?, ?)
+			ON CONFLICT (comment_id, user_id) DO UPDATE SET value = excluded.value`,
+			commentID, userID, value)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var score int64
+	if err := tx.QueryRowContext(ctx, `SELECT score FROM comments WHERE id = ?`, commentID).Scan(&score); err != nil {
+		return 0, err
+	}
+	return score, tx.Commit()
+}
+
+// attachVotes fills MyVote on each comment with viewerID's vote.
+func (m *CommentModel) attachVotes(ctx context.Context, comments []*Comment, viewerID int64) error {
+	if viewerID <= 0 || len(comments) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*Comment, len(comments))
+	ids := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+	in, args := inClause(ids)
+
+	rows, err := m.DB.QueryContext(ctx,
+		`SELECT comment_id, value FROM comment_votes WHERE user_id = ? AND comment_id IN (`+in+`)`,
+		append([]any{viewerID}, args...)...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var value int
+		if err := rows.Scan(&id, &value); err != nil {
+			return err
+		}
+		if c := byID[id]; c != nil && !c.Deleted {
+			c.MyVote = value
+		}
+	}
+	return rows.Err()
+}
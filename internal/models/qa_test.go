@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestQAAnswersVotesAndSolvedFilter(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	asker := newTestUser(t, db, "asker")
+	helper := newTestUser(t, db, "helper")
+	voter := newTestUser(t, db, "voter")
+
+	posts := &PostModel{DB: db}
+	comments := &CommentModel{DB: db}
+
+	question := &Post{UserID: asker.ID, Title: "Printer?", Content: "It is on fire", Category: "Tech-support"}
+	chat := &Post{UserID: asker.ID, Title: "Hi", Content: "hello", Category: "General"}
+	for _, p := range []*Post{question, chat} {
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	answers := make([]*Comment, 3)
+	for i := range answers {
+		answers[i] = &Comment{PostID: question.ID, UserID: helper.ID, Content: fmt.Sprintf("answer %d", i)}
+		if err := comments.Create(ctx, answers[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	offTopic := &Comment{PostID: chat.ID, UserID: helper.ID, Content: "hey"}
+	if err := comments.Create(ctx, offTopic); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := comments.Vote(ctx, offTopic.ID, voter.ID, 1); !errors.Is(err, ErrNotQA) {
+		t.Fatalf("vote outside Q&A: err = %v, want ErrNotQA", err)
+	}
+	if _, err := comments.Vote(ctx, answers[0].ID, voter.ID, 2); !errors.Is(err, ErrInvalidVote) {
+		t.Fatalf("vote 2: err = %v, want ErrInvalidVote", err)
+	}
+
+	// answer 1 ends up on +2, answer 2 on -1; changing a vote replaces it.
+	for _, v := range []struct {
+		comment *Comment
+		user    *User
+		value   int
+	}{
+		{answers[1], voter, -1},
+		{answers[1], voter, 1},
+		{answers[1], asker, 1},
+		{answers[2], voter, -1},
+	} {
+		if _, err := comments.Vote(ctx, v.comment.ID, v.user.ID, v.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order := func() []string {
+		t.Helper()
+		list, _, err := comments.ListByPost(ctx, question.ID, CommentQuery{Order: CommentsTop, ViewerID: voter.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range list {
+			got = append(got, fmt.Sprintf("%s:%d:%d:%v", c.Content, c.Score, c.MyVote, c.Accepted))
+		}
+		return got
+	}
+	want := "[answer 1:2:1:false answer 0:0:0:false answer 2:-1:-1:false]"
+	if got := fmt.Sprint(order()); got != want {
+		t.Fatalf("top order = %s, want %s", got, want)
+	}
+
+	// Only the asker accepts, only root comments on a question count.
+	if err := posts.SetAcceptedAnswer(ctx, question.ID, helper.ID, answers[2].ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("accept by non-author: err = %v, want sql.ErrNoRows", err)
+	}
+	if err := posts.SetAcceptedAnswer(ctx, question.ID, asker.ID, offTopic.ID); !errors.Is(err, ErrInvalidAnswer) {
+		t.Fatalf("accept foreign comment: err = %v, want ErrInvalidAnswer", err)
+	}
+	if err := posts.SetAcceptedAnswer(ctx, chat.ID, asker.ID, offTopic.ID); !errors.Is(err, ErrNotQA) {
+		t.Fatalf("accept outside Q&A: err = %v, want ErrNotQA", err)
+	}
+	if err := posts.SetAcceptedAnswer(ctx, question.ID, asker.ID, answers[2].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	want = "[answer 2:-1:-1:true answer 1:2:1:false answer 0:0:0:false]"
+	if got := fmt.Sprint(order()); got != want {
+		t.Fatalf("top order with accepted answer = %s, want %s", got, want)
+	}
+
+	// Paging by one keeps the order.
+	var paged []string
+	var after *CommentCursor
+	for {
+		page, more, err := comments.ListByPost(ctx, question.ID, CommentQuery{Order: CommentsTop, Limit: 1, AfterTop: after})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paged = append(paged, page[0].Content)
+		if !more {
+			break
+		}
+		cursor, err := DecodeCommentCursor(page[0].TopCursor().Encode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		after = &cursor
+
+		// The cursor outlives the comment it points at.
+		if page[0].Content == "answer 1" {
+			if _, err := db.Exec(`DELETE FROM comments WHERE id = ?`, page[0].ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if fmt.Sprint(paged) != "[answer 2 answer 1 answer 0]" {
+		t.Fatalf("paged top order = %v", paged)
+	}
+
+	feed := func(solved string) []int64 {
+		t.Helper()
+		list, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Solved: solved}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		for _, p := range list {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	if got := feed(Solved); fmt.Sprint(got) != fmt.Sprint([]int64{question.ID}) {
+		t.Fatalf("solved feed = %v", got)
+	}
+	if got := feed(Unsolved); len(got) != 0 {
+		t.Fatalf("unsolved feed = %v, want none (chat is not a question)", got)
+	}
+
+	// Deleting the accepted answer reopens the question.
+	if _, err := comments.Delete(ctx, answers[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := feed(Unsolved); fmt.Sprint(got) != fmt.Sprint([]int64{question.ID}) {
+		t.Fatalf("unsolved feed after delete = %v", got)
+	}
+	got, err := posts.GetWithReactions(ctx, question.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.QA || got.AcceptedCommentID != nil {
+		t.Fatalf("question after delete: qa=%v accepted=%v", got.QA, got.AcceptedCommentID)
+	}
+}
@@ -0,0 +1,355 @@
+// internal/models/reactions.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrUnknownReaction is returned for a reaction outside the allowed set.
+var ErrUnknownReaction = errors.New("unknown reaction")
+
+// DefaultReactions is the allowed set when none is configured.
+var DefaultReactions = []string{"like", "love", "laugh", "insightful", "sad"}
+
+// ParseReactions reads a comma-separated list ("like,love,wow"), dropping
+// blanks and duplicates. It returns DefaultReactions for an empty list.
+func ParseReactions(csv string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, r := range strings.Split(csv, ",") {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if r == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		return DefaultReactions
+	}
+	return out
+}
+
+// Reactor is a user who reacted to a post.
+type Reactor struct {
+	UserID    int64     `json:"user_id"`
+	Nickname  string    `json:"nickname"`
+	Reaction  string    `json:"reaction"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionModel provides database operations for post and comment reactions.
+type ReactionModel struct {
+	DB      *sql.DB
+	Allowed []string // DefaultReactions when empty
+}
+
+func (m *ReactionModel) allowed() []string {
+	if len(m.Allowed) == 0 {
+		return DefaultReactions
+	}
+	return m.Allowed
+}
+
+// AllowedReactions returns the configured reaction set, in display order.
+func (m *ReactionModel) AllowedReactions() []string {
+	return append([]string(nil), m.allowed()...)
+}
+
+// Normalize trims and lower-cases reaction and checks it is allowed.
+func (m *ReactionModel) Normalize(reaction string) (string, error) {
+	reaction = strings.ToLower(strings.TrimSpace(reaction))
+	for _, r := range m.allowed() {
+		if r == reaction {
+			return reaction, nil
+		}
+	}
+	return "", ErrUnknownReaction
+}
+
+// reactionTarget is the table holding reactions to one kind of content.
+// Both tables have the same shape: (<column>, user_id, reaction, created_at).
+type reactionTarget struct {
+	table, column string
+}
+
+var (
+	postReactions    = reactionTarget{"post_reactions", "post_id"}
+	commentReactions = reactionTarget{"comment_reactions", "comment_id"}
+)
+
+// Toggle adds the user's reaction if absent and removes it otherwise.
+// It returns whether the reaction is now set and the post's updated counts.
+func (m *ReactionModel) Toggle(ctx context.Context, postID, userID int64, reaction string) (bool, map[string]int64, error) {
+	return m.toggle(ctx, postReactions, postID, userID, reaction)
+}
+
+// ToggleComment is Toggle for a comment.
+func (m *ReactionModel) ToggleComment(ctx context.Context, commentID, userID int64, reaction string) (bool, map[string]int64, error) {
+	return m.toggle(ctx, commentReactions, commentID, userID, reaction)
+}
+
+func (m *ReactionModel) toggle(ctx context.Context, t reactionTarget, id, userID int64, reaction string) (bool, map[string]int64, error) {
+	reaction, err := m.Normalize(reaction)
+	if err != nil {
+		return false, nil, err
+	}
+
+	delRes, err := m.DB.ExecContext(ctx,
+		`DELETE FROM `+t.table+` WHERE `+t.column+`=? AND user_id=? AND reaction=?`,
+		id, userID, reaction,
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	rows, _ := delRes.RowsAffected()
+	reactedNow := false
+
+	if rows == 0 {
+		_, err := m.DB.ExecContext(ctx,
+			`INSERT INTO `+t.table+`(`+t.column+`, user_id, reaction) VALUES(?,?,?)`,
+			id, userID, reaction,
+		)
+		if err != nil {
+			return false, nil, err
+		}
+		reactedNow = true
+	}
+
+	counts, _, err := m.countsFor(ctx, t, []int64{id}, 0)
+	if err != nil {
+		return false, nil, err
+	}
+	return reactedNow, counts[id], nil
+}
+
+// Counts returns the number of reactions of each type on a post.
+// Every allowed reaction is present in the map, with 0 when unused.
+func (m *ReactionModel) Counts(ctx context.Context, postID int64) (map[string]int64, error) {
+	counts, _, err := m.countsFor(ctx, postReactions, []int64{postID}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return counts[postID], nil
+}
+
+// ViewerReactions returns the reactions viewerID has left on a post.
+func (m *ReactionModel) ViewerReactions(ctx context.Context, postID, viewerID int64) ([]string, error) {
+	_, mine, err := m.countsFor(ctx, postReactions, []int64{postID}, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	return mine[postID], nil
+}
+
+// CommentReactions returns a comment's counts per type and the reactions
+// viewerID has left on it (empty when viewerID <= 0).
+func (m *ReactionModel) CommentReactions(ctx context.Context, commentID, viewerID int64) (map[string]int64, []string, error) {
+	counts, mine, err := m.countsFor(ctx, commentReactions, []int64{commentID}, viewerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return counts[commentID], mine[commentID], nil
+}
+
+// Attach fills Reactions and MyReactions on each post with two batched
+// queries, so a feed page does not run one query per post. ReactionsCount
+// becomes the sum of Reactions and IReacted whether MyReactions has any, so
+// all of them leave out retired types.
+func (m *ReactionModel) Attach(ctx context.Context, posts []Post, viewerID int64) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+
+	counts, mine, err := m.countsFor(ctx, postReactions, ids, viewerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Reactions = counts[posts[i].ID]
+		posts[i].MyReactions = mine[posts[i].ID]
+		posts[i].ReactionsCount = total(posts[i].Reactions)
+		posts[i].IReacted = len(posts[i].MyReactions) > 0
+	}
+	return nil
+}
+
+// AttachComments is Attach for comments. Tombstones get no reactions.
+func (m *ReactionModel) AttachComments(ctx context.Context, comments []*Comment, viewerID int64) error {
+	ids := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		if !c.Deleted {
+			ids = append(ids, c.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	counts, mine, err := m.countsFor(ctx, commentReactions, ids, viewerID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range comments {
+		if !c.Deleted {
+			c.Reactions = counts[c.ID]
+			c.MyReactions = mine[c.ID]
+			c.ReactionsCount = total(c.Reactions)
+		}
+	}
+	return nil
+}
+
+func total(counts map[string]int64) int64 {
+	var n int64
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
+
+// countsFor loads per-type counts for ids in t and, when viewerID > 0, the
+// viewer's own reactions. Types no longer allowed are left out.
+func (m *ReactionModel) countsFor(ctx context.Context, t reactionTarget, ids []int64, viewerID int64) (map[int64]map[string]int64, map[int64][]string, error) {
+	allowed := m.allowed()
+
+	counts := make(map[int64]map[string]int64, len(ids))
+	mine := make(map[int64][]string, len(ids))
+	for _, id := range ids {
+		c := make(map[string]int64, len(allowed))
+		for _, r := range allowed {
+			c[r] = 0
+		}
+		counts[id] = c
+		mine[id] = []string{}
+	}
+
+	in, args := inClause(ids)
+
+	rows, err := m.DB.QueryContext(ctx,
+		`SELECT `+t.column+`, reaction, COUNT(*)
+		 FROM `+t.table+`
+		 WHERE `+t.column+` IN (`+in+`)
+		 GROUP BY `+t.column+`, reaction`,
+		args...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var id, n int64
+		var reaction string
+		if err := rows.Scan(&id, &reaction, &n); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		if _, ok := counts[id][reaction]; ok {
+			counts[id][reaction] = n
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if viewerID <= 0 {
+		return counts, mine, nil
+	}
+
+	rows, err = m.DB.QueryContext(ctx,
+		`SELECT `+t.column+`, reaction
+		 FROM `+t.table+`
+		 WHERE user_id = ? AND `+t.column+` IN (`+in+`)
+		 ORDER BY created_at ASC`,
+		append([]any{viewerID}, args...)...,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var reaction string
+		if err := rows.Scan(&id, &reaction); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := counts[id][reaction]; ok {
+			mine[id] = append(mine[id], reaction)
+		}
+	}
+
+	return counts, mine, rows.Err()
+}
+
+// ListReactors returns who reacted to a post, newest first. An empty
+// reaction lists every type. hasMore reports whether another page exists.
+func (m *ReactionModel) ListReactors(ctx context.Context, postID int64, reaction string, limit, offset int) ([]Reactor, bool, error) {
+	if reaction != "" {
+		var err error
+		if reaction, err = m.Normalize(reaction); err != nil {
+			return nil, false, err
+		}
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT r.user_id, u.nickname, r.reaction, r.created_at
+		FROM post_reactions r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.post_id = ? AND (? = '' OR r.reaction = ?)
+		ORDER BY r.created_at DESC, r.user_id DESC
+		LIMIT ? OFFSET ?`,
+		postID, reaction, reaction, limit+1, offset,
+	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	reactors := []Reactor{}
+	for rows.Next() {
+		var r Reactor
+		if err := rows.Scan(&r.UserID, &r.Nickname, &r.Reaction, &r.CreatedAt); err != nil {
+			return nil, false, err
+		}
+		reactors = append(reactors, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(reactors) > limit
+	if hasMore {
+		reactors = reactors[:limit]
+	}
+	return reactors, hasMore, nil
+}
+
+// inClause returns "?, ?, ?" and the matching args for an IN (...) list.
+func inClause(ids []int64) (string, []any) {
+	marks := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		marks[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(marks, ", "), args
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReactionToggleCountsPerType(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	alice := newTestUser(t, db, "alice")
+	bob := newTestUser(t, db, "bob")
+
+	posts := &PostModel{DB: db}
+	post := &Post{UserID: alice.ID, Title: "t", Content: "c", Category: "General"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	reactions := &ReactionModel{DB: db, Allowed: []string{"like", "love"}}
+
+	if _, _, err := reactions.Toggle(ctx, post.ID, alice.ID, "angry"); !errors.Is(err, ErrUnknownReaction) {
+		t.Fatalf("Toggle(angry) err = %v, want ErrUnknownReaction", err)
+	}
+
+	for _, step := range []struct {
+		user     int64
+		reaction string
+	}{
+		{alice.ID, "like"},
+		{alice.ID, "Love"},
+		{bob.ID, "love"},
+	} {
+		if _, _, err := reactions.Toggle(ctx, post.ID, step.user, step.reaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Toggling again removes the reaction.
+	reacted, counts, err := reactions.Toggle(ctx, post.ID, alice.ID, "like")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reacted {
+		t.Fatal("second toggle should remove the reaction")
+	}
+	if want := map[string]int64{"like": 0, "love": 2}; !reflect.DeepEqual(counts, want) {
+		t.Fatalf("counts = %v, want %v", counts, want)
+	}
+
+	feed, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10}, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reactions.Attach(ctx, feed, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if feed[0].ReactionsCount != 2 || !feed[0].IReacted || !reflect.DeepEqual(feed[0].MyReactions, []string{"love"}) {
+		t.Fatalf("feed post = %+v, want 2 reactions and my_reactions [love]", feed[0])
+	}
+
+	// Retiring "love" hides it from the count as well as the map.
+	retired := &ReactionModel{DB: db, Allowed: []string{"like"}}
+	if err := retired.Attach(ctx, feed, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	if feed[0].ReactionsCount != 0 || len(feed[0].Reactions) != 1 {
+		t.Fatalf("after retiring love: count %d, reactions %v", feed[0].ReactionsCount, feed[0].Reactions)
+	}
+	if feed[0].IReacted || len(feed[0].MyReactions) != 0 {
+		t.Fatalf("after retiring love: i_reacted %v, my_reactions %v", feed[0].IReacted, feed[0].MyReactions)
+	}
+
+	reactors, _, err := reactions.ListReactors(ctx, post.ID, "love", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reactors) != 2 {
+		t.Fatalf("reactors = %d, want 2", len(reactors))
+	}
+}
+
+func TestCommentReactionsToggleAndList(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	alice := newTestUser(t, db, "alice")
+	bob := newTestUser(t, db, "bob")
+
+	post := &Post{UserID: alice.ID, Title: "t", Content: "c", Category: "General"}
+	if err := (&PostModel{DB: db}).Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+
+	reactions := &ReactionModel{DB: db, Allowed: []string{"like", "love"}}
+	comments := &CommentModel{DB: db, Reactions: reactions}
+	c := &Comment{PostID: post.ID, UserID: alice.ID, Content: "nice"}
+	if err := comments.Create(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := reactions.ToggleComment(ctx, c.ID, bob.ID, "angry"); !errors.Is(err, ErrUnknownReaction) {
+		t.Fatalf("ToggleComment(angry) err = %v, want ErrUnknownReaction", err)
+	}
+	for _, r := range []string{"like", "LOVE", "like"} {
+		if _, _, err := reactions.ToggleComment(ctx, c.ID, bob.ID, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reacted, counts, err := reactions.ToggleComment(ctx, c.ID, alice.ID, "love")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reacted || !reflect.DeepEqual(counts, map[string]int64{"like": 0, "love": 2}) {
+		t.Fatalf("toggle = %v, %v", reacted, counts)
+	}
+
+	list, _, err := comments.ListByPost(ctx, post.ID, CommentQuery{ViewerID: bob.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := list[0]
+	if got.ReactionsCount != 2 || got.Reactions["love"] != 2 || !reflect.DeepEqual(got.MyReactions, []string{"love"}) {
+		t.Fatalf("listed comment = %+v", got)
+	}
+
+	// Removing the comment takes its reactions with it.
+	if _, err := comments.Delete(ctx, c.ID); err != nil {
+		t.Fatal(err)
+	}
+	var left int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM comment_reactions`).Scan(&left); err != nil {
+		t.Fatal(err)
+	}
+	if left != 0 {
+		t.Fatalf("%d comment reactions left after delete", left)
+	}
+}
@@ -0,0 +1,234 @@
+// internal/models/search.go
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrSearchUnavailable is returned when the FTS5 indexes were not created
+// (the SQLite driver was built without FTS5 support).
+var ErrSearchUnavailable = errors.New("search unavailable")
+
+// highlightMarkers returns the markers one search passes to highlight() and
+// snippet(). They carry a random nonce, so stored text cannot contain them
+// (a control character alone could be typed into a post): the snippet is
+// HTML-escaped first and only these exact markers become <mark> after.
+func highlightMarkers() (hlOpen, hlClose string, err error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", "", err
+	}
+	nonce := hex.EncodeToString(b[:])
+	return "\x02" + nonce, "\x03" + nonce, nil
+}
+
+// SearchHit is a single ranked result; Kind is "post" or "comment".
+type SearchHit struct {
+	Kind      string    `json:"kind"`
+	PostID    int64     `json:"post_id"`
+	CommentID int64     `json:"comment_id,omitempty"`
+	Title     string    `json:"title"`   // sanitized HTML with <mark> highlights
+	Snippet   string    `json:"snippet"` // sanitized HTML with <mark> highlights
+	Author    string    `json:"author"`
+	Category  string    `json:"category"`
+	CreatedAt time.Time `json:"created_at"`
+	Rank      float64   `json:"rank"` // bm25, lower is better
+}
+
+// SearchParams holds the query and its optional filters.
+type SearchParams struct {
+	Query    string
+	Category string
+	Author   string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type SearchModel struct {
+	DB *sql.DB
+}
+
+// Available reports whether the full-text indexes exist.
+func (m *SearchModel) Available(ctx context.Context) (bool, error) {
+	var n int
+	err := m.DB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sqlite_master WHERE name IN ('posts_fts', 'comments_fts')`,
+	).Scan(&n)
+	return n == 2, err
+}
+
+// BuildMatchQuery turns free text into a safe FTS5 MATCH expression.
+// Every term is quoted (so operators and punctuation are treated as text)
+// and the last one is a prefix query, which makes search-as-you-type work.
+// It returns "" when the input has no searchable terms.
+func BuildMatchQuery(raw string) string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		terms = append(terms, `"`+strings.ReplaceAll(f, `"`, `""`)+`"`)
+	}
+	terms[len(terms)-1] += "*"
+
+	return strings.Join(terms, " ")
+}
+
+// Search returns ranked post and comment hits matching p.Query.
+// Results are ordered by bm25 (title matches weigh more than body matches)
+// and paginated with LIMIT/OFFSET; hasMore reports whether another page exists.
+func (m *SearchModel) Search(ctx context.Context, p SearchParams) ([]SearchHit, bool, error) {
+	ok, err := m.Available(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, ErrSearchUnavailable
+	}
+
+	match := BuildMatchQuery(p.Query)
+	if match == "" {
+		return []SearchHit{}, false, nil
+	}
+
+	if p.Limit <= 0 || p.Limit > 50 {
+		p.Limit = 20
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+
+	hlOpen, hlClose, err := highlightMarkers()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Shared filters: they apply to the post itself, or to the comment and
+	// the post it belongs to (category always comes from the post).
+	filters := func(alias string, args *[]any) string {
+		var sb strings.Builder
+		if p.Category != "" {
+			sb.WriteString(` AND LOWER(p.category) = LOWER(?)`)
+			*args = append(*args, p.Category)
+		}
+		if p.Author != "" {
+			sb.WriteString(` AND LOWER(u.nickname) = LOWER(?)`)
+			*args = append(*args, p.Author)
+		}
+		if p.From != nil {
+			sb.WriteString(` AND ` + alias + `.created_at >= ?`)
+			*args = append(*args, p.From.UTC().Format("2006-01-02 15:04:05"))
+		}
+		if p.To != nil {
+			sb.WriteString(` AND ` + alias + `.created_at < ?`)
+			*args = append(*args, p.To.UTC().Format("2006-01-02 15:04:05"))
+		}
+		return sb.String()
+	}
+
+	args := []any{hlOpen, hlClose, hlOpen, hlClose, match}
+	postFilters := filters("p", &args)
+	args = append(args, hlOpen, hlClose, match)
+	commentFilters := filters("c", &args)
+	args = append(args, p.Limit+1, p.Offset)
+
+	query := `
+SELECT kind, post_id, comment_id, title, snippet, author, category, created_at, rank
+FROM (
+  SELECT
+    'post' AS kind,
+    p.id AS post_id,
+    0 AS comment_id,
+    highlight(posts_fts, 0, ?, ?) AS title,
+    snippet(posts_fts, 1, ?, ?, '…', 24) AS snippet,
+    u.nickname AS author,
+    p.category AS category,
+    p.created_at AS created_at,
+    bm25(posts_fts, 5.0, 1.0) AS rank
+  FROM posts_fts
+  JOIN posts p ON p.id = posts_fts.rowid
+  JOIN users u ON u.id = p.user_id
+  WHERE posts_fts MATCH ? AND ` + publishedOnly + postFilters + `
+
+  UNION ALL
+
+  SELECT
+    'comment' AS kind,
+    c.post_id AS post_id,
+    c.id AS comment_id,
+    p.title AS title,
+    snippet(comments_fts, 0, ?, ?, '…', 24) AS snippet,
+    u.nickname AS author,
+    p.category AS category,
+    c.created_at AS created_at,
+    bm25(comments_fts) AS rank
+  FROM comments_fts
+  JOIN comments c ON c.id = comments_fts.rowid
+  JOIN posts p ON p.id = c.post_id
+  JOIN users u ON u.id = c.user_id
+  WHERE comments_fts MATCH ? AND ` + publishedOnly + commentFilters + `
+)
+ORDER BY rank ASC, created_at DESC
+LIMIT ? OFFSET ?;
+`
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	hits := []SearchHit{}
+	for rows.Next() {
+		var h SearchHit
+		if err := rows.Scan(
+			&h.Kind,
+			&h.PostID,
+			&h.CommentID,
+			&h.Title,
+			&h.Snippet,
+			&h.Author,
+			&h.Category,
+			&h.CreatedAt,
+			&h.Rank,
+		); err != nil {
+			return nil, false, err
+		}
+
+		h.Title = renderHighlight(h.Title, hlOpen, hlClose)
+		h.Snippet = renderHighlight(h.Snippet, hlOpen, hlClose)
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(hits) > p.Limit
+	if hasMore {
+		hits = hits[:p.Limit]
+	}
+
+	return hits, hasMore, nil
+}
+
+// renderHighlight escapes user text and turns the markers hlOpen and
+// hlClose into <mark>.
+func renderHighlight(s, hlOpen, hlClose string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, hlOpen, "<mark>")
+	return strings.ReplaceAll(s, hlClose, "</mark>")
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appdb "real-time-forum/internal/db"
+)
+
+func TestBuildMatchQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "  ***  ", want: ""},
+		{in: "go", want: `"go"*`},
+		{in: "go channels", want: `"go" "channels"*`},
+		{in: `title:foo OR "bar`, want: `"title" "foo" "OR" "bar"*`},
+	}
+
+	for _, tt := range tests {
+		if got := BuildMatchQuery(tt.in); got != tt.want {
+			t.Errorf("BuildMatchQuery(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFindsPostsAndComments(t *testing.T) {
+	db, err := appdb.Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := appdb.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	search := &SearchModel{DB: db}
+	if ok, err := search.Available(ctx); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Skip("FTS5 not compiled in (run with -tags sqlite_fts5)")
+	}
+
+	users := &UserModel{DB: db}
+	user := &User{Nickname: "searcher", Age: 30, Gender: "other", FirstName: "S", LastName: "R", Email: "s@example.com"}
+	if err := users.Create(ctx, user, "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := &PostModel{DB: db}
+	post := &Post{UserID: user.ID, Title: "Goroutines explained", Content: "A <b>gentle</b> intro", Category: "Go"}
+	if err := posts.Create(ctx, post); err != nil {
+		t.Fatal(err)
+	}
+	comments := &CommentModel{DB: db}
+	if err := comments.Create(ctx, &Comment{PostID: post.ID, UserID: user.ID, Content: "goroutines leak if you forget to cancel"}); err != nil {
+		t.Fatal(err)
+	}
+
+	hits, _, err := search.Search(ctx, SearchParams{Query: "goroutine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("hits = %d, want 2", len(hits))
+	}
+	if hits[0].Kind != "post" || !strings.Contains(hits[0].Title, "<mark>Goroutines</mark>") {
+		t.Fatalf("first hit = %+v, want highlighted post title", hits[0])
+	}
+
+	// Updates go through the triggers; the HTML in content must come back escaped.
+	newContent := "A <b>gentle</b> intro to scheduling"
+	if err := posts.UpdateByOwner(ctx, post.ID, user.ID, nil, &newContent, nil); err != nil {
+		t.Fatal(err)
+	}
+	hits, _, err = search.Search(ctx, SearchParams{Query: "scheduling", Category: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || strings.Contains(hits[0].Snippet, "<b>") {
+		t.Fatalf("hits = %+v, want one escaped snippet", hits)
+	}
+
+	// Marker bytes typed into a post stay text.
+	sneaky := "\x02<img src=x>\x03 sneaky"
+	if err := posts.UpdateByOwner(ctx, post.ID, user.ID, nil, &sneaky, nil); err != nil {
+		t.Fatal(err)
+	}
+	hits, _, err = search.Search(ctx, SearchParams{Query: "sneaky"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || strings.Contains(hits[0].Snippet, "<img") || strings.Count(hits[0].Snippet, "<mark>") != 1 {
+		t.Fatalf("hits = %+v, want one escaped snippet with only the real match marked", hits)
+	}
+
+	hits, _, err = search.Search(ctx, SearchParams{Query: "scheduling", Author: "nobody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Fatalf("hits = %d with unknown author, want 0", len(hits))
+	}
+}
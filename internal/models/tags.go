@@ -0,0 +1,225 @@
+// internal/models/tags.go
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tag limits.
+const (
+	MaxTagLen      = 30
+	MaxTagsPerPost = 5
+)
+
+var (
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTooManyTags = errors.New("too many tags")
+)
+
+// TagCount is a tag with the number of published posts carrying it.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type TagModel struct {
+	DB *sql.DB
+}
+
+// NormalizeTag lowercases a tag, drops a leading '#', and turns runs of
+// spaces and underscores into a single '-'. Only letters, digits and
+// "-+." survive; the result must be 1..MaxTagLen runes.
+func NormalizeTag(s string) (string, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '.':
+			b.WriteRune(r)
+			dash = false
+		case r == '-' || r == '_' || unicode.IsSpace(r):
+			if !dash && b.Len() > 0 {
+				b.WriteRune('-')
+				dash = true
+			}
+		default:
+			return "", ErrInvalidTag
+		}
+	}
+
+	tag := strings.TrimRight(b.String(), "-")
+	if tag == "" || utf8.RuneCountInString(tag) > MaxTagLen {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes and de-duplicates tags, keeping their order.
+func NormalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, t := range tags {
+		tag, err := NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	if len(out) > MaxTagsPerPost {
+		return nil, ErrTooManyTags
+	}
+	return out, nil
+}
+
+// SetForPost replaces the tags of ownerID's post (sql.ErrNoRows if the
+// post is not theirs). tags must already be normalized. The edit window is
+// the caller's to check (PostModel.CanEdit).
+func (m *TagModel) SetForPost(ctx context.Context, postID, ownerID int64, tags []string) error {
+	return m.set(ctx, postID, ownerID, true, tags)
+}
+
+// SetForPostAsModerator is SetForPost without the owner check. Permission
+// checks are the caller's job. Returns sql.ErrNoRows if the post does not
+// exist.
+func (m *TagModel) SetForPostAsModerator(ctx context.Context, postID int64, tags []string) error {
+	return m.set(ctx, postID, 0, false, tags)
+}
+
+func (m *TagModel) set(ctx context.Context, postID, ownerID int64, byOwner bool, tags []string) error {
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// safe rollback
+		_ = tx.Rollback()
+	}()
+
+	q, args := `SELECT EXISTS(SELECT 1 FROM posts WHERE id = ?)`, []any{postID}
+	if byOwner {
+		q, args = `SELECT EXISTS(SELECT 1 FROM posts WHERE id = ? AND user_id = ?)`, []any{postID, ownerID}
+	}
+	var found bool
+	if err := tx.QueryRowContext(ctx, q, args...).Scan(&found); err != nil {
+		return err
+	}
+	if !found {
+		return sql.ErrNoRows
+	}
+
+	if err := setTags(ctx, tx, postID, tags); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setTags replaces a post's tags inside tx.
+func setTags(ctx context.Context, tx *sql.Tx, postID int64, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_tags WHERE post_id = ?`, postID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (name) VALUES (?)`, tag); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO post_tags (post_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ?;
+		`, postID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Attach fills Tags on each post (alphabetical) with one query.
+func (m *TagModel) Attach(ctx context.Context, posts []Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	in, args := inClause(ids)
+
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT pt.post_id, t.name
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.post_id IN (`+in+`)
+		ORDER BY pt.post_id, t.name;
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byPost := make(map[int64][]string, len(posts))
+	for rows.Next() {
+		var postID int64
+		var name string
+		if err := rows.Scan(&postID, &name); err != nil {
+			return err
+		}
+		byPost[postID] = append(byPost[postID], name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Tags = byPost[posts[i].ID]
+	}
+	return nil
+}
+
+// Suggest returns tags starting with prefix (all tags when prefix is ""),
+// most used first. Only published posts count; unused tags are skipped.
+func (m *TagModel) Suggest(ctx context.Context, prefix string, limit int) ([]TagCount, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	rows, err := m.DB.QueryContext(ctx, `
+		SELECT t.name, COUNT(*) AS n
+		FROM tags t
+		JOIN post_tags pt ON pt.tag_id = t.id
+		JOIN posts p ON p.id = pt.post_id
+		WHERE t.name LIKE ? ESCAPE '\' AND `+publishedOnly+`
+		GROUP BY t.id
+		ORDER BY n DESC, t.name
+		LIMIT ?;
+	`, escaped+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// tagCond matches posts carrying the given (normalized) tag.
+const tagCond = `p.id IN (
+		SELECT pt.post_id FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE t.name = ?
+	)`
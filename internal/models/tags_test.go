@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := map[string]string{
+		"Go":              "go",
+		"#golang":         "golang",
+		"  Web  Dev ":     "web-dev",
+		"c++":             "c++",
+		"node.js":         "node.js",
+		"snake_case__tag": "snake-case-tag",
+		"Ünïcode":         "ünïcode",
+	}
+	for in, want := range cases {
+		got, err := NormalizeTag(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeTag(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for _, bad := range []string{"", "#", "---", "what?", "a/b", "abcdefghijklmnopqrstuvwxyz01234"} {
+		if _, err := NormalizeTag(bad); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("NormalizeTag(%q): err = %v, want ErrInvalidTag", bad, err)
+		}
+	}
+
+	tags, err := NormalizeTags([]string{"Go", "#go", "sql"})
+	if err != nil || fmt.Sprint(tags) != "[go sql]" {
+		t.Fatalf("NormalizeTags = %v, %v", tags, err)
+	}
+	if _, err := NormalizeTags([]string{"a", "b", "c", "d", "e", "f"}); !errors.Is(err, ErrTooManyTags) {
+		t.Fatalf("six tags: err = %v", err)
+	}
+}
+
+func TestTagsFilterAndSuggest(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	user := newTestUser(t, db, "author")
+	other := newTestUser(t, db, "other")
+	posts := &PostModel{DB: db}
+	tags := &TagModel{DB: db}
+
+	create := func(title string, tagList ...string) *Post {
+		p := &Post{UserID: user.ID, Title: title, Content: "body", Category: "General"}
+		if err := posts.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		if err := tags.SetForPost(ctx, p.ID, user.ID, tagList); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := create("a", "golang", "sql")
+	b := create("b", "golang")
+	create("c", "gossip")
+
+	if err := tags.SetForPost(ctx, a.ID, other.ID, []string{"spam"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("non-owner: err = %v", err)
+	}
+
+	page, _, err := posts.ListWithReactionsPage(ctx, FeedQuery{Limit: 10, Tag: "golang"}, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tags.Attach(ctx, page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 2 || page[0].ID != b.ID || page[1].ID != a.ID {
+		t.Fatalf("golang feed = %+v", page)
+	}
+	if fmt.Sprint(page[1].Tags) != "[golang sql]" {
+		t.Fatalf("tags of a = %v", page[1].Tags)
+	}
+
+	got, err := tags.Suggest(ctx, "go", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[{golang 2} {gossip 1}]" {
+		t.Fatalf("suggest go = %v", got)
+	}
+
+	// Replacing tags drops the old ones from counts.
+	if err := tags.SetForPost(ctx, b.ID, user.ID, []string{"sql"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = tags.Suggest(ctx, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[{sql 2} {golang 1} {gossip 1}]" {
+		t.Fatalf("all tags = %v", got)
+	}
+}
@@ -19,6 +19,12 @@ var (
 	ErrInvalidPassword = errors.New("invalid password")
 )
 
+// User roles. Moderators can act on content they do not own.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+)
+
 // User represents an account in the system.
 type User struct {
 	ID           int64     `json:"id"`
@@ -30,6 +36,7 @@ type User struct {
 	LastName     string    `json:"last_name"`
 	Email        string    `json:"email"`
 	PasswordHash string    `json:"-"` // never exposed in JSON
+	Role         string    `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
@@ -58,6 +65,7 @@ func (m *UserModel) Create(ctx context.Context, u *User, password string) error
 
 	u.PasswordHash = string(hash)
 	u.UUID = uuid.NewString()
+	u.Role = RoleUser
 
 	query := `
 	INSERT INTO users (uuid, nickname, age, gender, first_name, last_name, email, password_hash)
@@ -86,7 +94,7 @@ func (m *UserModel) Create(ctx context.Context, u *User, password string) error
 // GetByIdentifier retrieves a user using either nickname or email.
 func (m *UserModel) GetByIdentifier(ctx context.Context, identifier string) (*User, error) {
 	query := `
-	SELECT id, uuid, nickname, age, gender, first_name, last_name, email, password_hash, created_at
+	SELECT id, uuid, nickname, age, gender, first_name, last_name, email, password_hash, role, created_at
 	FROM users
 	WHERE lower(nickname) = lower(?) OR lower(email) = lower(?)
 	LIMIT 1`
@@ -96,7 +104,7 @@ func (m *UserModel) GetByIdentifier(ctx context.Context, identifier string) (*Us
 	var u User
 	err := row.Scan(
 		&u.ID, &u.UUID, &u.Nickname, &u.Age, &u.Gender,
-		&u.FirstName, &u.LastName, &u.Email, &u.PasswordHash, &u.CreatedAt,
+		&u.FirstName, &u.LastName, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -111,7 +119,7 @@ func (m *UserModel) GetByIdentifier(ctx context.Context, identifier string) (*Us
 // GetByID retrieves a user by id.
 func (m *UserModel) GetByID(ctx context.Context, id int64) (*User, error) {
 	query := `
-	SELECT id, uuid, nickname, age, gender, first_name, last_name, email, password_hash, created_at
+	SELECT id, uuid, nickname, age, gender, first_name, last_name, email, password_hash, role, created_at
 	FROM users
 	WHERE id = ?
 	LIMIT 1`
@@ -121,7 +129,7 @@ func (m *UserModel) GetByID(ctx context.Context, id int64) (*User, error) {
 	var u User
 	err := row.Scan(
 		&u.ID, &u.UUID, &u.Nickname, &u.Age, &u.Gender,
-		&u.FirstName, &u.LastName, &u.Email, &u.PasswordHash, &u.CreatedAt,
+		&u.FirstName, &u.LastName, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -193,3 +201,36 @@ func (m *UserModel) UpdateLastSeen(ctx context.Context, userID int64, t time.Tim
 	_, err := m.DB.ExecContext(ctx, `UPDATE users SET last_seen_at = ? WHERE id = ?`, t.UTC(), userID)
 	return err
 }
+
+// ------------------------------------------------------------
+// Roles
+// ------------------------------------------------------------
+
+// IsModerator reports whether the user has the moderator role.
+func (m *UserModel) IsModerator(ctx context.Context, userID int64) (bool, error) {
+	if userID <= 0 {
+		return false, nil
+	}
+	var role string
+	err := m.DB.QueryRowContext(ctx, `SELECT role FROM users WHERE id = ?`, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return role == RoleModerator, err
+}
+
+// SetRoleByNickname assigns role to the user with the given nickname.
+// It returns ErrUserNotFound when no such user exists.
+func (m *UserModel) SetRoleByNickname(ctx context.Context, nickname, role string) error {
+	res, err := m.DB.ExecContext(ctx,
+		`UPDATE users SET role = ? WHERE lower(nickname) = lower(?)`,
+		role, nickname,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewLocal(t.TempDir())
+
+	if err := s.Put(ctx, "posts/1/a.png", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := s.Open(ctx, "posts/1/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Fatalf("got %q", data)
+	}
+
+	if err := s.Delete(ctx, "posts/1/a.png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, "posts/1/a.png"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("after delete: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalRejectsEscapingKeys(t *testing.T) {
+	s := NewLocal(t.TempDir())
+	for _, key := range []string{"", "../x", "a/../../x", "/etc/passwd", `a\b`, "a//b"} {
+		if err := s.Put(context.Background(), key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// internal/textdiff/diff.go
+
+// Package textdiff computes line-based diffs between two texts.
+package textdiff
+
+import "strings"
+
+// Line operations.
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Line is one line of a diff.
+type Line struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxCells caps the LCS table (lines(a) * lines(b)) at about 1 MB, since
+// anyone can request a diff. Larger inputs fall back to "delete everything,
+// insert everything", which is still a valid diff. The common prefix and
+// suffix are trimmed first, so only the changed region has to fit.
+const maxCells = 250_000
+
+// Lines returns the line-based diff that turns a into b, using the longest
+// common subsequence of lines. Line endings are normalised to "\n".
+func Lines(a, b string) []Line {
+	al := splitLines(a)
+	bl := splitLines(b)
+
+	// Trim the common prefix and suffix; edits are usually local.
+	pre := 0
+	for pre < len(al) && pre < len(bl) && al[pre] == bl[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(al)-pre && suf < len(bl)-pre && al[len(al)-1-suf] == bl[len(bl)-1-suf] {
+		suf++
+	}
+
+	out := make([]Line, 0, len(al)+len(bl))
+	for _, l := range al[:pre] {
+		out = append(out, Line{Op: OpEqual, Text: l})
+	}
+	out = append(out, lcsDiff(al[pre:len(al)-suf], bl[pre:len(bl)-suf])...)
+	for _, l := range al[len(al)-suf:] {
+		out = append(out, Line{Op: OpEqual, Text: l})
+	}
+	return out
+}
+
+func lcsDiff(a, b []string) []Line {
+	out := make([]Line, 0, len(a)+len(b))
+
+	if len(a)*len(b) > maxCells {
+		for _, l := range a {
+			out = append(out, Line{Op: OpDelete, Text: l})
+		}
+		for _, l := range b {
+			out = append(out, Line{Op: OpInsert, Text: l})
+		}
+		return out
+	}
+
+	// lcs(i, j) = LCS length of a[i:] and b[j:], in one flat table.
+	w := len(b) + 1
+	table := make([]int32, (len(a)+1)*w)
+	lcs := func(i, j int) int32 { return table[i*w+j] }
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*w+j] = lcs(i+1, j+1) + 1
+			} else {
+				table[i*w+j] = max(lcs(i+1, j), lcs(i, j+1))
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, Line{Op: OpEqual, Text: a[i]})
+			i++
+			j++
+		case lcs(i+1, j) >= lcs(i, j+1):
+			out = append(out, Line{Op: OpDelete, Text: a[i]})
+			i++
+		default:
+			out = append(out, Line{Op: OpInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, Line{Op: OpDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, Line{Op: OpInsert, Text: b[j]})
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(s, "\n")
+}
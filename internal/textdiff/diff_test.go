@@ -0,0 +1,56 @@
+package textdiff
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{name: "identical", a: "x\ny", b: "x\ny", want: []Line{{OpEqual, "x"}, {OpEqual, "y"}}},
+		{name: "from empty", a: "", b: "x", want: []Line{{OpInsert, "x"}}},
+		{name: "to empty", a: "x", b: "", want: []Line{{OpDelete, "x"}}},
+		{
+			name: "replace middle line",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: []Line{{OpEqual, "one"}, {OpDelete, "two"}, {OpInsert, "2"}, {OpEqual, "three"}},
+		},
+		{
+			name: "crlf normalised",
+			a:    "a\r\nb",
+			b:    "a\nb\nc",
+			want: []Line{{OpEqual, "a"}, {OpEqual, "b"}, {OpInsert, "c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Lines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinesTooLargeFallsBack(t *testing.T) {
+	// 600 x 600 changed lines is over maxCells: everything is replaced, in
+	// order, rather than building the table.
+	var a, b []string
+	for i := 0; i < 600; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+	b[300] = a[300] // a common line the fallback ignores
+
+	got := Lines(strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if len(got) != 1200 || got[0] != (Line{OpDelete, "a0"}) || got[599] != (Line{OpDelete, "a599"}) || got[600] != (Line{OpInsert, "b0"}) {
+		t.Fatalf("fallback diff = %d lines starting %v", len(got), got[:2])
+	}
+}
@@ -0,0 +1,112 @@
+// internal/ws/ack.go
+package ws
+
+import (
+	"errors"
+	"time"
+)
+
+// Every operation a client sends (message, delivered, seen, typing,
+// subscribe, unsubscribe) that carries a temp_id gets exactly one reply on
+// the same connection: an AckEvent when it succeeded, or an ErrorEvent
+// saying why not. Operations without a temp_id get no reply. A frame with
+// a field of the wrong type is a failed operation like any other (code
+// validation); only a frame that is not JSON at all closes the connection.
+
+// Error codes in ErrorEvent.
+const (
+	CodeValidation  = "validation"   // malformed or missing fields
+	CodeRateLimited = "rate_limited" // too many operations, try again later
+	CodeBlocked     = "blocked"      // not allowed for this user
+	CodeInternal    = "internal"     // the server failed
+	CodeUnlogged    = "unlogged"     // an event was sent without a seq (see replay.go)
+)
+
+// ErrBlocked is returned (wrapped) by hub callbacks when the user may not
+// do what they asked, e.g. message an unknown user or acknowledge someone
+// else's message. Other callback errors are reported as internal.
+var ErrBlocked = errors.New("not allowed")
+
+// AckEvent confirms the operation sent with TempID. ID is what it created
+// or touched, when there is one: the stored message, the delivered
+// message, or the last message marked seen.
+type AckEvent struct {
+	Type   string `json:"type"` // "ack"
+	Op     string `json:"op"`   // the operation's type, e.g. "message"
+	TempID string `json:"temp_id"`
+	ID     int64  `json:"id,omitempty"`
+}
+
+// ErrorEvent reports that the operation sent with TempID failed.
+type ErrorEvent struct {
+	Type    string `json:"type"` // "error"
+	Op      string `json:"op"`
+	TempID  string `json:"temp_id"`
+	Code    string `json:"code"` // CodeValidation, CodeRateLimited, ...
+	Message string `json:"message"`
+}
+
+// opError is why an operation failed.
+type opError struct {
+	code    string
+	message string
+}
+
+func fail(code, message string) *opError {
+	return &opError{code: code, message: message}
+}
+
+// callbackError classifies an error from a hub callback.
+func callbackError(err error) *opError {
+	if errors.Is(err, ErrBlocked) {
+		return fail(CodeBlocked, err.Error())
+	}
+	return fail(CodeInternal, "something went wrong, try again")
+}
+
+// rateLimiter is a token bucket: up to burst operations at once, refilled
+// at rate per second. Each connection has its own, used by readPump only.
+type rateLimiter struct {
+	rate, burst float64
+	tokens      float64
+	last        time.Time
+}
+
+// allow takes a token if one is left.
+func (l *rateLimiter) allow(now time.Time) bool {
+	if l.last.IsZero() {
+		l.tokens = l.burst
+	} else {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// reply sends an ack or error to this connection only. The hub may have
+// closed c.send already (a dropped client); then the reply is discarded.
+func (c *Client) reply(ev any) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return
+	}
+	select {
+	case c.send <- ev:
+	default:
+		go c.requestUnregister()
+	}
+}
+
+// closeSend closes c.send once the hub has forgotten the client.
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	c.closed = true
+	close(c.send)
+}
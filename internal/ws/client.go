@@ -2,14 +2,48 @@
 package ws
 
 import (
-	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// Limits bound every WebSocket connection. The server pings each client
+// every PingInterval; a client that sends nothing back, not even a pong,
+// for PongWait is dropped, so presence only counts live connections.
+type Limits struct {
+	PingInterval   time.Duration // how often the server pings a client
+	PongWait       time.Duration // how long a client may stay silent
+	WriteWait      time.Duration // time allowed for each write
+	MaxMessageSize int64         // largest message a client may send, in bytes
+}
+
+// DefaultLimits returns the limits NewHub starts with.
+func DefaultLimits() Limits {
+	return Limits{
+		PingInterval:   54 * time.Second,
+		PongWait:       60 * time.Second,
+		WriteWait:      10 * time.Second,
+		MaxMessageSize: 16 << 10,
+	}
+}
+
+// Validate checks that every limit is set and that pings go out before
+// the pong wait runs out.
+func (l Limits) Validate() error {
+	if l.PingInterval <= 0 || l.PongWait <= 0 || l.WriteWait <= 0 || l.MaxMessageSize <= 0 {
+		return errors.New("websocket limits must be positive")
+	}
+	if l.PingInterval >= l.PongWait {
+		return errors.New("websocket ping interval must be shorter than the pong wait")
+	}
+	return nil
+}
+
 // Client represents a single WebSocket connection (tab/window) for a user.
 type Client struct {
 	hub    *Hub
@@ -17,6 +51,26 @@ type Client struct {
 	send   chan any // send any WS event (MessageEvent, DeliveredEvent, SeenEvent, TypingEvent, ...)
 	userID int64
 
+	// since is the last event seq this tab saw before reconnecting, or -1
+	// for a fresh tab (see replay.go).
+	since int64
+
+	// registered is closed once the hub has registered the client (see
+	// connect in replay.go).
+	registered chan struct{}
+
+	// subs is the live feed this client listens to (see feed.go).
+	subs subscriptions
+
+	// Throttle state, used by readPump only.
+	lastTyping   time.Time
+	messageLimit rateLimiter
+
+	// sendMu and closed let reply (readPump) and the hub's close of send
+	// happen in any order (see ack.go).
+	sendMu sync.Mutex
+	closed bool
+
 	unregisterOnce sync.Once
 }
 
@@ -28,7 +82,7 @@ func (c *Client) requestUnregister() {
 
 // incomingMessage is what the frontend sends to the server via WS.
 type incomingMessage struct {
-	Type string `json:"type"` // "message" | "delivered" | "seen" | "typing"
+	Type string `json:"type"` // "message" | "delivered" | "seen" | "typing" | "subscribe" | "unsubscribe"
 
 	// message
 	ToID   int64  `json:"to_user_id,omitempty"`
@@ -43,6 +97,11 @@ type incomingMessage struct {
 
 	// typing
 	IsTyping bool `json:"is_typing,omitempty"`
+
+	// subscribe / unsubscribe
+	Scope    string `json:"scope,omitempty"` // "feed" | "category" | "post"
+	Category string `json:"category,omitempty"`
+	PostID   int64  `json:"post_id,omitempty"`
 }
 
 // TypingEvent is sent to the recipient to show typing status.
@@ -53,6 +112,12 @@ type TypingEvent struct {
 	IsTyping   bool   `json:"is_typing"`
 }
 
+// closeWith tells the client why the server is hanging up (best effort).
+func (c *Client) closeWith(code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(c.hub.Limits.WriteWait))
+}
+
 // readPump reads JSON frames from the WS connection and routes them to the hub.
 func (c *Client) readPump() {
 	defer func() {
@@ -61,150 +126,280 @@ func (c *Client) readPump() {
 		_ = c.conn.Close()
 	}()
 
-	// Simple backend throttle for typing to avoid accidental spam.
-	// (Frontend should also throttle, but this is extra safety.)
-	const typingMinInterval = 200 * time.Millisecond
-	var lastTypingSent time.Time
+	// Oversized messages fail the read, and gorilla closes with 1009
+	// (message too big) itself. Each pong buys the client another PongWait.
+	limits := c.hub.Limits
+	c.conn.SetReadLimit(limits.MaxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(limits.PongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(limits.PongWait))
+	})
 
 	for {
-		var in incomingMessage
-		if err := c.conn.ReadJSON(&in); err != nil {
+		kind, data, err := c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if kind != websocket.TextMessage {
+			c.closeWith(websocket.CloseUnsupportedData, "text frames only")
 			break
 		}
+		// Only bytes that are not JSON end the connection; JSON of the
+		// wrong shape is answered like any other invalid operation.
+		if !json.Valid(data) {
+			c.closeWith(websocket.CloseInvalidFramePayloadData, "invalid json")
+			break
+		}
+		op, tempID := peekEnvelope(data)
 
-		log.Printf("[WS] received: %+v\n", in)
+		var in incomingMessage
+		var id int64
+		var opErr *opError
+		if err := json.Unmarshal(data, &in); err != nil {
+			opErr = decodeError(err)
+		} else {
+			log.Printf("[WS] received: %+v\n", in)
+			in.Type = op
+			id, opErr = c.handle(in)
+		}
 
-		// Default to "message" if omitted (keeps frontend simpler).
-		if in.Type == "" {
-			in.Type = "message"
+		if tempID == "" {
+			continue
 		}
+		if opErr != nil {
+			c.reply(ErrorEvent{Type: "error", Op: op, TempID: tempID, Code: opErr.code, Message: opErr.message})
+			continue
+		}
+		c.reply(AckEvent{Type: "ack", Op: op, TempID: tempID, ID: id})
+	}
+}
 
-		switch in.Type {
+// peekEnvelope reads the type and temp_id of a frame whose other fields
+// may not decode, so even a malformed operation can be answered. The type
+// defaults to "message" (keeps frontend simpler); a temp_id that is not a
+// string is echoed as its JSON text.
+func peekEnvelope(data []byte) (op, tempID string) {
+	var env struct {
+		Type   json.RawMessage `json:"type"`
+		TempID json.RawMessage `json:"temp_id"`
+	}
+	_ = json.Unmarshal(data, &env)
 
-		// ------------------------------------------------------------
-		// 1) MESSAGE: sender -> server (persist) -> broadcast to sender+recipient
-		// ------------------------------------------------------------
-		case "message":
-			// Basic validation.
-			if in.ToID <= 0 || in.Text == "" {
-				continue
-			}
-			// do not allow yourself to be sent by WS
-			if in.ToID == c.userID {
-				continue
-			}
+	if json.Unmarshal(env.Type, &op) != nil || op == "" {
+		op = "message"
+	}
+	if len(env.TempID) > 0 && json.Unmarshal(env.TempID, &tempID) != nil && string(env.TempID) != "null" {
+		tempID = string(env.TempID)
+	}
+	return op, tempID
+}
 
-			ev := MessageEvent{
-				Type:       "message",
-				FromUserID: c.userID,
-				ToUserID:   in.ToID,
-				Content:    in.Text,
-				Seen:       false,
-				TempID:     in.TempID,
-			}
+// decodeError explains why a JSON frame does not fit incomingMessage.
+func decodeError(err error) *opError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return fail(CodeValidation, typeErr.Field+" has the wrong type")
+	}
+	return fail(CodeValidation, "operation must be a JSON object")
+}
 
-			// Persist to DB before broadcasting (if configured).
-			if c.hub.OnMessage != nil {
-				out, err := c.hub.OnMessage(context.Background(), ev)
-				if err != nil {
-					continue
-				}
-				ev = out
-			}
+// Simple backend throttles against accidental spam (the frontend should
+// also throttle, but this is extra safety): typing updates at most every
+// typingMinInterval, chat messages in bursts of messageBurst refilled at
+// messageRate per second.
+const (
+	typingMinInterval = 200 * time.Millisecond
+	messageRate       = 5
+	messageBurst      = 20
+)
 
-			// Broadcast to both sides (hub decides routing).
-			c.hub.broadcast <- ev
+// handle runs one client operation. It returns the ID to acknowledge, if
+// any, or why the operation failed.
+func (c *Client) handle(in incomingMessage) (int64, *opError) {
+	switch in.Type {
+
+	// ------------------------------------------------------------
+	// 1) MESSAGE: sender -> server (persist) -> deliver to sender+recipient
+	// ------------------------------------------------------------
+	case "message":
+		// Basic validation.
+		if in.ToID <= 0 || strings.TrimSpace(in.Text) == "" {
+			return 0, fail(CodeValidation, "to_user_id and text are required")
+		}
+		// do not allow yourself to be sent by WS
+		if in.ToID == c.userID {
+			return 0, fail(CodeValidation, "cannot message yourself")
+		}
+		if !c.messageLimit.allow(time.Now()) {
+			return 0, fail(CodeRateLimited, "too many messages, slow down")
+		}
 
-		// ------------------------------------------------------------
-		// 2) DELIVERED: recipient -> server (mark delivered) -> notify sender
-		// ------------------------------------------------------------
-		case "delivered":
-			if in.MessageID <= 0 {
-				continue
-			}
-			if c.hub.OnDelivered == nil {
-				continue
-			}
+		ev := MessageEvent{
+			Type:       "message",
+			FromUserID: c.userID,
+			ToUserID:   in.ToID,
+			Content:    in.Text,
+			Seen:       false,
+			TempID:     in.TempID,
+		}
 
-			fromUserID, deliveredAt, err := c.hub.OnDelivered(context.Background(), c.userID, in.MessageID)
+		// Persist to DB before delivering (if configured).
+		if c.hub.OnMessage != nil {
+			ctx, cancel := callbackContext()
+			out, err := c.hub.OnMessage(ctx, ev)
+			cancel()
 			if err != nil {
-				continue
+				if !errors.Is(err, ErrBlocked) {
+					log.Println("[WS] Message error:", err)
+				}
+				return 0, callbackError(err)
 			}
+			ev = out
+		}
+
+		// Deliver to both sides (all tabs); each gets it under their own seq.
+		c.hub.deliver(ev.FromUserID, ev)
+		c.hub.deliver(ev.ToUserID, ev)
+		return ev.ID, nil
+
+	// ------------------------------------------------------------
+	// 2) DELIVERED: recipient -> server (mark delivered) -> notify sender
+	// ------------------------------------------------------------
+	case "delivered":
+		if in.MessageID <= 0 {
+			return 0, fail(CodeValidation, "message_id is required")
+		}
+		if c.hub.OnDelivered == nil {
+			return in.MessageID, nil
+		}
 
-			ack := DeliveredEvent{
-				Type:        "delivered",
-				MessageID:   in.MessageID,
-				FromUserID:  fromUserID,
-				ToUserID:    c.userID,
-				DeliveredAt: deliveredAt,
+		ctx, cancel := callbackContext()
+		fromUserID, deliveredAt, err := c.hub.OnDelivered(ctx, c.userID, in.MessageID)
+		cancel()
+		if err != nil {
+			if !errors.Is(err, ErrBlocked) {
+				log.Println("[WS] Delivered error:", err)
 			}
+			return 0, callbackError(err)
+		}
 
-			c.hub.sendToUser(fromUserID, ack)
+		ack := DeliveredEvent{
+			Type:        "delivered",
+			MessageID:   in.MessageID,
+			FromUserID:  fromUserID,
+			ToUserID:    c.userID,
+			DeliveredAt: deliveredAt,
+		}
 
-		// ------------------------------------------------------------
-		// 3) SEEN: viewer opened chat -> server (mark seen) -> notify sender
-		// ------------------------------------------------------------
-		case "seen":
-			otherID := in.FromUserID
-			if otherID <= 0 {
-				continue
-			}
-			if c.hub.OnSeen == nil {
-				continue
-			}
+		c.hub.deliver(fromUserID, ack)
+		return in.MessageID, nil
 
-			fromUserID, toUserID, seenUpToID, seenAt, err := c.hub.OnSeen(context.Background(), c.userID, otherID)
-			if err != nil {
-				continue
-			}
+	// ------------------------------------------------------------
+	// 3) SEEN: viewer opened chat -> server (mark seen) -> notify sender
+	// ------------------------------------------------------------
+	case "seen":
+		otherID := in.FromUserID
+		if otherID <= 0 {
+			return 0, fail(CodeValidation, "from_user_id is required")
+		}
+		if c.hub.OnSeen == nil {
+			return 0, nil
+		}
 
-			ev := SeenEvent{
-				Type:       "seen",
-				FromUserID: fromUserID,
-				ToUserID:   toUserID,
-				SeenUpToID: seenUpToID,
-				SeenAt:     seenAt,
+		ctx, cancel := callbackContext()
+		fromUserID, toUserID, seenUpToID, seenAt, err := c.hub.OnSeen(ctx, c.userID, otherID)
+		cancel()
+		if err != nil {
+			if !errors.Is(err, ErrBlocked) {
+				log.Println("[WS] Seen error:", err)
 			}
+			return 0, callbackError(err)
+		}
 
-			c.hub.sendToUser(fromUserID, ev)
+		ev := SeenEvent{
+			Type:       "seen",
+			FromUserID: fromUserID,
+			ToUserID:   toUserID,
+			SeenUpToID: seenUpToID,
+			SeenAt:     seenAt,
+		}
 
-		// ------------------------------------------------------------
-		// 4) TYPING: user -> server -> recipient (no DB)
-		// ------------------------------------------------------------
-		case "typing":
-			if in.ToID <= 0 {
-				continue
-			}
+		// Only receipts that marked something are worth replaying.
+		if seenUpToID > 0 {
+			c.hub.deliver(fromUserID, ev)
+		} else {
+			c.hub.sendToUser(fromUserID, ev)
+		}
+		return seenUpToID, nil
+
+	// ------------------------------------------------------------
+	// 4) TYPING: user -> server -> recipient (no DB)
+	// ------------------------------------------------------------
+	case "typing":
+		if in.ToID <= 0 {
+			return 0, fail(CodeValidation, "to_user_id is required")
+		}
 
-			// throttle typing spam per-connection
-			now := time.Now()
-			if !lastTypingSent.IsZero() && now.Sub(lastTypingSent) < typingMinInterval {
-				continue
-			}
-			lastTypingSent = now
+		// throttle typing spam per-connection
+		now := time.Now()
+		if !c.lastTyping.IsZero() && now.Sub(c.lastTyping) < typingMinInterval {
+			return 0, fail(CodeRateLimited, "typing updates are too frequent")
+		}
+		c.lastTyping = now
 
-			ev := TypingEvent{
-				Type:       "typing",
-				FromUserID: c.userID,
-				ToUserID:   in.ToID,
-				IsTyping:   in.IsTyping,
-			}
+		ev := TypingEvent{
+			Type:       "typing",
+			FromUserID: c.userID,
+			ToUserID:   in.ToID,
+			IsTyping:   in.IsTyping,
+		}
 
-			c.hub.sendToUser(in.ToID, ev)
+		c.hub.sendToUser(in.ToID, ev)
+		return 0, nil
 
-		default:
-			continue
+	// ------------------------------------------------------------
+	// 5) SUBSCRIBE / UNSUBSCRIBE: live feed (no DB, see feed.go)
+	// ------------------------------------------------------------
+	case "subscribe", "unsubscribe":
+		if opErr := c.subs.set(in.Scope, in.Category, in.PostID, in.Type == "subscribe"); opErr != nil {
+			return 0, opErr
 		}
+		return 0, nil
+
+	default:
+		return 0, fail(CodeValidation, "unknown operation type")
 	}
 }
 
-// writePump writes outgoing events to the WS connection.
+// writePump writes outgoing events to the WS connection and pings the
+// client every PingInterval. Every write has a WriteWait deadline, so a
+// client that stops reading cannot stall it.
 func (c *Client) writePump() {
-	defer func() { _ = c.conn.Close() }()
+	limits := c.hub.Limits
+	ticker := time.NewTicker(limits.PingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
 
-	for msg := range c.send {
-		if err := c.conn.WriteJSON(msg); err != nil {
-			break
+	for {
+		select {
+		case msg, ok := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(limits.WriteWait))
+			if !ok {
+				// The hub dropped this client; say goodbye properly.
+				_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(limits.WriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
 		}
 	}
 }
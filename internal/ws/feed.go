@@ -0,0 +1,154 @@
+// internal/ws/feed.go
+package ws
+
+import (
+	"strings"
+	"sync"
+)
+
+// Live feed: clients subscribe to the whole feed, a category or a single
+// post, and the server publishes events about published posts to whoever
+// is subscribed to them.
+//
+//	{"type": "subscribe",   "scope": "feed"}
+//	{"type": "subscribe",   "scope": "category", "category": "Travel"}
+//	{"type": "subscribe",   "scope": "post", "post_id": 12}
+//	{"type": "unsubscribe", ...same fields}
+
+// Subscription scopes.
+const (
+	ScopeFeed     = "feed"
+	ScopeCategory = "category"
+	ScopePost     = "post"
+)
+
+// Most posts and categories one client may subscribe to at a time.
+const (
+	maxPostSubs     = 50
+	maxCategorySubs = 30
+)
+
+// Topic says which post (and so which category) an event is about.
+type Topic struct {
+	PostID   int64
+	Category string
+}
+
+// PostCreatedEvent announces a newly published post. Post is the post as
+// an anonymous viewer sees it (no i_reacted, my_reactions, ...).
+type PostCreatedEvent struct {
+	Type   string `json:"type"` // "post_created"
+	PostID int64  `json:"post_id"`
+	Post   any    `json:"post"`
+}
+
+// CommentCreatedEvent announces a new comment or reply on a post.
+type CommentCreatedEvent struct {
+	Type    string `json:"type"` // "comment_created"
+	PostID  int64  `json:"post_id"`
+	Comment any    `json:"comment"`
+}
+
+// ReactionChangedEvent carries fresh reaction counts for a post, or for one
+// of its comments when CommentID is set.
+type ReactionChangedEvent struct {
+	Type      string           `json:"type"` // "reaction_changed"
+	PostID    int64            `json:"post_id"`
+	CommentID int64            `json:"comment_id,omitempty"`
+	Reactions map[string]int64 `json:"reactions"` // reaction -> count
+}
+
+// PostUpdatedEvent carries either the whole post after an edit, pin, lock
+// or accepted answer (as an anonymous viewer sees it), or just its new
+// view count.
+type PostUpdatedEvent struct {
+	Type       string `json:"type"` // "post_updated"
+	PostID     int64  `json:"post_id"`
+	Post       any    `json:"post,omitempty"`
+	ViewsCount int64  `json:"views_count,omitempty"`
+}
+
+// subscriptions is what one client listens to. readPump changes it while
+// the hub reads it, hence the lock.
+type subscriptions struct {
+	mu         sync.Mutex
+	feed       bool
+	categories map[string]bool // lower-cased names
+	posts      map[int64]bool
+}
+
+// set adds (on) or removes a subscription. It fails for an unknown scope,
+// a missing category/post, or a new one past maxPostSubs/maxCategorySubs.
+func (s *subscriptions) set(scope, category string, postID int64, on bool) *opError {
+	category = strings.ToLower(strings.TrimSpace(category))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch scope {
+	case ScopeFeed:
+		s.feed = on
+	case ScopeCategory:
+		if category == "" {
+			return fail(CodeValidation, "category is required")
+		}
+		if s.categories == nil {
+			s.categories = make(map[string]bool)
+		}
+		if on {
+			if !s.categories[category] && len(s.categories) >= maxCategorySubs {
+				return fail(CodeValidation, "too many category subscriptions")
+			}
+			s.categories[category] = true
+		} else {
+			delete(s.categories, category)
+		}
+	case ScopePost:
+		if postID <= 0 {
+			return fail(CodeValidation, "post_id is required")
+		}
+		if s.posts == nil {
+			s.posts = make(map[int64]bool)
+		}
+		if on {
+			if !s.posts[postID] && len(s.posts) >= maxPostSubs {
+				return fail(CodeValidation, "too many post subscriptions")
+			}
+			s.posts[postID] = true
+		} else {
+			delete(s.posts, postID)
+		}
+	default:
+		return fail(CodeValidation, "scope must be feed, category or post")
+	}
+	return nil
+}
+
+// matches reports whether an event about t should reach this client.
+func (s *subscriptions) matches(t Topic) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.feed ||
+		s.posts[t.PostID] ||
+		(t.Category != "" && s.categories[strings.ToLower(t.Category)])
+}
+
+// Publish sends a live feed event to every client subscribed to the feed,
+// to t's category or to t's post.
+func (h *Hub) Publish(t Topic, payload any) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, set := range h.clientsByUser {
+		for c := range set {
+			if !c.subs.matches(t) {
+				continue
+			}
+			select {
+			case c.send <- payload:
+			default:
+				go c.requestUnregister()
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/websocket"
@@ -31,7 +32,18 @@ func checkOrigin(r *http.Request) bool {
 
 // HandleChat upgrades the incoming request to a WebSocket connection
 // and registers a new client within the Hub using the provided user ID.
+// A reconnecting tab passes ?since=<seq> to get the chat events it missed
+// replayed first.
 func (h *Hub) HandleChat(w http.ResponseWriter, r *http.Request, userID int64) {
+	since := int64(-1)
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
 
 	// Attempt to establish a WebSocket connection.
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -48,10 +60,13 @@ func (h *Hub) HandleChat(w http.ResponseWriter, r *http.Request, userID int64) {
 		send: make(chan any, 256),
 
 		userID: userID,
+		since:  since,
+
+		messageLimit: rateLimiter{rate: messageRate, burst: messageBurst},
 	}
 
-	// Register the client with the Hub.
-	h.register <- client
+	// Replay what the client missed and register it with the Hub.
+	h.connect(client)
 
 	// Start goroutines responsible for reading and writing messages.
 	go client.writePump()
@@ -1,8 +1,16 @@
 package ws
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func TestCheckOrigin(t *testing.T) {
@@ -34,3 +42,264 @@ func TestCheckOrigin(t *testing.T) {
 		})
 	}
 }
+
+// startChat serves /ws/chat for user 1 on a hub with the given limits and
+// dials it.
+func startChat(t *testing.T, limits Limits) (*Hub, *websocket.Conn) {
+	t.Helper()
+
+	hub := NewHub()
+	hub.Limits = limits
+	go hub.Run()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleChat(w, r, 1)
+	}))
+	t.Cleanup(ts.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return hub, conn
+}
+
+// waitOnline waits until user 1's online count is want.
+func waitOnline(t *testing.T, hub *Hub, want int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		hub.mu.RLock()
+		n := hub.onlineCount[1]
+		hub.mu.RUnlock()
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("online count = %d, want %d", n, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSilentClientGoesOffline(t *testing.T) {
+	limits := DefaultLimits()
+	limits.PingInterval = 20 * time.Millisecond
+	limits.PongWait = 100 * time.Millisecond
+	hub, conn := startChat(t, limits)
+
+	// A client that reads answers pings and stays online.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(5 * limits.PongWait)
+	waitOnline(t, hub, 1)
+
+	// One that never reads never answers, and is dropped once the pong wait
+	// runs out.
+	silentHub, _ := startChat(t, limits)
+	waitOnline(t, silentHub, 1)
+	waitOnline(t, silentHub, 0)
+}
+
+func TestBadMessagesCloseWithCode(t *testing.T) {
+	limits := DefaultLimits()
+	limits.MaxMessageSize = 64
+
+	tests := []struct {
+		name string
+		kind int
+		data string
+		code int
+	}{
+		{name: "too big", kind: websocket.TextMessage, data: `{"type":"typing","text":"` + strings.Repeat("x", 100) + `"}`, code: websocket.CloseMessageTooBig},
+		{name: "invalid json", kind: websocket.TextMessage, data: `{"type":`, code: websocket.CloseInvalidFramePayloadData},
+		{name: "binary", kind: websocket.BinaryMessage, data: `{}`, code: websocket.CloseUnsupportedData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, conn := startChat(t, limits)
+			if err := conn.WriteMessage(tt.kind, []byte(tt.data)); err != nil {
+				t.Fatal(err)
+			}
+
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			for {
+				_, _, err := conn.ReadMessage()
+				if err == nil {
+					continue
+				}
+				if !websocket.IsCloseError(err, tt.code) {
+					t.Fatalf("read error = %v, want close code %d", err, tt.code)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestOperationsGetAckOrError(t *testing.T) {
+	hub := NewHub()
+	hub.OnMessage = func(ctx context.Context, in MessageEvent) (MessageEvent, error) {
+		switch in.ToUserID {
+		case 3:
+			return in, fmt.Errorf("no such user: %w", ErrBlocked)
+		case 4:
+			return in, errors.New("disk full")
+		}
+		in.ID = 7
+		return in, nil
+	}
+	go hub.Run()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleChat(w, r, 1)
+	}))
+	t.Cleanup(ts.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	// reply sends frame and returns the ack or error that answers it.
+	reply := func(frame string) map[string]any {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		for {
+			var ev map[string]any
+			if err := conn.ReadJSON(&ev); err != nil {
+				t.Fatal(err)
+			}
+			if ev["type"] == "ack" || ev["type"] == "error" {
+				return ev
+			}
+		}
+	}
+
+	tests := []struct {
+		name  string
+		frame string
+		want  string // "ack" or an error code
+	}{
+		{name: "message", frame: `{"type":"message","to_user_id":2,"text":"hi","temp_id":"a"}`, want: "ack"},
+		{name: "empty text", frame: `{"type":"message","to_user_id":2,"text":"  ","temp_id":"b"}`, want: CodeValidation},
+		{name: "to self", frame: `{"type":"message","to_user_id":1,"text":"hi","temp_id":"c"}`, want: CodeValidation},
+		{name: "blocked", frame: `{"type":"message","to_user_id":3,"text":"hi","temp_id":"d"}`, want: CodeBlocked},
+		{name: "persistence fails", frame: `{"type":"message","to_user_id":4,"text":"hi","temp_id":"e"}`, want: CodeInternal},
+		{name: "unknown type", frame: `{"type":"dance","temp_id":"f"}`, want: CodeValidation},
+		{name: "bad subscribe", frame: `{"type":"subscribe","scope":"post","temp_id":"g"}`, want: CodeValidation},
+		{name: "typing", frame: `{"type":"typing","to_user_id":2,"is_typing":true,"temp_id":"h"}`, want: "ack"},
+		{name: "typing too soon", frame: `{"type":"typing","to_user_id":2,"is_typing":false,"temp_id":"i"}`, want: CodeRateLimited},
+		{name: "wrong field type", frame: `{"type":"message","to_user_id":"x","text":"hi","temp_id":"l"}`, want: CodeValidation},
+		{name: "wrong temp_id type", frame: `{"type":"subscribe","scope":"feed","temp_id":5}`, want: CodeValidation},
+	}
+
+	for _, tt := range tests {
+		ev := reply(tt.frame)
+		got, _ := ev["code"].(string)
+		if ev["type"] == "ack" {
+			got = "ack"
+		}
+		if got != tt.want {
+			t.Fatalf("%s: reply = %v, want %s", tt.name, ev, tt.want)
+		}
+	}
+
+	// A temp_id of the wrong type still comes back, as its JSON text.
+	if ev := reply(`{"type":"subscribe","scope":"feed","temp_id":7}`); ev["temp_id"] != "7" || ev["code"] != CodeValidation {
+		t.Fatalf("reply = %v, want a validation error for temp_id 7", ev)
+	}
+
+	// An ack says what was stored; operations without a temp_id are not
+	// answered, so the next reply is for "k".
+	if ev := reply(`{"type":"message","to_user_id":2,"text":"hi","temp_id":"j"}`); ev["op"] != "message" || ev["temp_id"] != "j" || ev["id"] != float64(7) {
+		t.Fatalf("ack = %v", ev)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"message","text":""}`)); err != nil {
+		t.Fatal(err)
+	}
+	if ev := reply(`{"type":"subscribe","scope":"feed","temp_id":"k"}`); ev["type"] != "ack" || ev["temp_id"] != "k" {
+		t.Fatalf("reply = %v, want ack for k", ev)
+	}
+}
+
+func TestMessageRateLimit(t *testing.T) {
+	l := rateLimiter{rate: 5, burst: 2}
+	now := time.Now()
+	if !l.allow(now) || !l.allow(now) {
+		t.Fatal("burst was not allowed")
+	}
+	if l.allow(now) {
+		t.Fatal("allowed past the burst")
+	}
+	if !l.allow(now.Add(200 * time.Millisecond)) {
+		t.Fatal("token was not refilled")
+	}
+}
+
+func TestSlowEventLogLeavesHubRunning(t *testing.T) {
+	release := make(chan struct{})
+	hub := NewHub()
+	hub.OnEvent = func(ctx context.Context, userID int64, eventType, payload string) (int64, error) {
+		<-release
+		return 0, errors.New("database is locked")
+	}
+	go hub.Run()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.HandleChat(w, r, 1)
+	}))
+	t.Cleanup(ts.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"message","to_user_id":2,"text":"hi","temp_id":"a"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// While the log is stuck, other clients still come and go.
+	other := &Client{hub: hub, send: make(chan any, 4), userID: 9}
+	hub.register <- other
+	waitForClientState(t, hub, other, true)
+	hub.unregister <- other
+	waitForClientState(t, hub, other, false)
+
+	// Once it fails, the message still arrives, without a seq, and the
+	// client is told its history has a gap.
+	close(release)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var got []map[string]any
+	for len(got) < 3 {
+		var ev map[string]any
+		if err := conn.ReadJSON(&ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev["type"] == "presence_snapshot" || ev["type"] == "presence" {
+			continue
+		}
+		got = append(got, ev)
+	}
+	if got[0]["type"] != "message" || got[0]["seq"] != nil {
+		t.Fatalf("first event = %v, want the message without a seq", got[0])
+	}
+	if got[1]["type"] != "error" || got[1]["code"] != CodeUnlogged || got[1]["op"] != "message" {
+		t.Fatalf("second event = %v, want an unlogged error", got[1])
+	}
+	if got[2]["type"] != "ack" || got[2]["temp_id"] != "a" {
+		t.Fatalf("third event = %v, want the ack", got[2])
+	}
+}
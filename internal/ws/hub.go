@@ -3,9 +3,19 @@ package ws
 
 import (
 	"context"
+	"log"
 	"sync"
+	"time"
 )
 
+// callbackTimeout bounds each hub callback; they all go to the database.
+const callbackTimeout = 5 * time.Second
+
+// callbackContext returns the context a hub callback runs under.
+func callbackContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), callbackTimeout)
+}
+
 // MessageEvent is the payload for chat messages.
 type MessageEvent struct {
 	Type       string `json:"type"` // "message"
@@ -16,6 +26,7 @@ type MessageEvent struct {
 	SentAt     string `json:"sent_at"`           // RFC3339 for frontend
 	Seen       bool   `json:"seen"`              // optional shortcut (or compute from seen_at)
 	TempID     string `json:"temp_id,omitempty"` // optimistic UI reconciliation
+	Seq        int64  `json:"seq,omitempty"`     // position in the recipient's event stream (see replay.go)
 }
 
 // Presence Event
@@ -38,6 +49,7 @@ type DeliveredEvent struct {
 	FromUserID  int64  `json:"from_user_id"`
 	ToUserID    int64  `json:"to_user_id"`
 	DeliveredAt string `json:"delivered_at,omitempty"` // RFC3339 optional
+	Seq         int64  `json:"seq,omitempty"`
 }
 
 // SeenEvent means "the recipient opened the conversation and saw messages".
@@ -47,6 +59,27 @@ type SeenEvent struct {
 	ToUserID   int64  `json:"to_user_id"`   // viewer (who saw)
 	SeenUpToID int64  `json:"seen_up_to_id,omitempty"`
 	SeenAt     string `json:"seen_at,omitempty"` // RFC3339 optional
+	Seq        int64  `json:"seq,omitempty"`
+}
+
+// PollEvent carries fresh vote counts for a post's poll.
+type PollEvent struct {
+	Type       string          `json:"type"` // "poll_update"
+	PostID     int64           `json:"post_id"`
+	PollID     int64           `json:"poll_id"`
+	TotalVotes int64           `json:"total_votes"`
+	Votes      map[int64]int64 `json:"votes"` // option ID -> votes
+}
+
+// CommentDeletedEvent tells viewers of a post that a comment was deleted:
+// either kept as a tombstone, or removed along with any tombstones it
+// emptied (Removed).
+type CommentDeletedEvent struct {
+	Type       string  `json:"type"` // "comment_deleted"
+	PostID     int64   `json:"post_id"`
+	CommentID  int64   `json:"comment_id"`
+	Tombstoned bool    `json:"tombstoned"`
+	Removed    []int64 `json:"removed"`
 }
 
 // Hub manages all active WebSocket clients and routes events between them.
@@ -59,9 +92,6 @@ type Hub struct {
 	register   chan *Client
 	unregister chan *Client
 
-	// broadcast is for chat messages (persisted and normalized).
-	broadcast chan MessageEvent
-
 	// OnMessage persists the message (DB) and returns the final event to broadcast.
 	OnMessage func(ctx context.Context, in MessageEvent) (MessageEvent, error)
 
@@ -73,6 +103,18 @@ type Hub struct {
 	onlineCount map[int64]int
 
 	OnOffline func(ctx context.Context, userID int64) (lastSeenRFC3339 string, err error)
+
+	// Event log for reconnect replay (see replay.go): OnEvent appends a
+	// chat event to a user's log and returns its seq; OnReplay returns up to
+	// limit events after since, the latest seq, and whether that covers
+	// everything missed. seqMu orders logging, sending and replays; Run
+	// never takes it, so the database cannot stall the hub.
+	OnEvent  func(ctx context.Context, userID int64, eventType, payload string) (seq int64, err error)
+	OnReplay func(ctx context.Context, userID, since int64, limit int) (events []StoredEvent, lastSeq int64, complete bool, err error)
+	seqMu    sync.Mutex
+
+	// Limits bound each connection; change them before serving clients.
+	Limits Limits
 }
 
 // NewHub creates a new Hub with initialized channels and storage.
@@ -81,12 +123,14 @@ func NewHub() *Hub {
 		clientsByUser: make(map[int64]map[*Client]bool),
 		register:      make(chan *Client),
 		unregister:    make(chan *Client),
-		broadcast:     make(chan MessageEvent, 256),
 		onlineCount:   make(map[int64]int),
+		Limits:        DefaultLimits(),
 	}
 }
 
-// Run listens for register/unregister and broadcast events.
+// Run listens for register/unregister events. It does no database work
+// itself: chat events are logged by the readPump that sends them, replays
+// run in connect, and OnOffline in a goroutine of its own.
 func (h *Hub) Run() {
 	for {
 		select {
@@ -111,6 +155,10 @@ func (h *Hub) Run() {
 			}
 
 			h.mu.Unlock()
+			if c.registered != nil {
+				close(c.registered)
+			}
+
 			// 1) send snapshot ONLY Online client
 			c.send <- PresenceSnapshotEvent{Type: "presence_snapshot", Online: onlineIDs}
 
@@ -147,31 +195,41 @@ func (h *Hub) Run() {
 			}
 
 			if becameOffline {
-				lastSeen := ""
-				if h.OnOffline != nil {
-					if ts, err := h.OnOffline(context.Background(), c.userID); err == nil {
-						lastSeen = ts
-					}
-				}
-
-				h.broadcastToAll(PresenceEvent{
-					Type:       "presence",
-					UserID:     c.userID,
-					Online:     false,
-					LastSeenAt: lastSeen,
-				})
+				go h.announceOffline(c.userID)
 			}
 			// Close send channel AFTER removing from maps (safe for writePump range).
-			close(c.send)
+			c.closeSend()
+		}
+	}
+}
 
-		case msg := <-h.broadcast:
-			// Deliver message to sender and recipient (all tabs).
-			h.sendToUser(msg.FromUserID, msg)
-			if msg.ToUserID != msg.FromUserID {
-				h.sendToUser(msg.ToUserID, msg)
-			}
+// announceOffline records when userID was last seen and tells everyone
+// they went offline, unless a new tab of theirs registered meanwhile (its
+// online event is then the latest word).
+func (h *Hub) announceOffline(userID int64) {
+	lastSeen := ""
+	if h.OnOffline != nil {
+		ctx, cancel := callbackContext()
+		ts, err := h.OnOffline(ctx, userID)
+		cancel()
+		if err != nil {
+			log.Println("[WS] Offline error:", err)
+		} else {
+			lastSeen = ts
 		}
 	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.onlineCount[userID] > 0 {
+		return
+	}
+	h.sendToAllLocked(PresenceEvent{
+		Type:       "presence",
+		UserID:     userID,
+		Online:     false,
+		LastSeenAt: lastSeen,
+	})
 }
 
 // sendToUser sends any WS event to all connected tabs for a given user.
@@ -199,6 +257,11 @@ func (h *Hub) sendToUser(userID int64, payload any) {
 func (h *Hub) broadcastToAll(payload any) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
+	h.sendToAllLocked(payload)
+}
+
+// sendToAllLocked is broadcastToAll for a caller already holding h.mu.
+func (h *Hub) sendToAllLocked(payload any) {
 	for userID := range h.clientsByUser {
 		// send each online client
 		for c := range h.clientsByUser[userID] {
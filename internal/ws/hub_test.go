@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"fmt"
 	"testing"
 	"time"
 )
@@ -68,6 +69,81 @@ func TestClientRequestsUnregisterOnlyOnce(t *testing.T) {
 	}
 }
 
+func TestPublishReachesSubscribers(t *testing.T) {
+	hub := NewHub()
+
+	feed := &Client{hub: hub, send: make(chan any, 4), userID: 1}
+	travel := &Client{hub: hub, send: make(chan any, 4), userID: 2}
+	post := &Client{hub: hub, send: make(chan any, 4), userID: 3}
+	none := &Client{hub: hub, send: make(chan any, 4), userID: 4}
+
+	feed.subs.set(ScopeFeed, "", 0, true)
+	travel.subs.set(ScopeCategory, " Travel ", 0, true)
+	post.subs.set(ScopePost, "", 12, true)
+	if none.subs.set(ScopePost, "", 0, true) == nil || none.subs.set("everything", "", 0, true) == nil {
+		t.Fatal("invalid subscriptions were accepted")
+	}
+
+	for _, c := range []*Client{feed, travel, post, none} {
+		hub.clientsByUser[c.userID] = map[*Client]bool{c: true}
+	}
+
+	received := func(c *Client) bool {
+		select {
+		case <-c.send:
+			return true
+		default:
+			return false
+		}
+	}
+	check := func(topic Topic, want map[*Client]bool) {
+		t.Helper()
+		hub.Publish(topic, PostUpdatedEvent{Type: "post_updated", PostID: topic.PostID})
+		for _, c := range []*Client{feed, travel, post, none} {
+			if got := received(c); got != want[c] {
+				t.Fatalf("topic %+v: client %d received = %v, want %v", topic, c.userID, got, want[c])
+			}
+		}
+	}
+
+	check(Topic{PostID: 12, Category: "travel"}, map[*Client]bool{feed: true, travel: true, post: true})
+	check(Topic{PostID: 13, Category: "Travel"}, map[*Client]bool{feed: true, travel: true})
+	check(Topic{PostID: 12, Category: "General"}, map[*Client]bool{feed: true, post: true})
+
+	travel.subs.set(ScopeCategory, "travel", 0, false)
+	post.subs.set(ScopePost, "", 12, false)
+	check(Topic{PostID: 12, Category: "Travel"}, map[*Client]bool{feed: true})
+}
+
+func TestSubscriptionsAreCapped(t *testing.T) {
+	var s subscriptions
+	for id := int64(1); id <= maxPostSubs; id++ {
+		if err := s.set(ScopePost, "", id, true); err != nil {
+			t.Fatalf("post %d: %v", id, err.message)
+		}
+	}
+	if err := s.set(ScopePost, "", maxPostSubs+1, true); err == nil || err.code != CodeValidation {
+		t.Fatalf("post past the cap: err = %v, want validation", err)
+	}
+	// Subscribing again to one already held, or making room, still works.
+	if err := s.set(ScopePost, "", 1, true); err != nil {
+		t.Fatalf("repeat subscription: %v", err.message)
+	}
+	s.set(ScopePost, "", 1, false)
+	if err := s.set(ScopePost, "", maxPostSubs+1, true); err != nil {
+		t.Fatalf("after unsubscribing: %v", err.message)
+	}
+
+	for i := 0; i < maxCategorySubs; i++ {
+		if err := s.set(ScopeCategory, fmt.Sprintf("c%d", i), 0, true); err != nil {
+			t.Fatalf("category %d: %v", i, err.message)
+		}
+	}
+	if err := s.set(ScopeCategory, "one more", 0, true); err == nil || err.code != CodeValidation {
+		t.Fatalf("category past the cap: err = %v, want validation", err)
+	}
+}
+
 func waitForClientState(t *testing.T, hub *Hub, client *Client, registered bool) {
 	t.Helper()
 
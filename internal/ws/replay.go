@@ -0,0 +1,164 @@
+// internal/ws/replay.go
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Chat events (message, delivered, seen) are numbered per user and logged
+// through OnEvent before they are sent. A tab that reconnects with
+// /ws/chat?since=<seq> is sent every logged event after seq (via OnReplay)
+// and then a SyncedEvent, before any live event. Typing, presence and live
+// feed events are not logged.
+//
+// An event the log fails to take is still sent, without a seq, followed by
+// an ErrorEvent with Op set to its type, no TempID and code CodeUnlogged:
+// the client's seq no longer covers everything it was sent, and it should
+// reload its chat history.
+
+// replayLimit caps how many events a reconnecting tab is replayed; it
+// stays well under the client's send buffer.
+const replayLimit = 200
+
+// StoredEvent is a logged chat event, as OnReplay returns it.
+type StoredEvent struct {
+	Seq     int64
+	Type    string
+	Payload string // the event as JSON, without its seq
+}
+
+// SyncedEvent ends the replay on connect. Seq is the user's latest event;
+// Complete is false when the missed events could not be replayed (too many,
+// or no longer logged), and the client should reload its history instead.
+type SyncedEvent struct {
+	Type     string `json:"type"` // "synced"
+	Seq      int64  `json:"seq"`
+	Complete bool   `json:"complete"`
+}
+
+// loggedEvent is a chat event kept in each user's log.
+type loggedEvent interface {
+	eventType() string
+	withSeq(seq int64) loggedEvent
+}
+
+func (e MessageEvent) eventType() string   { return e.Type }
+func (e DeliveredEvent) eventType() string { return e.Type }
+func (e SeenEvent) eventType() string      { return e.Type }
+
+func (e MessageEvent) withSeq(seq int64) loggedEvent   { e.Seq = seq; return e }
+func (e DeliveredEvent) withSeq(seq int64) loggedEvent { e.Seq = seq; return e }
+func (e SeenEvent) withSeq(seq int64) loggedEvent      { e.Seq = seq; return e }
+
+// decodeEvent turns a logged event back into the event it was sent as.
+func decodeEvent(stored StoredEvent) (loggedEvent, error) {
+	var ev loggedEvent
+	var err error
+	switch stored.Type {
+	case "message":
+		var e MessageEvent
+		err = json.Unmarshal([]byte(stored.Payload), &e)
+		ev = e
+	case "delivered":
+		var e DeliveredEvent
+		err = json.Unmarshal([]byte(stored.Payload), &e)
+		ev = e
+	case "seen":
+		var e SeenEvent
+		err = json.Unmarshal([]byte(stored.Payload), &e)
+		ev = e
+	default:
+		return nil, fmt.Errorf("unknown chat event type %q", stored.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ev.withSeq(stored.Seq), nil
+}
+
+// deliver logs ev in userID's event stream and sends it, stamped with its
+// seq, to all of the user's tabs. It runs on the sender's readPump, never
+// on the hub. seqMu keeps each user's events in seq order and out of the
+// way of a replay in progress.
+func (h *Hub) deliver(userID int64, ev loggedEvent) {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+
+	if h.OnEvent == nil {
+		h.sendToUser(userID, ev)
+		return
+	}
+
+	payload, err := json.Marshal(ev)
+	if err == nil {
+		ctx, cancel := callbackContext()
+		var seq int64
+		seq, err = h.OnEvent(ctx, userID, ev.eventType(), string(payload))
+		cancel()
+		if err == nil {
+			ev = ev.withSeq(seq)
+		}
+	}
+	h.sendToUser(userID, ev)
+
+	if err != nil {
+		// Delivered live, but a replay would miss it.
+		log.Println("[WS] Event log error:", err)
+		h.sendToUser(userID, ErrorEvent{
+			Type:    "error",
+			Op:      ev.eventType(),
+			Code:    CodeUnlogged,
+			Message: "chat history may be incomplete, reload it",
+		})
+	}
+}
+
+// connect replays what c missed and then registers it with the hub. It
+// holds seqMu throughout, so no logged event falls between the replay and
+// c receiving live events, and runs on the connecting request, so a slow
+// replay only holds up chat events, never the hub.
+func (h *Hub) connect(c *Client) {
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+
+	h.replay(c)
+
+	c.registered = make(chan struct{})
+	h.register <- c
+	<-c.registered
+}
+
+// replay queues what c missed since c.since, then a SyncedEvent.
+func (h *Hub) replay(c *Client) {
+	if h.OnReplay == nil {
+		return
+	}
+
+	limit := replayLimit
+	if c.since < 0 {
+		// A fresh tab: nothing to replay, it only needs the latest seq.
+		limit = 0
+	}
+
+	ctx, cancel := callbackContext()
+	stored, last, complete, err := h.OnReplay(ctx, c.userID, c.since, limit)
+	cancel()
+	if err != nil {
+		log.Println("[WS] Replay error:", err)
+		c.send <- SyncedEvent{Type: "synced", Seq: max(c.since, 0), Complete: false}
+		return
+	}
+
+	for _, s := range stored {
+		ev, err := decodeEvent(s)
+		if err != nil {
+			log.Println("[WS] Replay decode error:", err)
+			complete = false
+			continue
+		}
+		c.send <- ev
+	}
+	c.send <- SyncedEvent{Type: "synced", Seq: last, Complete: complete}
+}
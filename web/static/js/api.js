@@ -129,23 +129,30 @@ export function apiGetCurrentUser() {
   return request('/me')
 }
 
-// Fetch paginated posts: GET /api/posts?limit=10&offset=0
-// Returns: { posts: [], has_more: boolean, next_offset: number }
-export async function apiGetPosts(limit = 10, offset = 0) {
-  const data = await request(`/posts?limit=${encodeURIComponent(limit)}&offset=${encodeURIComponent(offset)}`)
+// Fetch paginated posts: GET /api/posts?limit=10&cursor=<next_cursor>&sort=hot&window=week
+// Returns: { posts: [], hasMore: boolean, nextCursor: string }
+// following = true lists only posts from followed authors and categories.
+export async function apiGetPosts(limit = 10, cursor = '', sort = 'new', window = '', tag = '', following = false, solved = '') {
+  const qs = new URLSearchParams({ limit: String(limit), sort })
+  if (cursor) qs.set('cursor', cursor)
+  if (window) qs.set('window', window)
+  if (tag) qs.set('tag', tag)
+  if (solved) qs.set('solved', solved)
+
+  const data = await request(`${following ? '/feed/following' : '/posts'}?${qs.toString()}`)
 
   const posts = Array.isArray(data?.posts) ? data.posts : []
   const hasMore = Boolean(data?.has_more)
-  const nextOffset = typeof data?.next_offset === 'number' ? data.next_offset : offset + posts.length
+  const nextCursor = typeof data?.next_cursor === 'string' ? data.next_cursor : ''
 
-  console.log('[API] apiGetPosts -> posts:', posts.length, 'has_more:', hasMore, 'next_offset:', nextOffset)
+  console.log('[API] apiGetPosts -> posts:', posts.length, 'has_more:', hasMore, 'next_cursor:', nextCursor)
 
-  return { posts, hasMore, nextOffset }
+  return { posts, hasMore, nextCursor }
 }
 
 export async function apiGetPost(id) {
   const data = await request(`/posts/${id}`)
-  return data || { post: null, comments: [] }
+  return data || { post: null }
 }
 
 export async function apiCreatePost(data) {
@@ -160,19 +167,132 @@ export async function apiCreatePost(data) {
   return res.post
 }
 
-export async function apiGetComments(id) {
-  const data = await request(`/posts/${id}/comments`)
-  return data || { comments: [] }
+// Moderators only: pin (scope "global" or "category") or unpin a post.
+export async function apiSetPinned(postId, pinned, scope = 'global') {
+  const res = await request(`/posts/${postId}/pin`, {
+    method: 'POST',
+    body: JSON.stringify({ pinned, scope }),
+  })
+  return res ? res.post : null
+}
+
+// Moderators only: close or reopen a thread for new comments.
+export async function apiSetLocked(postId, locked) {
+  const res = await request(`/posts/${postId}/lock`, {
+    method: 'POST',
+    body: JSON.stringify({ locked }),
+  })
+  return res ? res.post : null
+}
+
+// Saves (on = true) or unsaves a post. Returns { bookmarked }.
+export async function apiSetBookmark(postId, on) {
+  return request(`/posts/${postId}/bookmark`, { method: on ? 'POST' : 'DELETE' })
+}
+
+// The current user's saved posts, most recently saved first.
+export async function apiGetBookmarks(limit = 10, cursor = '') {
+  const qs = new URLSearchParams({ limit: String(limit) })
+  if (cursor) qs.set('cursor', cursor)
+  const data = await request(`/me/bookmarks?${qs.toString()}`)
+  return {
+    posts: Array.isArray(data?.posts) ? data.posts : [],
+    hasMore: Boolean(data?.has_more),
+    nextCursor: typeof data?.next_cursor === 'string' ? data.next_cursor : '',
+  }
+}
+
+// Who and what the current user follows: { users: [{id, nickname}], categories: [{id, name}] }.
+export async function apiGetFollows() {
+  const data = await request('/me/follows')
+  return { users: data?.users || [], categories: data?.categories || [] }
+}
+
+export async function apiSetFollowUser(userId, on) {
+  return request(`/users/${userId}/follow`, { method: on ? 'POST' : 'DELETE' })
+}
+
+export async function apiSetFollowCategory(name, on) {
+  return request(`/categories/${encodeURIComponent(name)}/follow`, { method: on ? 'POST' : 'DELETE' })
+}
+
+// Tag autocomplete: [{ name, count }], most used first.
+export async function apiGetTags(q = '', limit = 10) {
+  const qs = new URLSearchParams({ limit: String(limit) })
+  if (q) qs.set('q', q)
+  const data = await request(`/tags?${qs.toString()}`)
+  return data?.tags || []
+}
+
+// Votes on a post's poll; optionIds holds one ID for single-choice polls.
+export async function apiVotePoll(postId, optionIds) {
+  const res = await request(`/posts/${postId}/poll/vote`, {
+    method: 'POST',
+    body: JSON.stringify({ option_ids: optionIds }),
+  })
+  return res ? res.poll : null
+}
+
+// Drafts and scheduled posts of the current user.
+export async function apiGetDrafts() {
+  const data = await request('/drafts')
+  return data?.drafts || []
+}
+
+// patch: { title?, content?, category?, status?, publish_at? }
+export async function apiUpdateDraft(draftId, patch) {
+  const res = await request(`/drafts/${draftId}`, {
+    method: 'PATCH',
+    body: JSON.stringify(patch),
+  })
+  return res ? res.post : null
+}
+
+// Uploads one image to a post. Passing explicit headers drops the JSON
+// Content-Type so the browser sets the multipart boundary itself.
+export async function apiUploadAttachment(postId, file) {
+  const form = new FormData()
+  form.append('file', file)
+
+  const res = await request(`/posts/${postId}/attachments`, {
+    method: 'POST',
+    headers: {},
+    body: form,
+  })
+  return res ? res.attachment : null
+}
+
+// GET /api/posts/{id}/comments?after=&limit=&order=oldest|newest
+// GET /api/posts/{id}/comments?cursor=&limit=&order=top
+// Returns: { comments: [], has_more: boolean, next_after: number } (next_cursor: string for top)
+export async function apiGetComments(id, { after = 0, cursor = '', limit = 20, order = 'oldest' } = {}) {
+  const qs = new URLSearchParams({ limit: String(limit), order })
+  if (after) qs.set('after', String(after))
+  if (cursor) qs.set('cursor', cursor)
+  const data = await request(`/posts/${id}/comments?${qs.toString()}`)
+  return data || { comments: [], has_more: false }
 }
 
-export async function apiAddComment(postId, content) {
+// parentId makes the comment a reply.
+export async function apiAddComment(postId, content, parentId = null) {
+  const body = { content }
+  if (parentId) body.parent_id = Number(parentId)
   const data = await request(`/posts/${postId}/comments`, {
     method: 'POST',
-    body: JSON.stringify({ content }),
+    body: JSON.stringify(body),
   })
   return data
 }
 
+// GET /api/comments/{id}/replies?after=&limit=
+// Returns: { replies: [], has_more: boolean }
+export async function apiGetReplies(rootId, after = 0, limit = 20) {
+  const qs = new URLSearchParams({ limit: String(limit) })
+  if (after) qs.set('after', String(after))
+  const data = await request(`/comments/${rootId}/replies?${qs.toString()}`)
+  return data || { replies: [], has_more: false }
+}
+
 export function apiGetChatHistory(userId, offset, limit = 10) {
   return request(`/chat/${userId}?offset=${offset}&limit=${limit}`)
 }
@@ -241,3 +361,47 @@ export async function apiUpdateComment(commentId, content) {
   })
   return data
 }
+
+// POST /api/comments/{id}/reactions { reaction: "like" }
+// Returns: { comment_id, reaction, reacted, reactions_count, reactions }
+export async function apiToggleCommentReaction(commentId, reaction = 'like') {
+  return request(`/comments/${commentId}/reactions`, {
+    method: 'POST',
+    body: JSON.stringify({ reaction }),
+  })
+}
+
+// POST /api/comments/{id}/vote { value: 1 | -1 | 0 }  (Q&A posts only)
+// Returns: { comment_id, score, my_vote }
+export async function apiVoteComment(commentId, value) {
+  return request(`/comments/${commentId}/vote`, {
+    method: 'POST',
+    body: JSON.stringify({ value }),
+  })
+}
+
+// POST /api/posts/{id}/accepted { comment_id }  (0 clears)
+// Returns: { post }
+export async function apiSetAcceptedAnswer(postId, commentId) {
+  return request(`/posts/${postId}/accepted`, {
+    method: 'POST',
+    body: JSON.stringify({ comment_id: commentId }),
+  })
+}
+
+// DELETE /api/comments/{id}
+// Returns: { deleted: true, tombstoned: boolean, removed: [] }
+export async function apiDeleteComment(commentId) {
+  return request(`/comments/${commentId}`, { method: 'DELETE' })
+}
+
+// GET /api/search?q=...&category=&author=&from=&to=&limit=&offset=
+// Returns: { results: [], has_more: boolean, next_offset: number }
+export async function apiSearch(q, filters = {}, limit = 20, offset = 0) {
+  const qs = new URLSearchParams({ q, limit: String(limit), offset: String(offset) })
+  for (const key of ['category', 'author', 'from', 'to']) {
+    if (filters[key]) qs.set(key, filters[key])
+  }
+  const data = await request(`/search?${qs.toString()}`)
+  return data || { results: [], has_more: false, next_offset: offset }
+}
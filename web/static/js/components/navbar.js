@@ -25,6 +25,7 @@ export function renderNavbar(root) {
 
       <button class="nav-btn" data-route="feed">Feed</button>
       <button class="nav-btn" data-route="new-post">New post</button>
+      <button class="nav-btn" data-route="saved">Saved</button>
       <button class="nav-btn" data-route="chat">Chat</button>
     </div>
 
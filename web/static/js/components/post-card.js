@@ -1,33 +1,49 @@
 // Renders a single post card used in the feed.
-// onClick is called when the user clicks the card.
-import { apiTogglePostReaction } from '../api.js'
+// onClick is called when the user clicks the card, onTagClick(tag) when
+// the user clicks one of its tags.
+import { apiSetBookmark, apiTogglePostReaction } from '../api.js'
 
-export function renderPostCard(post, onClick) {
+export function renderPostCard(post, onClick, onTagClick) {
   const card = document.createElement('article')
   card.className = 'post-card'
+  card.dataset.postId = post.id || ''
 
   // Basic safe values
   const title = post.title || 'Untitled'
   const author = post.author || 'Unknown'
   const category = post.category || 'General'
 
+  const tags = Array.isArray(post.tags) ? post.tags : []
+
   const created = post.created_at ? new Date(post.created_at).toLocaleString() : ''
+  const commentsCount = Number(post.comments_count || 0) || 0
+  // Only mention activity when someone replied after the post went up.
+  const active =
+    post.last_activity_at && post.last_activity_at !== post.created_at
+      ? new Date(post.last_activity_at).toLocaleString()
+      : ''
 
   // Reactions (server-driven)
   const postId = Number(post.id || 0) || 0
-  const reactionsCount = Number(post.reactions_count ?? post.likes_count ?? 0) || 0
-  const iReacted = Boolean(post.i_reacted ?? post.i_liked ?? false)
+  // The button toggles "like"; prefer the per-type counts when present.
+  const reactionsCount = Number(post.reactions?.like ?? post.reactions_count ?? 0) || 0
+  const iReacted = Array.isArray(post.my_reactions) ? post.my_reactions.includes('like') : Boolean(post.i_reacted)
 
   card.innerHTML = `
   <header class="post-card-header">
-    <h3 class="post-title">${title}</h3>
+    <h3 class="post-title">${post.pin_scope === 'global' ? '📌 ' : ''}${title}${post.locked ? ' 🔒' : ''}</h3>
+    ${post.qa ? `<span class="post-qa-badge ${post.accepted_comment_id ? 'solved' : ''}">${post.accepted_comment_id ? '✔ Solved' : '? Unsolved'}</span>` : ''}
     <span class="post-category">${category}</span>
   </header>
 
+  ${tags.length ? `<div class="post-tags">${tags.map((t) => `<button type="button" class="post-tag" data-tag="${t}">#${t}</button>`).join('')}</div>` : ''}
+
   <footer class="post-meta">
     <div class="post-meta-left">
       <span>by <strong>${author}</strong></span>
       ${created ? `<span> • ${created}</span>` : ''}
+      <span class="post-comments-count" title="Comments"> • 💬 ${commentsCount}</span>
+      ${active ? `<span class="post-activity"> • active ${active}</span>` : ''}
     </div>
 
     <button
@@ -46,6 +62,13 @@ export function renderPostCard(post, onClick) {
       <span class="reaction-dot">·</span>
       <span class="reaction-count">${reactionsCount}</span>
     </button>
+
+    <button
+      class="bookmark-btn ${post.i_bookmarked ? 'bookmarked' : ''}"
+      type="button"
+      aria-label="Save post"
+      title="${post.i_bookmarked ? 'Saved' : 'Save for later'}"
+    >${post.i_bookmarked ? '★' : '☆'}</button>
   </footer>
 `
 
@@ -54,6 +77,37 @@ export function renderPostCard(post, onClick) {
     card.addEventListener('click', onClick)
   }
 
+  // Tag click filters the feed (do not trigger card navigation)
+  card.querySelectorAll('.post-tag').forEach((btn) => {
+    btn.addEventListener('click', (e) => {
+      e.preventDefault()
+      e.stopPropagation()
+      if (onTagClick) onTagClick(btn.dataset.tag)
+    })
+  })
+
+  // Bookmark click (do not trigger card navigation)
+  const bookmarkBtn = card.querySelector('.bookmark-btn')
+  bookmarkBtn?.addEventListener('click', async (e) => {
+    e.preventDefault()
+    e.stopPropagation()
+    if (!postId) return
+
+    const next = !bookmarkBtn.classList.contains('bookmarked')
+    bookmarkBtn.disabled = true
+    try {
+      await apiSetBookmark(postId, next)
+      bookmarkBtn.classList.toggle('bookmarked', next)
+      bookmarkBtn.textContent = next ? '★' : '☆'
+      bookmarkBtn.title = next ? 'Saved' : 'Save for later'
+    } catch (err) {
+      console.error('[BOOKMARK] Update failed:', err)
+      alert('Could not update bookmark. Please try again.')
+    } finally {
+      bookmarkBtn.disabled = false
+    }
+  })
+
   // Reaction click (do not trigger card navigation)
   const reactionBtn = card.querySelector('.reaction-btn')
   if (reactionBtn) {
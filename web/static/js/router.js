@@ -5,6 +5,7 @@ import { renderFeedView } from './views/view-feed.js'
 import { renderPostView } from './views/view-post.js'
 import { renderChatView, renderChatSidebar } from './views/view-chat.js'
 import { renderNewPostView } from './views/view-new-post.js'
+import { renderSavedView } from './views/view-saved.js'
 
 import { getState } from './state.js'
 
@@ -67,6 +68,9 @@ function handleRoute() {
     case 'new-post':
       renderNewPostView(app)
       break
+    case 'saved':
+      renderSavedView(app)
+      break
     case 'chat':
       renderChatView(app, param)
       break
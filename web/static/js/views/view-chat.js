@@ -330,6 +330,10 @@ export async function renderChatView(root, param) {
       delivered_at: m.delivered_at ?? null,
       seen: Boolean(m.seen),
       seen_at: m.seen_at ?? null,
+
+      // set when the server answered our send with an error event
+      failed: Boolean(m.failed),
+      error: m.error ?? '',
     }
   }
 
@@ -355,6 +359,13 @@ export async function renderChatView(root, param) {
     allMessages.push(m)
   }
 
+  function markFailedLocally(tempID, error) {
+    const idx = allMessages.findIndex((m) => m.temp_id && m.temp_id === tempID)
+    if (idx === -1) return false
+    allMessages[idx] = { ...allMessages[idx], failed: true, error }
+    return true
+  }
+
   function markDeliveredLocally(messageID) {
     const idx = allMessages.findIndex((m) => m.id === messageID)
     if (idx !== -1) allMessages[idx] = { ...allMessages[idx], delivered: true }
@@ -752,7 +763,10 @@ export async function renderChatView(root, param) {
         let statusText = ''
         let statusClass = ''
 
-        if (isMine && isLastInGroup) {
+        if (isMine && m.failed) {
+          statusText = '⚠ Not sent'
+          statusClass = 'is-failed'
+        } else if (isMine && isLastInGroup) {
           const pending = !m.id
           const sent = !!m.id
           const delivered = Boolean(m.delivered || m.delivered_at)
@@ -773,7 +787,8 @@ export async function renderChatView(root, param) {
           }
         }
 
-        const statusHtml = statusText ? `<div class="chat-status ${statusClass}">${statusText}</div>` : ''
+        const statusTitle = m.failed && m.error ? ` title="${escapeHtml(m.error)}"` : ''
+        const statusHtml = statusText ? `<div class="chat-status ${statusClass}"${statusTitle}>${statusText}</div>` : ''
 
         bubble.innerHTML = `
           <div class="chat-text">${escapeHtml(m.content)}</div>
@@ -835,6 +850,26 @@ export async function renderChatView(root, param) {
 
     if (!otherId) return
 
+    // reconnected, but too much was missed to replay: reload the history
+    if (ev.type === 'synced') {
+      if (!ev.complete) loadInitial(otherId)
+      return
+    }
+
+    // the server refused one of our messages: flag its bubble
+    if (ev.type === 'error') {
+      // an event went out without being logged: our seq has a gap
+      if (ev.code === 'unlogged') {
+        loadInitial(otherId)
+        return
+      }
+      if (ev.op !== 'message' || !ev.temp_id) return
+      if (markFailedLocally(ev.temp_id, ev.message || ev.code)) {
+        renderMessages(allMessages, { preserveScroll: true })
+      }
+      return
+    }
+
     // message
     if (ev.type === 'message') {
       const belongs =
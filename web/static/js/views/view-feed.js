@@ -4,12 +4,88 @@ import { apiGetPosts } from '../api.js'
 import { getState, setStateKey } from '../state.js'
 import { renderPostCard } from '../components/post-card.js'
 import { navigateTo } from '../router.js'
+import { onWSMessage, subscribeWS } from '../ws-chat.js'
 
 const PAGE_SIZE = 10
 
+const SORT_OPTIONS = [
+  ['new', 'New'],
+  ['hot', 'Hot'],
+  ['top', 'Top this week'],
+  ['discussed', 'Most discussed'],
+  ['active', 'Recent activity'],
+]
+
+// Questions in Q&A categories, by whether an answer was accepted.
+const SOLVED_OPTIONS = [
+  ['', 'All posts'],
+  ['unsolved', 'Unsolved questions'],
+  ['solved', 'Solved questions'],
+]
+
+let currentSort = 'new'
+let currentSolved = ''
+let currentTag = ''
+let followingOnly = false
+
 export async function renderFeedView(root) {
   root.innerHTML = ''
 
+  // Sort selector
+  const sortSelect = document.createElement('select')
+  sortSelect.className = 'feed-sort'
+  SORT_OPTIONS.forEach(([value, label]) => {
+    const opt = document.createElement('option')
+    opt.value = value
+    opt.textContent = label
+    sortSelect.appendChild(opt)
+  })
+  sortSelect.value = currentSort
+  sortSelect.addEventListener('change', () => {
+    currentSort = sortSelect.value
+    setStateKey('posts', [])
+    renderFeedView(root)
+  })
+  root.appendChild(sortSelect)
+
+  const solvedSelect = document.createElement('select')
+  solvedSelect.className = 'feed-sort feed-solved'
+  SOLVED_OPTIONS.forEach(([value, label]) => {
+    const opt = document.createElement('option')
+    opt.value = value
+    opt.textContent = label
+    solvedSelect.appendChild(opt)
+  })
+  solvedSelect.value = currentSolved
+  solvedSelect.addEventListener('change', () => {
+    currentSolved = solvedSelect.value
+    setStateKey('posts', [])
+    renderFeedView(root)
+  })
+  root.appendChild(solvedSelect)
+
+  // All posts, or only followed authors and categories
+  const followingToggle = document.createElement('button')
+  followingToggle.type = 'button'
+  followingToggle.className = `nav-btn feed-following ${followingOnly ? 'active' : ''}`
+  followingToggle.textContent = followingOnly ? 'Following ✓' : 'Following'
+  followingToggle.addEventListener('click', () => {
+    followingOnly = !followingOnly
+    setStateKey('posts', [])
+    renderFeedView(root)
+  })
+  root.appendChild(followingToggle)
+
+  // Active tag filter (set by clicking a tag on a card)
+  if (currentTag) {
+    const tagFilter = document.createElement('div')
+    tagFilter.className = 'feed-tag-filter'
+    tagFilter.innerHTML = `<span>Tagged <strong></strong></span><button type="button" class="nav-btn">Clear</button>`
+    tagFilter.querySelector('strong').textContent = `#${currentTag}`
+    tagFilter.querySelector('button').addEventListener('click', () => filterByTag(''))
+    root.appendChild(tagFilter)
+  }
+
   const list = document.createElement('div')
   list.className = 'feed-list'
   root.appendChild(list)
@@ -26,7 +102,8 @@ export async function renderFeedView(root) {
   loadMoreWrap.appendChild(loadMoreBtn)
   root.appendChild(loadMoreWrap)
 
-  let offset = 0
+  let cursor = ''
+  let firstPage = true
   let hasMore = true
   let loading = false
 
@@ -44,24 +121,77 @@ export async function renderFeedView(root) {
     loadMoreBtn.textContent = loading ? 'Loading…' : 'Load more'
   }
 
+  function filterByTag(tag) {
+    currentTag = tag
+    setStateKey('posts', [])
+    renderFeedView(root)
+  }
+
+  const shown = new Map() // post id -> post on screen
+
+  function postCard(p) {
+    shown.set(Number(p.id), p)
+    return renderPostCard(p, () => navigateTo(`post/${p.id}`), filterByTag)
+  }
+
   function appendPosts(posts) {
-    posts.forEach((p) => {
-      const card = renderPostCard(p, () => navigateTo(`post/${p.id}`))
-      list.appendChild(card)
-    })
+    posts.forEach((p) => list.appendChild(postCard(p)))
   }
 
+  // ---- LIVE UPDATES ----
+  const unsubscribeLive = subscribeWS('feed')
+  const unsubscribeWS = onWSMessage((ev) => {
+    if (!list.isConnected) {
+      unsubscribeWS()
+      unsubscribeLive()
+      return
+    }
+    const id = Number(ev?.post_id)
+
+    if (ev?.type === 'post_created' && ev.post) {
+      // Only the plain "New" feed is sure to start with the newest post.
+      if (currentSort !== 'new' || currentTag || followingOnly || currentSolved || shown.has(id)) return
+      list.querySelector('.feed-empty')?.remove()
+      // Pinned posts stay on top.
+      const firstUnpinned = [...list.querySelectorAll('.post-card')].find((el) => !shown.get(Number(el.dataset.postId))?.pinned)
+      list.insertBefore(postCard(ev.post), firstUnpinned || null)
+      return
+    }
+
+    const p = shown.get(id)
+    const card = p && list.querySelector(`.post-card[data-post-id="${id}"]`)
+    if (!card) return
+
+    switch (ev.type) {
+      case 'comment_created':
+        p.comments_count = (Number(p.comments_count) || 0) + 1
+        card.querySelector('.post-comments-count').textContent = ` • 💬 ${p.comments_count}`
+        break
+      case 'reaction_changed':
+        if (ev.comment_id) break
+        card.querySelector('.reaction-count').textContent = String(Number(ev.reactions?.like) || 0)
+        break
+      case 'post_updated':
+        if (!ev.post) break
+        card.querySelector('.post-title').textContent =
+          `${ev.post.pin_scope === 'global' ? '📌 ' : ''}${ev.post.title || 'Untitled'}${ev.post.locked ? ' 🔒' : ''}`
+        break
+    }
+  })
+
   async function loadPage() {
     if (!hasMore || loading) return
     setLoading(true)
 
     try {
-      const res = await apiGetPosts(PAGE_SIZE, offset)
+      const res = await apiGetPosts(PAGE_SIZE, cursor, currentSort, currentSort === 'top' ? 'week' : '', currentTag, followingOnly, currentSolved)
       const newPosts = Array.isArray(res?.posts) ? res.posts : []
 
       // first page + empty
-      if (offset === 0 && newPosts.length === 0) {
-        list.innerHTML = `<p class="feed-empty">No posts yet. Be the first to create one!</p>`
+      if (firstPage && newPosts.length === 0) {
+        list.innerHTML = followingOnly
+          ? `<p class="feed-empty">Nothing here yet. Follow authors or categories from a post page.</p>`
+          : `<p class="feed-empty">No posts yet. Be the first to create one!</p>`
         hideLoadMore()
         return
       }
@@ -73,14 +203,15 @@ export async function renderFeedView(root) {
       setStateKey('posts', [...prev, ...newPosts])
 
       // update pagination
-      offset = typeof res?.nextOffset === 'number' ? res.nextOffset : offset + newPosts.length
-      hasMore = Boolean(res?.hasMore)
+      cursor = res?.nextCursor || ''
+      hasMore = Boolean(res?.hasMore) && cursor !== ''
+      firstPage = false
 
       if (!hasMore) hideLoadMore()
       else showLoadMore()
     } catch (err) {
       console.error('[FEED] Failed to load posts:', err)
-      if (offset === 0) {
+      if (firstPage) {
         list.innerHTML = `<p class="feed-empty">Could not load posts. Please try again.</p>`
         hideLoadMore()
       } else {
@@ -1,7 +1,7 @@
 // views/view-new-post.js
 // New post page with category chips and optional custom category
 
-import { apiCreatePost } from '../api.js'
+import { apiCreatePost, apiGetDrafts, apiGetTags, apiUpdateDraft, apiUploadAttachment } from '../api.js'
 import { navigateTo } from '../router.js'
 
 // Base categories shown as chips
@@ -30,6 +30,11 @@ export function renderNewPostView(root) {
       <p>Share something with the forum.</p>
     </div>
 
+    <div class="drafts-section" id="draftsSection" hidden>
+      <div class="category-label">Your drafts</div>
+      <div class="drafts-list" id="draftsList"></div>
+    </div>
+
     <form id="newPostForm" class="new-post-form">
       <input
         type="text"
@@ -53,12 +58,48 @@ export function renderNewPostView(root) {
       <textarea
         id="postContent"
         placeholder="Write your post here..."
+        maxlength="20000"
         required
       ></textarea>
 
-      <button type="submit" class="new-post-submit">
-        Publish post
-      </button>
+      <input
+        type="text"
+        id="postTags"
+        class="category-input"
+        placeholder="Tags, comma separated (optional, up to 5)"
+        list="tagSuggestions"
+        autocomplete="off"
+      />
+      <datalist id="tagSuggestions"></datalist>
+
+      <label class="attachment-input">
+        <span>Images (optional, up to 4)</span>
+        <input type="file" id="postImages" accept="image/jpeg,image/png,image/gif" multiple />
+      </label>
+
+      <label class="attachment-input">
+        <span>Publish later (optional)</span>
+        <input type="datetime-local" id="postPublishAt" />
+      </label>
+
+      <details class="poll-input">
+        <summary>Add a poll (optional)</summary>
+        <input type="text" id="pollQuestion" placeholder="Question" maxlength="200" />
+        <textarea id="pollOptions" placeholder="One option per line (2 to 10)"></textarea>
+        <label><input type="checkbox" id="pollMultiple" /> Allow multiple choices</label>
+        <label><input type="checkbox" id="pollPublic" /> Show who voted</label>
+        <label class="attachment-input">
+          <span>Closes at (optional)</span>
+          <input type="datetime-local" id="pollClosesAt" />
+        </label>
+      </details>
+
+      <div class="new-post-actions">
+        <button type="button" class="nav-btn" id="saveDraftBtn">Save draft</button>
+        <button type="submit" class="new-post-submit">
+          Publish post
+        </button>
+      </div>
     </form>
   `
 
@@ -71,6 +112,9 @@ export function renderNewPostView(root) {
   // Currently selected category value
   let selectedCategory = null
 
+  // Draft being edited (null = new post)
+  let editingDraftId = null
+
   // Marks one chip as selected and stores its value
   function setSelectedCategory(name) {
     selectedCategory = name
@@ -147,10 +191,104 @@ export function renderNewPostView(root) {
     }
   })
 
-  // Submit handler to create the post
-  form.addEventListener('submit', async (e) => {
-    e.preventDefault()
+  /*----------------------------------------------------------------------------------
+  Tags: autocomplete the tag being typed (the text after the last comma)
+  -----------------------------------------------------------------------------------
+  */
+  const tagsInput = container.querySelector('#postTags')
+  const tagSuggestions = container.querySelector('#tagSuggestions')
+  let tagTimer = null
+
+  const parseTagsInput = () =>
+    tagsInput.value
+      .split(',')
+      .map((t) => t.trim())
+      .filter(Boolean)
+
+  tagsInput.addEventListener('input', () => {
+    clearTimeout(tagTimer)
+    tagTimer = setTimeout(async () => {
+      const parts = tagsInput.value.split(',')
+      const current = parts.pop().trim()
+      if (!current) {
+        tagSuggestions.innerHTML = ''
+        return
+      }
+      try {
+        const tags = await apiGetTags(current, 8)
+        const head = parts.map((t) => t.trim()).filter(Boolean)
+        tagSuggestions.innerHTML = ''
+        tags.forEach((t) => {
+          const opt = document.createElement('option')
+          opt.value = [...head, t.name].join(', ')
+          opt.label = `${t.name} (${t.count})`
+          tagSuggestions.appendChild(opt)
+        })
+      } catch (err) {
+        console.error('Failed to load tags:', err)
+      }
+    }, 200)
+  })
+
+  /*----------------------------------------------------------------------------------
+  Drafts: list them above the form; clicking one loads it for editing
+  -----------------------------------------------------------------------------------
+  */
+  const draftsSection = container.querySelector('#draftsSection')
+  const draftsList = container.querySelector('#draftsList')
+  const publishAtInput = container.querySelector('#postPublishAt')
+
+  // datetime-local wants "YYYY-MM-DDTHH:MM" in local time.
+  function toLocalInput(iso) {
+    const d = new Date(iso)
+    if (Number.isNaN(d.getTime())) return ''
+    const pad = (n) => String(n).padStart(2, '0')
+    return `${d.getFullYear()}-${pad(d.getMonth() + 1)}-${pad(d.getDate())}T${pad(d.getHours())}:${pad(d.getMinutes())}`
+  }
 
+  function loadDraft(draft) {
+    editingDraftId = draft.id
+    container.querySelector('#postTitle').value = draft.title || ''
+    container.querySelector('#postContent').value = draft.content || ''
+    publishAtInput.value = draft.publish_at ? toLocalInput(draft.publish_at) : ''
+    tagsInput.value = (draft.tags || []).join(', ')
+
+    const exists = Array.from(chipsContainer.querySelectorAll('.category-chip')).some((chip) => chip.textContent === draft.category)
+    if (!exists && draft.category) {
+      customInput.value = draft.category
+      handleCustomCategory()
+    } else {
+      setSelectedCategory(draft.category)
+    }
+  }
+
+  async function renderDrafts() {
+    let drafts = []
+    try {
+      drafts = await apiGetDrafts()
+    } catch (err) {
+      console.error('Failed to load drafts:', err)
+    }
+
+    draftsList.innerHTML = ''
+    draftsSection.hidden = drafts.length === 0
+
+    drafts.forEach((draft) => {
+      const item = document.createElement('button')
+      item.type = 'button'
+      item.className = 'category-chip draft-chip'
+      const when = draft.status === 'scheduled' && draft.publish_at ? ` · ${new Date(draft.publish_at).toLocaleString()}` : ''
+      item.textContent = `${draft.title}${when}`
+      item.title = draft.status === 'scheduled' ? 'Scheduled' : 'Draft'
+      item.addEventListener('click', () => loadDraft(draft))
+      draftsList.appendChild(item)
+    })
+  }
+
+  renderDrafts()
+
+  // Creates or updates the post with the given status, then uploads images.
+  async function savePost(status) {
     const title = container.querySelector('#postTitle').value.trim()
     const content = container.querySelector('#postContent').value.trim()
 
@@ -164,17 +302,57 @@ export function renderNewPostView(root) {
       return
     }
 
+    const files = Array.from(container.querySelector('#postImages').files || []).slice(0, 4)
+
+    const payload = { title, category: selectedCategory, content, status, tags: parseTagsInput() }
+    if (status === 'scheduled') {
+      payload.publish_at = new Date(publishAtInput.value).toISOString()
+    }
+
+    // Polls are created together with the post.
+    const question = container.querySelector('#pollQuestion').value.trim()
+    if (question && !editingDraftId) {
+      const closesAt = container.querySelector('#pollClosesAt').value
+      payload.poll = {
+        question,
+        options: container
+          .querySelector('#pollOptions')
+          .value.split('\n')
+          .map((o) => o.trim())
+          .filter(Boolean),
+        multiple: container.querySelector('#pollMultiple').checked,
+        anonymous: !container.querySelector('#pollPublic').checked,
+        closes_at: closesAt ? new Date(closesAt).toISOString() : undefined,
+      }
+    }
+
     try {
-      await apiCreatePost({
-        title,
-        category: selectedCategory,
-        content,
-      })
+      const post = editingDraftId ? await apiUpdateDraft(editingDraftId, payload) : await apiCreatePost(payload)
+
+      // Images are uploaded once the post exists; a failed image does not undo the post.
+      if (post?.id) {
+        for (const file of files) {
+          try {
+            await apiUploadAttachment(post.id, file)
+          } catch (err) {
+            console.error('Failed to upload image:', err)
+            alert(`Could not upload ${file.name}.`)
+          }
+        }
+      }
 
       navigateTo('feed')
     } catch (err) {
-      console.error('Failed to create post:', err)
-      alert('Could not create post. Please try again.')
+      console.error('Failed to save post:', err)
+      alert('Could not save post. Please try again.')
     }
+  }
+
+  // Publish (or schedule, when a publish time is set)
+  form.addEventListener('submit', (e) => {
+    e.preventDefault()
+    savePost(publishAtInput.value ? 'scheduled' : 'published')
   })
+
+  container.querySelector('#saveDraftBtn').addEventListener('click', () => savePost('draft'))
 }
@@ -1,7 +1,8 @@
 // Post Card Detail
 // web/static/js/views/view-post.js
 
-import { apiGetPost, apiAddComment, apiTogglePostReaction, apiRegisterPostView, apiUpdatePost, apiUpdateComment } from '../api.js'
+import { apiGetPost, apiGetComments, apiAddComment, apiGetReplies, apiDeleteComment, apiToggleCommentReaction, apiVoteComment, apiSetAcceptedAnswer, apiTogglePostReaction, apiRegisterPostView, apiUpdatePost, apiUpdateComment, apiSetPinned, apiSetLocked, apiVotePoll, apiSetBookmark, apiGetFollows, apiSetFollowUser, apiSetFollowCategory } from '../api.js'
+import { onWSMessage, subscribeWS } from '../ws-chat.js'
 import { navigateTo } from '../router.js'
 import { getState } from '../state.js'
 
@@ -9,6 +10,9 @@ export async function renderPostView(root, postId) {
   const escapeHtml = (str) =>
     String(str).replaceAll('&', '&amp;').replaceAll('<', '&lt;').replaceAll('>', '&gt;').replaceAll('"', '&quot;').replaceAll("'", '&#039;')
 
+  // content_html is rendered and sanitized by the server.
+  const commentHtml = (c) => c?.content_html || escapeHtml(c?.content || '')
+
   const container = document.createElement('div')
   container.className = 'post-page'
   container.innerHTML = `<p>Loading post…</p>`
@@ -24,12 +28,25 @@ export async function renderPostView(root, postId) {
   }
 
   const post = data.post
-  const comments = data.comments || []
+
+  const attachments = Array.isArray(post?.attachments) ? post.attachments : []
+  const attachmentsHtml = attachments.length
+    ? `<div class="post-attachments">${attachments
+        .map(
+          (a) => `
+          <a href="${escapeHtml(a.url)}" target="_blank" rel="noopener">
+            <img src="${escapeHtml(a.url)}" alt="${escapeHtml(a.filename || 'attachment')}" width="${Number(a.width) || ''}" height="${Number(a.height) || ''}" loading="lazy" />
+          </a>`,
+        )
+        .join('')}</div>`
+    : ''
 
   const me = getState().currentUser
   const myId = Number(me?.id ?? me?.ID ?? 0)
   const ownerId = Number(post?.user_id ?? post?.userID ?? post?.UserID ?? 0)
   const isOwner = myId > 0 && ownerId > 0 && myId === ownerId
+  const isModerator = me?.role === 'moderator'
+  const canComment = !post?.locked || isModerator
 
   console.log('[OWNER CHECK]', {
     me,
@@ -41,14 +58,16 @@ export async function renderPostView(root, postId) {
 
   // Reactions
   const pid = Number(post?.id || 0) || 0
-  const reactionsCount = Number(post?.reactions_count ?? post?.likes_count ?? 0) || 0
-  const iReacted = Boolean(post?.i_reacted ?? post?.i_liked ?? false)
+  // The button toggles "like"; prefer the per-type counts when present.
+  const reactionsCount = Number(post?.reactions?.like ?? post?.reactions_count ?? 0) || 0
+  const iReacted = Array.isArray(post?.my_reactions) ? post.my_reactions.includes('like') : Boolean(post?.i_reacted)
 
   // Views
   const initialViews = Number(post?.views_count ?? 0) || 0
 
   // Date formatting
   const created = post?.created_at ? new Date(post.created_at).toLocaleString() : ''
+  const edited = post?.edited_at ? new Date(post.edited_at).toLocaleString() : ''
 
   // Categories list (best-effort from feed state + defaults)
   function getAllCategories() {
@@ -87,7 +106,17 @@ export async function renderPostView(root, postId) {
         <div class="post-header-right">
           <span class="post-page-category" id="postCategory">${escapeHtml(post?.category || 'General')}</span>
 
+          ${post?.pinned ? `<span class="post-badge">📌 Pinned</span>` : ``}
+          ${post?.locked ? `<span class="post-badge">🔒 Locked</span>` : ``}
+          ${post?.qa ? `<span class="post-badge post-qa-badge${post.accepted_comment_id ? ' solved' : ''}">${post.accepted_comment_id ? '✔ Solved' : '? Unsolved'}</span>` : ``}
+
           ${isOwner ? `<button class="nav-btn" id="editPostBtn" type="button">Edit</button>` : ``}
+          ${
+            isModerator
+              ? `<button class="nav-btn" id="pinPostBtn" type="button">${post?.pinned ? 'Unpin' : 'Pin'}</button>
+                 <button class="nav-btn" id="lockPostBtn" type="button">${post?.locked ? 'Unlock' : 'Lock'}</button>`
+              : ``
+          }
         </div>
       </header>
 
@@ -95,6 +124,9 @@ export async function renderPostView(root, postId) {
         <div class="post-meta-left">
           <span>by <strong>${escapeHtml(post?.author || 'Unknown')}</strong></span>
           ${created ? `<span>•</span><span>${escapeHtml(created)}</span>` : ''}
+          ${edited ? `<span class="post-edited" title="Edited ${escapeHtml(edited)}">(edited)</span>` : ''}
+          ${myId > 0 && !isOwner ? `<button type="button" class="follow-btn" id="followAuthorBtn" hidden></button>` : ``}
+          ${myId > 0 ? `<button type="button" class="follow-btn" id="followCategoryBtn" hidden></button>` : ``}
         </div>
 
         <div class="post-meta-right">
@@ -115,16 +147,38 @@ export async function renderPostView(root, postId) {
             <span class="reaction-dot">·</span>
             <span class="reaction-count">${reactionsCount}</span>
           </button>
+
+          <button class="bookmark-btn ${post?.i_bookmarked ? 'bookmarked' : ''}" type="button" aria-label="Save post" title="Save for later">
+            ${post?.i_bookmarked ? '★ Saved' : '☆ Save'}
+          </button>
         </div>
       </footer>
 
       <article class="post-page-content" id="postContent">
-        ${escapeHtml(post?.content || '')}
+        ${post?.content_html || escapeHtml(post?.content || '')}
       </article>
 
+      ${
+        Array.isArray(post?.tags) && post.tags.length
+          ? `<div class="post-tags" id="postTags">${post.tags.map((t) => `<span class="post-tag">#${escapeHtml(t)}</span>`).join('')}</div>`
+          : ``
+      }
+
+      ${attachmentsHtml}
+
+      <section class="post-poll" id="postPoll"></section>
+
       <section class="post-comments">
-        <h2 class="comments-title">Comments (${comments.length})</h2>
+        <div class="comments-header">
+          <h2 class="comments-title">Comments (${Number(post?.comments_count) || 0})</h2>
+          <select class="comments-order" aria-label="Comment order">
+            ${post?.qa ? `<option value="top" selected>Top answers</option>` : ``}
+            <option value="oldest">Oldest first</option>
+            <option value="newest">Newest first</option>
+          </select>
+        </div>
         <div class="comments-list"></div>
+        <button type="button" class="nav-btn comments-load-more" style="display:none">Load more comments</button>
 
         <!-- ✅ barra de acciones para EDIT (siempre FUERA del form) -->
         <div class="post-edit-actions-bar" id="postEditActionsBar" style="display:none">
@@ -132,10 +186,14 @@ export async function renderPostView(root, postId) {
           <button type="button" class="nav-btn" id="cancelPostEdit">Cancel</button>
         </div>
 
-        <form id="commentForm" class="comment-form">
-          <textarea id="commentText" placeholder="Write a reply…" required></textarea>
+        ${
+          canComment
+            ? `<form id="commentForm" class="comment-form">
+          <textarea id="commentText" placeholder="Write a reply…" maxlength="20000" required></textarea>
           <button type="submit">Add comment</button>
-        </form>
+        </form>`
+            : `<p class="thread-locked">🔒 This thread is locked. New comments are closed.</p>`
+        }
       </section>
     </div>
   `
@@ -158,13 +216,72 @@ export async function renderPostView(root, postId) {
 
   // ---- COMMENTS ----
   const listEl = container.querySelector('.comments-list')
+  const titleEl = container.querySelector('.comments-title')
+
+  // Matches models.MaxCommentDepth: comments this deep take no replies.
+  const MAX_COMMENT_DEPTH = 3
+  const commentEls = new Map() // comment id -> element
+  const lastReplyId = new Map() // root id -> last reply loaded from the server
+  let commentsCount = Number(post?.comments_count) || 0
+
+  function setCommentsCount(n) {
+    commentsCount = Math.max(0, n)
+    titleEl.textContent = `Comments (${commentsCount})`
+  }
+  const bumpCommentsCount = () => setCommentsCount(commentsCount + 1)
+
+  // Strips a comment element down to a tombstone, keeping its replies.
+  function markTombstone(item) {
+    item.classList.add('is-deleted')
+    item.querySelector('.comment-avatar').textContent = '?'
+    item.querySelector('.comment-author').textContent = 'deleted'
+    item.querySelector('.comment-text').textContent = '[deleted]'
+    item.querySelectorAll(':scope > .comment-body > .comment-header button, :scope > .comment-body > .comment-reply-btn, :scope > .comment-body > .comment-like-btn, :scope > .comment-body > .comment-votes').forEach((b) => b.remove())
+  }
+
+  // Applies a deletion from our own DELETE or from a comment_deleted event;
+  // whichever arrives second is ignored.
+  const handledDeletions = new Set()
+  function applyCommentDeletion(commentId, tombstoned, removed) {
+    if (handledDeletions.has(Number(commentId))) return
+    handledDeletions.add(Number(commentId))
+
+    if (tombstoned) {
+      const el = commentEls.get(Number(commentId))
+      if (el) markTombstone(el)
+    }
+    for (const id of removed || []) {
+      commentEls.get(Number(id))?.remove()
+      commentEls.delete(Number(id))
+    }
+    setCommentsCount(commentsCount - (removed || []).length)
+  }
+
+  // Remembers where paging of a thread's replies should resume.
+  function trackLoaded(c) {
+    if (!c.root_id) return
+    const rootId = Number(c.root_id)
+    lastReplyId.set(rootId, Math.max(lastReplyId.get(rootId) || 0, Number(c.id)))
+  }
+
+  // prepend puts a new root comment at the top (newest-first order).
+  function appendComment(c, prepend = false) {
+    if (commentEls.has(Number(c.id))) return
 
-  function appendComment(c) {
     const me = getState().currentUser
-    const isMine = me && Number(me.id) === Number(c.user_id)
+    const isMine = me && !c.deleted && Number(me.id) === Number(c.user_id)
+    const canEdit = !c.deleted && (isMine || isModerator)
+    const canDelete = !c.deleted && (isMine || isModerator)
+    const depth = Number(c.depth || 0)
+    const likes = Number(c.reactions?.like) || 0
+    const iLiked = Array.isArray(c.my_reactions) && c.my_reactions.includes('like')
+    // On questions, root comments are answers: voted on and acceptable.
+    const isAnswer = Boolean(post?.qa) && depth === 0 && !c.deleted
 
     const item = document.createElement('div')
-    item.className = 'comment-item'
+    item.className = depth > 0 ? 'comment-item comment-reply' : 'comment-item'
+    if (c.deleted) item.classList.add('is-deleted')
+    if (c.accepted) item.classList.add('is-accepted')
     item.dataset.commentId = c.id
 
     item.innerHTML = `
@@ -173,28 +290,53 @@ export async function renderPostView(root, postId) {
       </div>
       <div class="comment-body">
         <div class="comment-header">
-          <span class="comment-author">${escapeHtml(c.author || 'Unknown')}</span>
+          <span class="comment-author">${c.deleted ? 'deleted' : escapeHtml(c.author || 'Unknown')}</span>
           <span class="comment-date">
   ${c.created_at ? new Date(c.created_at).toLocaleString() : ''}
 </span>
+          <span class="comment-edited" title="${c.edited_at ? `Edited ${escapeHtml(new Date(c.edited_at).toLocaleString())}` : ''}" ${c.edited_at ? '' : 'hidden'}>(edited)</span>
 
-          ${isMine ? `<button type="button" class="comment-edit-btn">Edit</button>` : ``}
+          ${c.accepted ? `<span class="comment-accepted">✔ Accepted answer</span>` : ``}
+          ${canEdit ? `<button type="button" class="comment-edit-btn">Edit</button>` : ``}
+          ${canDelete ? `<button type="button" class="comment-edit-btn comment-delete-btn">Delete</button>` : ``}
         </div>
-        <p class="comment-text">${escapeHtml(c.content || '')}</p>
+        <div class="comment-text">${commentHtml(c)}</div>
+        ${
+          isAnswer
+            ? `<div class="comment-votes">
+          <button type="button" class="vote-btn ${c.my_vote === 1 ? 'voted' : ''}" data-value="1" aria-label="Vote up" ${me ? '' : 'disabled'}>▲</button>
+          <span class="vote-score">${Number(c.score) || 0}</span>
+          <button type="button" class="vote-btn ${c.my_vote === -1 ? 'voted' : ''}" data-value="-1" aria-label="Vote down" ${me ? '' : 'disabled'}>▼</button>
+          ${isOwner ? `<button type="button" class="comment-accept-btn">${c.accepted ? 'Unaccept' : 'Accept answer'}</button>` : ``}
+        </div>`
+            : ``
+        }
+        ${
+          c.deleted
+            ? ``
+            : `<button type="button" class="comment-like-btn ${iLiked ? 'reacted' : ''}" ${me ? '' : 'disabled'}>♥ <span class="comment-like-count">${likes}</span></button>`
+        }
+        ${canComment && !c.deleted && depth < MAX_COMMENT_DEPTH ? `<button type="button" class="comment-reply-btn">Reply</button>` : ``}
+        <div class="comment-replies"></div>
+        ${c.more_replies ? `<button type="button" class="nav-btn comment-more-replies">Show more replies</button>` : ``}
       </div>
     `
 
+    // Raw Markdown source, used when editing.
+    let source = c.content || ''
+
     const editBtn = item.querySelector('.comment-edit-btn')
     if (editBtn) {
       editBtn.addEventListener('click', async () => {
         container.classList.add('is-editing-comment')
 
         const p = item.querySelector('.comment-text')
-        const old = p.textContent
+        const oldHtml = p.innerHTML
+        const old = source
 
         p.outerHTML = `
           <div class="comment-edit-wrap">
-            <textarea class="comment-edit-input">${escapeHtml(old)}</textarea>
+            <textarea class="comment-edit-input" maxlength="20000">${escapeHtml(old)}</textarea>
             <div class="comment-edit-actions">
               <button type="button" class="nav-btn comment-save">Save</button>
               <button type="button" class="nav-btn comment-cancel">Cancel</button>
@@ -208,7 +350,7 @@ export async function renderPostView(root, postId) {
         wrap.querySelector('.comment-cancel')?.addEventListener('click', () => {
           container.classList.remove('is-editing-comment')
 
-          wrap.outerHTML = `<p class="comment-text">${escapeHtml(old)}</p>`
+          wrap.outerHTML = `<div class="comment-text">${oldHtml}</div>`
         })
 
         wrap.querySelector('.comment-save')?.addEventListener('click', async () => {
@@ -218,20 +360,259 @@ export async function renderPostView(root, postId) {
           if (!next) return
           try {
             const res = await apiUpdateComment(Number(c.id), next)
-            const updated = res?.comment
-            wrap.outerHTML = `<p class="comment-text">${escapeHtml(updated?.content ?? next)}</p>`
+            const updated = res?.comment || { content: next }
+            source = updated.content
+            wrap.outerHTML = `<div class="comment-text">${commentHtml(updated)}</div>`
+            const editedEl = item.querySelector('.comment-edited')
+            editedEl.hidden = false
+            editedEl.title = `Edited ${new Date(updated.edited_at || Date.now()).toLocaleString()}`
           } catch (err) {
             console.error('[COMMENT] update failed:', err)
-            alert('Could not update comment.')
+            alert(err?.status === 403 ? 'This comment can no longer be edited.' : 'Could not update comment.')
           }
         })
       })
     }
 
-    listEl.appendChild(item)
+    // ---- DELETE ----
+    const deleteBtn = item.querySelector('.comment-delete-btn')
+    deleteBtn?.addEventListener('click', async () => {
+      if (!confirm('Delete this comment?')) return
+      deleteBtn.disabled = true
+      try {
+        const res = await apiDeleteComment(Number(c.id))
+        applyCommentDeletion(c.id, res?.tombstoned, res?.removed)
+      } catch (err) {
+        console.error('[COMMENT] delete failed:', err)
+        alert('Could not delete comment.')
+        deleteBtn.disabled = false
+      }
+    })
+
+    // ---- LIKE ----
+    const likeBtn = item.querySelector('.comment-like-btn')
+    likeBtn?.addEventListener('click', async () => {
+      likeBtn.disabled = true
+      try {
+        const res = await apiToggleCommentReaction(Number(c.id), 'like')
+        likeBtn.classList.toggle('reacted', !!res?.reacted)
+        likeBtn.querySelector('.comment-like-count').textContent = Number(res?.reactions?.like) || 0
+      } catch (err) {
+        console.error('[COMMENT] reaction failed:', err)
+      } finally {
+        likeBtn.disabled = false
+      }
+    })
+
+    // ---- Q&A: VOTE / ACCEPT ----
+    let myVote = Number(c.my_vote) || 0
+    item.querySelectorAll(':scope > .comment-body > .comment-votes .vote-btn').forEach((btn) => {
+      btn.addEventListener('click', async () => {
+        // Clicking the current vote again withdraws it.
+        const value = Number(btn.dataset.value) === myVote ? 0 : Number(btn.dataset.value)
+        try {
+          const res = await apiVoteComment(Number(c.id), value)
+          myVote = Number(res?.my_vote) || 0
+          item.querySelector('.vote-score').textContent = Number(res?.score) || 0
+          item.querySelectorAll(':scope > .comment-body > .comment-votes .vote-btn').forEach((b) => {
+            b.classList.toggle('voted', Number(b.dataset.value) === myVote)
+          })
+        } catch (err) {
+          console.error('[COMMENT] vote failed:', err)
+        }
+      })
+    })
+
+    item.querySelector('.comment-accept-btn')?.addEventListener('click', async () => {
+      try {
+        const res = await apiSetAcceptedAnswer(Number(post.id), c.accepted ? 0 : Number(c.id))
+        post.accepted_comment_id = res?.post?.accepted_comment_id ?? null
+        const badge = container.querySelector('.post-qa-badge')
+        if (badge) {
+          badge.classList.toggle('solved', Boolean(post.accepted_comment_id))
+          badge.textContent = post.accepted_comment_id ? '✔ Solved' : '? Unsolved'
+        }
+        // The accepted answer leads the list, so reload it.
+        loadComments(true)
+      } catch (err) {
+        console.error('[COMMENT] accept failed:', err)
+        alert('Could not accept this answer.')
+      }
+    })
+
+    // ---- REPLY ----
+    const body = item.querySelector('.comment-body')
+    const repliesEl = body.querySelector('.comment-replies')
+
+    item.querySelector('.comment-reply-btn')?.addEventListener('click', () => {
+      if (body.querySelector(':scope > .comment-reply-form')) return
+
+      const replyForm = document.createElement('form')
+      replyForm.className = 'comment-form comment-reply-form'
+      replyForm.innerHTML = `
+        <textarea placeholder="Reply to ${escapeHtml(c.author || 'comment')}…" maxlength="20000" required></textarea>
+        <button type="submit">Reply</button>
+        <button type="button" class="nav-btn comment-cancel">Cancel</button>
+      `
+      body.insertBefore(replyForm, repliesEl)
+
+      const input = replyForm.querySelector('textarea')
+      input.focus()
+      replyForm.querySelector('.comment-cancel').addEventListener('click', () => replyForm.remove())
+      replyForm.addEventListener('submit', async (e) => {
+        e.preventDefault()
+        const content = input.value.trim()
+        if (!content) return
+
+        try {
+          const { comment } = await apiAddComment(post.id, content, c.id)
+          appendComment(comment)
+          replyForm.remove()
+          bumpCommentsCount()
+        } catch (err) {
+          console.error('[COMMENT] reply failed:', err)
+          alert('Could not post reply. Please try again.')
+        }
+      })
+    })
+
+    // ---- MORE REPLIES (root comments only) ----
+    const moreBtn = body.querySelector('.comment-more-replies')
+    moreBtn?.addEventListener('click', async () => {
+      moreBtn.disabled = true
+      try {
+        const res = await apiGetReplies(c.id, lastReplyId.get(Number(c.id)) || 0)
+        const replies = res.replies || []
+        replies.forEach((r) => {
+          trackLoaded(r)
+          appendComment(r)
+        })
+        if (!res.has_more) moreBtn.remove()
+      } catch (err) {
+        console.error('[COMMENT] load replies failed:', err)
+        alert('Could not load replies.')
+      } finally {
+        moreBtn.disabled = false
+      }
+    })
+
+    // Replies nest under their parent; the server lists parents first.
+    const parentEl = c.parent_id ? commentEls.get(Number(c.parent_id)) : null
+    if (parentEl) {
+      parentEl.querySelector(':scope > .comment-body > .comment-replies').appendChild(item)
+    } else if (prepend) {
+      listEl.prepend(item)
+    } else {
+      listEl.appendChild(item)
+    }
+    commentEls.set(Number(c.id), item)
+  }
+
+  // ---- COMMENT PAGES ----
+  const orderSelect = container.querySelector('.comments-order')
+  const loadMoreBtn = container.querySelector('.comments-load-more')
+  let commentsAfter = 0
+  let commentsCursor = '' // order=top pages by cursor instead
+  let loadingComments = false
+
+  async function loadComments(reset = false) {
+    if (loadingComments) return
+    loadingComments = true
+    loadMoreBtn.disabled = true
+
+    if (reset) {
+      listEl.innerHTML = ''
+      commentEls.clear()
+      lastReplyId.clear()
+      commentsAfter = 0
+      commentsCursor = ''
+    }
+
+    try {
+      const res = await apiGetComments(post.id, { after: commentsAfter, cursor: commentsCursor, order: orderSelect.value })
+      const page = res.comments || []
+      page.forEach((c) => {
+        trackLoaded(c)
+        appendComment(c)
+      })
+      commentsAfter = Number(res.next_after) || 0
+      commentsCursor = res.next_cursor || ''
+      loadMoreBtn.style.display = res.has_more ? '' : 'none'
+    } catch (err) {
+      console.error('[COMMENT] load failed:', err)
+      alert('Could not load comments.')
+    } finally {
+      loadingComments = false
+      loadMoreBtn.disabled = false
+    }
   }
 
-  comments.forEach(appendComment)
+  orderSelect.addEventListener('change', () => loadComments(true))
+  loadMoreBtn.addEventListener('click', () => loadComments())
+  loadComments()
+
+  // ---- LIVE UPDATES ----
+  // New comments, deletions, reactions and edits by others arrive live.
+  const unsubscribeLive = subscribeWS('post', post.id)
+  const unsubscribeComments = onWSMessage((ev) => {
+    if (!container.isConnected) {
+      unsubscribeComments()
+      unsubscribeLive()
+      return
+    }
+    if (Number(ev?.post_id) !== Number(post.id)) return
+
+    switch (ev.type) {
+      case 'comment_deleted':
+        applyCommentDeletion(ev.comment_id, ev.tombstoned, ev.removed)
+        break
+
+      case 'comment_created': {
+        // Our own comments are added from the POST response.
+        const c = ev.comment
+        if (!c || Number(c.user_id) === myId || commentEls.has(Number(c.id))) break
+        bumpCommentsCount()
+        // Replies to threads not on screen, and roots that belong on a
+        // later page, show up when those are loaded.
+        const moreRootsPending = loadMoreBtn.style.display !== 'none' && orderSelect.value !== 'newest'
+        if (c.parent_id ? commentEls.has(Number(c.parent_id)) : !moreRootsPending) {
+          appendComment(c, !c.parent_id && orderSelect.value === 'newest')
+        }
+        break
+      }
+
+      case 'reaction_changed': {
+        const likes = String(Number(ev.reactions?.like) || 0)
+        const countEl = ev.comment_id
+          ? commentEls.get(Number(ev.comment_id))?.querySelector(':scope > .comment-body > .comment-like-btn .comment-like-count')
+          : container.querySelector('.post-meta-right .reaction-count')
+        if (countEl) countEl.textContent = likes
+        break
+      }
+
+      case 'post_updated':
+        if (typeof ev.views_count === 'number') {
+          const viewsEl = container.querySelector('#postViews')
+          if (viewsEl) viewsEl.textContent = `👁 ${ev.views_count} views`
+        }
+        // Leave the page alone while its author is editing it.
+        if (ev.post && !container.classList.contains('is-editing-post')) {
+          const titleEl = container.querySelector('#postTitle')
+          const contentEl = container.querySelector('#postContent')
+          const categoryEl = container.querySelector('#postCategory')
+          if (titleEl) titleEl.textContent = ev.post.title || 'Untitled'
+          if (contentEl) contentEl.innerHTML = ev.post.content_html || escapeHtml(ev.post.content || '')
+          if (categoryEl) categoryEl.textContent = ev.post.category || 'General'
+          post.accepted_comment_id = ev.post.accepted_comment_id ?? null
+          const badge = container.querySelector('.post-qa-badge')
+          if (badge) {
+            badge.classList.toggle('solved', Boolean(post.accepted_comment_id))
+            badge.textContent = post.accepted_comment_id ? '✔ Solved' : '? Unsolved'
+          }
+        }
+        break
+    }
+  })
 
   // ---- ADD COMMENT ----
   const form = container.querySelector('#commentForm')
@@ -243,16 +624,174 @@ export async function renderPostView(root, postId) {
 
     try {
       const { comment } = await apiAddComment(post.id, content)
-      appendComment(comment)
+      appendComment(comment, orderSelect.value === 'newest')
       textarea.value = ''
-      const titleEl = container.querySelector('.comments-title')
-      titleEl.textContent = `Comments (${listEl.children.length})`
+      bumpCommentsCount()
     } catch (err) {
       console.error('Failed to add comment:', err)
       alert('Could not add comment. Please try again.')
     }
   })
 
+  // ---- POLL ----
+  const pollEl = container.querySelector('#postPoll')
+  let poll = post?.poll || null
+
+  function renderPoll() {
+    if (!poll) {
+      pollEl.remove()
+      return
+    }
+    const total = Number(poll.total_votes) || 0
+    const mine = new Set((poll.my_votes || []).map(Number))
+    const canVote = myId > 0 && !poll.closed && mine.size === 0
+    const inputType = poll.multiple ? 'checkbox' : 'radio'
+    const closes = poll.closes_at ? new Date(poll.closes_at).toLocaleString() : ''
+
+    pollEl.innerHTML = `
+      <h2 class="poll-question">📊 ${escapeHtml(poll.question)}</h2>
+      <div class="poll-options">
+        ${(poll.options || [])
+          .map((o) => {
+            const votes = Number(o.votes) || 0
+            const pct = total ? Math.round((votes / total) * 100) : 0
+            const voters = Array.isArray(o.voters) && o.voters.length ? ` title="${escapeHtml(o.voters.join(', '))}"` : ''
+            return `
+            <label class="poll-option ${mine.has(Number(o.id)) ? 'mine' : ''}"${voters}>
+              ${canVote ? `<input type="${inputType}" name="pollOption" value="${Number(o.id)}" />` : ''}
+              <span class="poll-label">${escapeHtml(o.label)}</span>
+              <span class="poll-count">${votes}</span>
+              <span class="poll-bar" style="width:${pct}%"></span>
+            </label>`
+          })
+          .join('')}
+      </div>
+      <div class="poll-meta">
+        <span>${total} ${total === 1 ? 'voter' : 'voters'}</span>
+        <span>${poll.anonymous ? 'Anonymous' : 'Public'} · ${poll.multiple ? 'multiple choice' : 'single choice'}</span>
+        ${poll.closed ? `<span>Closed</span>` : closes ? `<span>Closes ${escapeHtml(closes)}</span>` : ``}
+        ${canVote ? `<button type="button" class="nav-btn" id="pollVoteBtn">Vote</button>` : ``}
+      </div>
+    `
+
+    pollEl.querySelector('#pollVoteBtn')?.addEventListener('click', async () => {
+      const ids = [...pollEl.querySelectorAll('input[name="pollOption"]:checked')].map((i) => Number(i.value))
+      if (!ids.length) return
+      try {
+        const updated = await apiVotePoll(post.id, ids)
+        if (updated) poll = updated
+        renderPoll()
+      } catch (err) {
+        console.error('[POLL] vote failed:', err)
+        alert('Could not record your vote.')
+      }
+    })
+  }
+
+  renderPoll()
+
+  // Live counts from other voters.
+  if (poll) {
+    const unsubscribe = onWSMessage((ev) => {
+      if (!container.isConnected) {
+        unsubscribe()
+        return
+      }
+      if (ev?.type !== 'poll_update' || Number(ev.post_id) !== pid || !poll) return
+      poll.total_votes = ev.total_votes
+      for (const o of poll.options || []) {
+        o.votes = Number(ev.votes?.[o.id] ?? o.votes)
+      }
+      renderPoll()
+    })
+  }
+
+  // ---- MODERATION (pin / lock) ----
+  const rerender = () => {
+    container.remove()
+    renderPostView(root, postId)
+  }
+  container.querySelector('#pinPostBtn')?.addEventListener('click', async () => {
+    try {
+      await apiSetPinned(post.id, !post.pinned)
+      rerender()
+    } catch (err) {
+      console.error('Failed to pin post:', err)
+      alert('Could not update pin.')
+    }
+  })
+  container.querySelector('#lockPostBtn')?.addEventListener('click', async () => {
+    try {
+      await apiSetLocked(post.id, !post.locked)
+      rerender()
+    } catch (err) {
+      console.error('Failed to lock post:', err)
+      alert('Could not update lock.')
+    }
+  })
+
+  // ---- FOLLOW (author / category) ----
+  const followAuthorBtn = container.querySelector('#followAuthorBtn')
+  const followCategoryBtn = container.querySelector('#followCategoryBtn')
+  if (followAuthorBtn || followCategoryBtn) {
+    const setLabel = (btn, on, what) => {
+      btn.dataset.on = on ? '1' : ''
+      btn.textContent = on ? `Following ${what}` : `Follow ${what}`
+      btn.classList.toggle('following', on)
+      btn.hidden = false
+    }
+    const toggle = (btn, what, update) => {
+      btn?.addEventListener('click', async () => {
+        const next = !btn.dataset.on
+        btn.disabled = true
+        try {
+          await update(next)
+          setLabel(btn, next, what)
+        } catch (err) {
+          console.error('[FOLLOW] Update failed:', err)
+          alert('Could not update follow. Please try again.')
+        } finally {
+          btn.disabled = false
+        }
+      })
+    }
+
+    try {
+      const follows = await apiGetFollows()
+      const category = String(post?.category || '')
+      if (followAuthorBtn) setLabel(followAuthorBtn, follows.users.some((u) => Number(u.id) === ownerId), 'author')
+      if (followCategoryBtn)
+        setLabel(
+          followCategoryBtn,
+          follows.categories.some((c) => c.name.toLowerCase() === category.toLowerCase()),
+          category,
+        )
+    } catch (err) {
+      console.error('[FOLLOW] Failed to load follows:', err)
+    }
+
+    toggle(followAuthorBtn, 'author', (on) => apiSetFollowUser(ownerId, on))
+    toggle(followCategoryBtn, String(post?.category || ''), (on) => apiSetFollowCategory(post.category, on))
+  }
+
+  // ---- BOOKMARK ----
+  const bookmarkBtn = container.querySelector('.bookmark-btn')
+  bookmarkBtn?.addEventListener('click', async () => {
+    if (!pid) return
+    const next = !bookmarkBtn.classList.contains('bookmarked')
+    bookmarkBtn.disabled = true
+    try {
+      await apiSetBookmark(pid, next)
+      bookmarkBtn.classList.toggle('bookmarked', next)
+      bookmarkBtn.textContent = next ? '★ Saved' : '☆ Save'
+    } catch (err) {
+      console.error('[BOOKMARK] Update failed:', err)
+      alert('Could not update bookmark. Please try again.')
+    } finally {
+      bookmarkBtn.disabled = false
+    }
+  })
+
   // ---- REACTION TOGGLE ----
   const reactionBtn = container.querySelector('.reaction-btn')
   if (reactionBtn) {
@@ -334,7 +873,16 @@ export async function renderPostView(root, postId) {
       meta?.insertAdjacentElement('afterend', catbar)
 
       // content textarea
-      contentEl.outerHTML = `<textarea id="postContentInput" class="post-edit-content">${escapeHtml(prevContent)}</textarea>`
+      contentEl.outerHTML = `<textarea id="postContentInput" class="post-edit-content" maxlength="20000">${escapeHtml(prevContent)}</textarea>`
+
+      // tags (comma separated)
+      container.querySelector('#postTags')?.remove()
+      const tagsInput = document.createElement('input')
+      tagsInput.id = 'postTagsInput'
+      tagsInput.className = 'post-edit-cat-new'
+      tagsInput.placeholder = 'Tags, comma separated'
+      tagsInput.value = (post?.tags || []).join(', ')
+      container.querySelector('#postContentInput')?.insertAdjacentElement('afterend', tagsInput)
 
       // chips
       const catsEl = container.querySelector('#postEditCats')
@@ -392,6 +940,11 @@ export async function renderPostView(root, postId) {
         const nextTitle = titleInput.value.trim()
         const nextContent = contentInput.value.trim()
         const nextCategory = (selectedCategory || 'General').trim()
+        const nextTags = container
+          .querySelector('#postTagsInput')
+          .value.split(',')
+          .map((t) => t.trim())
+          .filter(Boolean)
 
         if (!nextTitle || !nextContent) {
           alert('Title and content are required.')
@@ -399,7 +952,7 @@ export async function renderPostView(root, postId) {
         }
 
         try {
-          await apiUpdatePost(pid, { title: nextTitle, content: nextContent, category: nextCategory })
+          await apiUpdatePost(pid, { title: nextTitle, content: nextContent, category: nextCategory, tags: nextTags })
           location.hash = '#_'
           setTimeout(() => {
             navigateTo(`post/${pid}`)
@@ -407,7 +960,7 @@ export async function renderPostView(root, postId) {
           //  return to detail
         } catch (err) {
           console.error('[POST] update failed:', err)
-          alert('Could not update post. Please try again.')
+          alert(err?.status === 403 ? 'This post can no longer be edited.' : 'Could not update post. Please try again.')
         }
       })
     })
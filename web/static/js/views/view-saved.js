@@ -0,0 +1,65 @@
+// web/static/js/views/view-saved.js
+// The current user's bookmarked posts, most recently saved first.
+
+import { apiGetBookmarks } from '../api.js'
+import { renderPostCard } from '../components/post-card.js'
+import { navigateTo } from '../router.js'
+
+const PAGE_SIZE = 10
+
+export async function renderSavedView(root) {
+  root.innerHTML = ''
+
+  const title = document.createElement('h2')
+  title.className = 'saved-title'
+  title.textContent = 'Saved posts'
+  root.appendChild(title)
+
+  const list = document.createElement('div')
+  list.className = 'feed-list'
+  root.appendChild(list)
+
+  const loadMoreWrap = document.createElement('div')
+  loadMoreWrap.className = 'feed-load-more'
+  loadMoreWrap.style.display = 'none'
+
+  const loadMoreBtn = document.createElement('button')
+  loadMoreBtn.type = 'button'
+  loadMoreBtn.className = 'nav-btn'
+  loadMoreBtn.textContent = 'Load more'
+
+  loadMoreWrap.appendChild(loadMoreBtn)
+  root.appendChild(loadMoreWrap)
+
+  let cursor = ''
+  let firstPage = true
+
+  async function loadPage() {
+    loadMoreBtn.disabled = true
+    try {
+      const res = await apiGetBookmarks(PAGE_SIZE, cursor)
+
+      if (firstPage && res.posts.length === 0) {
+        list.innerHTML = `<p class="feed-empty">No saved posts yet. Use ☆ on a post to save it.</p>`
+        return
+      }
+
+      res.posts.forEach((p) => {
+        list.appendChild(renderPostCard(p, () => navigateTo(`post/${p.id}`)))
+      })
+
+      cursor = res.nextCursor
+      firstPage = false
+      loadMoreWrap.style.display = res.hasMore && cursor ? 'flex' : 'none'
+    } catch (err) {
+      console.error('[SAVED] Failed to load bookmarks:', err)
+      list.innerHTML = `<p class="feed-empty">Could not load saved posts. Please try again.</p>`
+    } finally {
+      loadMoreBtn.disabled = false
+    }
+  }
+
+  loadMoreBtn.addEventListener('click', loadPage)
+
+  await loadPage()
+}
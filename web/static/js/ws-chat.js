@@ -6,10 +6,18 @@ let reconnectAttempt = 0
 
 const outbox = []
 const msgListeners = new Set()
+
+// Live feed subscriptions: frame key -> { frame, count }. The server forgets
+// them with the connection, so they are sent again on every (re)connect.
+const subscriptions = new Map()
 const statusListeners = new Set()
 
 let isOpen = false
 
+// Last chat event seq this tab has seen (message/delivered/seen carry one).
+// Reconnects pass it as ?since= so the server replays what we missed.
+let lastSeq = null
+
 // ✅ gate
 let shouldReconnect = false
 
@@ -40,7 +48,8 @@ function emitStatus() {
 
 function buildWSUrl() {
   const proto = location.protocol === 'https:' ? 'wss:' : 'ws:'
-  return `${proto}//${location.host}/ws/chat`
+  const since = lastSeq !== null ? `?since=${lastSeq}` : ''
+  return `${proto}//${location.host}/ws/chat${since}`
 }
 
 /**
@@ -56,6 +65,7 @@ export function enableWS() {
  */
 export function disableWS() {
   shouldReconnect = false
+  lastSeq = null
   closeWS({ clearOutbox: true })
 }
 
@@ -91,12 +101,21 @@ export function connectWS() {
 
     console.log('[WS] connected')
     emitStatus()
+    for (const { frame } of subscriptions.values()) sendNow(frame)
     flushOutbox()
   }
 
   socket.onmessage = (e) => {
     try {
       const data = JSON.parse(e.data)
+      if (data?.type === 'synced') {
+        // End of the replay: the server's seq is authoritative from here on.
+        // Listeners reload their history when the replay was incomplete.
+        lastSeq = Number(data.seq) || 0
+      } else if (typeof data?.seq === 'number') {
+        if (lastSeq !== null && data.seq <= lastSeq) return // already seen
+        lastSeq = data.seq
+      }
       emitMessage(data)
     } catch {
       console.warn('[WS] invalid JSON', e.data)
@@ -194,6 +213,42 @@ export function sendWS(payload) {
   }
 }
 
+// sendNow sends a frame only if the socket is open; nothing is queued.
+function sendNow(payload) {
+  if (!socket || socket.readyState !== WebSocket.OPEN) return
+  try {
+    socket.send(JSON.stringify(payload))
+  } catch (_) {}
+}
+
+/**
+ * Subscribes to live post events (post_created, comment_created,
+ * reaction_changed, post_updated, ...):
+ *   subscribeWS('feed'), subscribeWS('category', 'Travel'), subscribeWS('post', 12)
+ * Returns a function that cancels this subscription.
+ */
+export function subscribeWS(scope, value) {
+  const frame = { type: 'subscribe', scope }
+  if (scope === 'category') frame.category = String(value)
+  if (scope === 'post') frame.post_id = Number(value)
+  const key = JSON.stringify(frame)
+
+  const sub = subscriptions.get(key) || { frame, count: 0 }
+  sub.count += 1
+  subscriptions.set(key, sub)
+  if (sub.count === 1) sendNow(frame)
+
+  let cancelled = false
+  return () => {
+    if (cancelled) return
+    cancelled = true
+    sub.count -= 1
+    if (sub.count > 0) return
+    subscriptions.delete(key)
+    sendNow({ ...frame, type: 'unsubscribe' })
+  }
+}
+
 export function closeWS({ clearOutbox = false } = {}) {
   // ✅ CHANGE:
   // When closing explicitly (logout), we should stop reconnecting.